@@ -5,8 +5,10 @@ package common
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 )
@@ -17,8 +19,12 @@ const BuilderId = "mitchellh.virtualbox"
 // Artifact is the result of running the VirtualBox builder, namely a set
 // of files associated with the resulting machine.
 type artifact struct {
-	dir string
-	f   []string
+	dir      string
+	f        []string
+	driver   Driver
+	metadata *ArtifactMetadata
+	// The disk image of an export_disk_only build
+	disk string
 
 	// StateData should store data such as GeneratedData
 	// to be shared with post-processors
@@ -26,8 +32,10 @@ type artifact struct {
 }
 
 // NewArtifact returns a VirtualBox artifact containing the files
-// in the given directory.
-func NewArtifact(dir string, generatedData map[string]interface{}) (packersdk.Artifact, error) {
+// in the given directory, described by metadata if not nil. If driver is
+// not nil, destroying the artifact also deletes what the build left
+// registered.
+func NewArtifact(dir string, driver Driver, metadata *ArtifactMetadata, generatedData map[string]interface{}) (packersdk.Artifact, error) {
 	files := make([]string, 0, 5)
 	visit := func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -44,9 +52,33 @@ func NewArtifact(dir string, generatedData map[string]interface{}) (packersdk.Ar
 		return nil, err
 	}
 
+	if metadata == nil {
+		metadata = new(ArtifactMetadata)
+	}
 	return &artifact{
 		dir:       dir,
 		f:         files,
+		driver:    driver,
+		metadata:  metadata,
+		StateData: generatedData,
+	}, nil
+}
+
+// NewDiskArtifact returns a VirtualBox artifact made of the disk image of
+// the single export of metadata, and the manifest of the artifact.
+func NewDiskArtifact(dir string, driver Driver, metadata *ArtifactMetadata, generatedData map[string]interface{}) (packersdk.Artifact, error) {
+	if len(metadata.Exports) != 1 {
+		return nil, fmt.Errorf("a disk artifact has one export, not %d", len(metadata.Exports))
+	}
+	export := metadata.Exports[0]
+	files := append([]string{}, export.Files...)
+	files = append(files, filepath.Join(dir, ArtifactManifestFilename))
+	return &artifact{
+		dir:       dir,
+		f:         files,
+		driver:    driver,
+		metadata:  metadata,
+		disk:      export.Path,
 		StateData: generatedData,
 	}, nil
 }
@@ -59,18 +91,132 @@ func (a *artifact) Files() []string {
 	return a.f
 }
 
-func (*artifact) Id() string {
+// Id returns the UUID of the VM built, if known.
+func (a *artifact) Id() string {
+	if a.metadata.VMUUID != "" {
+		return a.metadata.VMUUID
+	}
 	return "VM"
 }
 
 func (a *artifact) String() string {
+	if a.disk != "" {
+		return fmt.Sprintf("VM disk image: %s", a.disk)
+	}
+	if len(a.metadata.Exports) > 1 {
+		var formats []string
+		for _, e := range a.metadata.Exports {
+			formats = append(formats, e.Format)
+		}
+		return fmt.Sprintf("VM files in directory: %s (%s)", a.dir, strings.Join(formats, ", "))
+	}
 	return fmt.Sprintf("VM files in directory: %s", a.dir)
 }
 
+// The state of the artifact also holds, for post-processors, the formats
+// exported as "export_formats", the files of each format as
+// "export_files.<format>", the path to the manifest of the artifact as
+// "manifest", and the fields of the manifest by their JSON names, such as
+// "vm_uuid" or "disks".
 func (a *artifact) State(name string) interface{} {
+	if name == "manifest" {
+		return filepath.Join(a.dir, ArtifactManifestFilename)
+	}
+	if value, ok := a.metadata.state(name); ok {
+		return value
+	}
+	if name == "export_formats" {
+		var formats []interface{}
+		for _, e := range a.metadata.Exports {
+			formats = append(formats, e.Format)
+		}
+		return formats
+	}
+	if format, ok := strings.CutPrefix(name, "export_files."); ok {
+		for _, e := range a.metadata.Exports {
+			if e.Format == format {
+				var files []interface{}
+				for _, f := range e.Files {
+					files = append(files, f)
+				}
+				return files
+			}
+		}
+		return nil
+	}
 	return a.StateData[name]
 }
 
+// Destroy deletes the output directory. With a driver, it first deletes
+// the snapshot the build took and the VM it kept registered, if any, unless
+// the VM is running.
 func (a *artifact) Destroy() error {
-	return os.RemoveAll(a.dir)
+	var errs *packersdk.MultiError
+	if a.driver != nil && a.metadata.VMUUID != "" {
+		if err := a.destroyRegistered(); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+	if err := os.RemoveAll(a.dir); err != nil {
+		errs = packersdk.MultiErrorAppend(errs, err)
+	}
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (a *artifact) destroyRegistered() error {
+	if !a.metadata.Registered && a.metadata.SnapshotUUID == "" {
+		return nil
+	}
+
+	// The VM may be gone already, or its name taken by another one: look
+	// it up by UUID
+	output, err := a.driver.VBoxManageWithOutput("list", "vms")
+	if err != nil {
+		return fmt.Errorf("Error listing VMs: %s", err)
+	}
+	registered := false
+	for _, vm := range parseVMList(output) {
+		registered = registered || vm.UUID == a.metadata.VMUUID
+	}
+	if !registered {
+		log.Printf("VM %s is not registered anymore", a.metadata.VMUUID)
+		return nil
+	}
+
+	running, err := a.driver.IsRunning(a.metadata.VMUUID)
+	if err != nil {
+		return fmt.Errorf("Error checking if VM %s is running: %s", a.metadata.VMName, err)
+	}
+	if running {
+		return fmt.Errorf("VM %s is running, not deleting it or its snapshot", a.metadata.VMName)
+	}
+
+	if a.metadata.Registered {
+		log.Printf("Deleting VM %s (%s)", a.metadata.VMName, a.metadata.VMUUID)
+		if err := a.driver.Delete(a.metadata.VMUUID); err != nil {
+			return fmt.Errorf("Error deleting VM %s: %s", a.metadata.VMName, err)
+		}
+		return nil
+	}
+
+	snapshots, err := a.driver.LoadSnapshots(a.metadata.VMUUID)
+	if err != nil {
+		return fmt.Errorf("Error loading the snapshots of VM %s: %s", a.metadata.VMName, err)
+	}
+	var snapshot *VBoxSnapshot
+	if snapshots != nil {
+		snapshot = snapshots.GetSnapshotByUUID(a.metadata.SnapshotUUID)
+	}
+	if snapshot == nil {
+		log.Printf("Snapshot %s of VM %s is gone already", a.metadata.SnapshotUUID, a.metadata.VMName)
+		return nil
+	}
+	log.Printf("Deleting snapshot %s of VM %s", a.metadata.SnapshotUUID, a.metadata.VMName)
+	if err := a.driver.DeleteSnapshot(a.metadata.VMUUID, snapshot); err != nil {
+		return fmt.Errorf("Error deleting snapshot %s of VM %s: %s", snapshot.Name, a.metadata.VMName, err)
+	}
+	return nil
 }
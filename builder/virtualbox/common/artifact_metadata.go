@@ -0,0 +1,96 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ArtifactManifestFilename is the name of the file describing the artifact,
+// written to the output directory.
+const ArtifactManifestFilename = "packer-virtualbox-manifest.json"
+
+// ArtifactMetadata describes what a build produced, for post-processors and
+// other tools to read instead of looking through the output directory.
+type ArtifactMetadata struct {
+	VMName            string `json:"vm_name"`
+	VMUUID            string `json:"vm_uuid"`
+	VirtualBoxVersion string `json:"virtualbox_version"`
+	// The exported .ovf or .ova, if any.
+	Descriptor string         `json:"descriptor,omitempty"`
+	Disks      []ArtifactDisk `json:"disks"`
+	// The SHA256 digests of the exported files, by path relative to the
+	// output directory.
+	Checksums map[string]string `json:"checksums"`
+	// The snapshot the build took of the VM, if any.
+	SnapshotUUID string `json:"snapshot_uuid,omitempty"`
+	// The VM built is still registered with VirtualBox, with
+	// keep_registered.
+	Registered bool           `json:"registered"`
+	Exports    []ExportOutput `json:"exports"`
+}
+
+// ArtifactDisk is an exported disk image.
+type ArtifactDisk struct {
+	Path string `json:"path"`
+	// The OVA holding the disk image, if it is in one.
+	Archive string `json:"archive,omitempty"`
+	Format  string `json:"format"`
+	// The size of the disk seen by the VM.
+	VirtualSize int64 `json:"virtual_size"`
+	// The size of the disk image.
+	ActualSize int64 `json:"actual_size"`
+}
+
+// writeManifest writes the metadata as JSON to the manifest of the artifact
+// in dir, and returns its path.
+func (m *ArtifactMetadata) writeManifest(dir string) (string, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, ArtifactManifestFilename)
+	return path, os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// state returns the artifact state of the metadata, made of the types
+// that can be sent to post-processors.
+func (m *ArtifactMetadata) state(name string) (interface{}, bool) {
+	switch name {
+	case "vm_name":
+		return m.VMName, true
+	case "vm_uuid":
+		return m.VMUUID, true
+	case "virtualbox_version":
+		return m.VirtualBoxVersion, true
+	case "descriptor":
+		return m.Descriptor, true
+	case "snapshot_uuid":
+		return m.SnapshotUUID, true
+	case "registered":
+		return m.Registered, true
+	case "checksums":
+		checksums := make(map[string]string, len(m.Checksums))
+		for path, checksum := range m.Checksums {
+			checksums[path] = checksum
+		}
+		return checksums, true
+	case "disks":
+		var disks []interface{}
+		for _, d := range m.Disks {
+			disks = append(disks, map[string]string{
+				"path":         d.Path,
+				"archive":      d.Archive,
+				"format":       d.Format,
+				"virtual_size": strconv.FormatInt(d.VirtualSize, 10),
+				"actual_size":  strconv.FormatInt(d.ActualSize, 10),
+			})
+		}
+		return disks, true
+	}
+	return nil, false
+}
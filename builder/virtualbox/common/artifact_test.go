@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -33,7 +34,7 @@ func TestNewArtifact(t *testing.T) {
 	}
 
 	generatedData := map[string]interface{}{"generated_data": "data"}
-	a, err := NewArtifact(td, generatedData)
+	a, err := NewArtifact(td, nil, nil, generatedData)
 	if err != nil {
 		t.Fatalf("err: %s", err)
 	}
@@ -48,3 +49,199 @@ func TestNewArtifact(t *testing.T) {
 		t.Fatalf("bad: should length have generated_data: %s", a.State("generated_data"))
 	}
 }
+
+func TestArtifactExports(t *testing.T) {
+	exports := []ExportOutput{
+		{Format: "ova", Path: "out/foo.ova", Files: []string{"out/foo.ova"}},
+		{Format: "raw", Path: "out/foo.raw", Files: []string{"out/foo.raw", "out/foo-2.raw"}},
+	}
+	a := &artifact{dir: "out", metadata: &ArtifactMetadata{Exports: exports}}
+
+	if s := a.String(); s != "VM files in directory: out (ova, raw)" {
+		t.Fatalf("bad: %s", s)
+	}
+	if formats := a.State("export_formats"); !reflect.DeepEqual(formats, []interface{}{"ova", "raw"}) {
+		t.Fatalf("bad formats: %#v", formats)
+	}
+	if files := a.State("export_files.raw"); !reflect.DeepEqual(files, []interface{}{"out/foo.raw", "out/foo-2.raw"}) {
+		t.Fatalf("bad files: %#v", files)
+	}
+	if files := a.State("export_files.ovf"); files != nil {
+		t.Fatalf("bad files: %#v", files)
+	}
+}
+
+func TestNewDiskArtifact(t *testing.T) {
+	export := ExportOutput{Format: "raw", Path: "out/foo.raw.gz", Files: []string{"out/foo.raw.gz"}}
+	a, err := NewDiskArtifact("out", nil, &ArtifactMetadata{Exports: []ExportOutput{export}}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(a.Files(), []string{"out/foo.raw.gz", filepath.Join("out", ArtifactManifestFilename)}) {
+		t.Fatalf("bad files: %v", a.Files())
+	}
+	if s := a.String(); s != "VM disk image: out/foo.raw.gz" {
+		t.Fatalf("bad string: %s", s)
+	}
+	if formats := a.State("export_formats"); !reflect.DeepEqual(formats, []interface{}{"raw"}) {
+		t.Fatalf("bad formats: %v", formats)
+	}
+}
+
+func TestArtifactMetadata(t *testing.T) {
+	metadata := &ArtifactMetadata{
+		VMName:            "packer-ubuntu",
+		VMUUID:            "00000000-0000-0000-0000-000000000001",
+		VirtualBoxVersion: "7.0.14",
+		Descriptor:        "out/packer-ubuntu.ovf",
+		Disks: []ArtifactDisk{
+			{Path: "out/packer-ubuntu-disk001.vmdk", Format: "vmdk", VirtualSize: 40 << 30, ActualSize: 1 << 30},
+		},
+		Checksums: map[string]string{"packer-ubuntu.ovf": "abc"},
+	}
+	a, err := NewArtifact(t.TempDir(), nil, metadata, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if a.Id() != "00000000-0000-0000-0000-000000000001" {
+		t.Fatalf("bad id: %s", a.Id())
+	}
+	for name, value := range map[string]interface{}{
+		"vm_name":            "packer-ubuntu",
+		"virtualbox_version": "7.0.14",
+		"descriptor":         "out/packer-ubuntu.ovf",
+		"snapshot_uuid":      "",
+		"registered":         false,
+		"checksums":          map[string]string{"packer-ubuntu.ovf": "abc"},
+		"disks": []interface{}{map[string]string{
+			"path":         "out/packer-ubuntu-disk001.vmdk",
+			"archive":      "",
+			"format":       "vmdk",
+			"virtual_size": "42949672960",
+			"actual_size":  "1073741824",
+		}},
+	} {
+		if !reflect.DeepEqual(a.State(name), value) {
+			t.Fatalf("bad %s: %#v", name, a.State(name))
+		}
+	}
+
+	a, err = NewArtifact(t.TempDir(), nil, nil, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if a.Id() != "VM" {
+		t.Fatalf("bad id: %s", a.Id())
+	}
+}
+
+func TestArtifactDestroy_registered(t *testing.T) {
+	driver := new(DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list vms": `"packer-ubuntu" {00000000-0000-0000-0000-000000000001}`,
+	}
+	dir := t.TempDir()
+	metadata := &ArtifactMetadata{
+		VMName:     "packer-ubuntu",
+		VMUUID:     "00000000-0000-0000-0000-000000000001",
+		Registered: true,
+	}
+	a, err := NewArtifact(dir, driver, metadata, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := a.Destroy(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !driver.DeleteCalled || driver.DeleteName != metadata.VMUUID {
+		t.Fatalf("should delete the VM: %q", driver.DeleteName)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("should remove the output directory: %s", err)
+	}
+}
+
+func TestArtifactDestroy_running(t *testing.T) {
+	driver := new(DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list vms": `"packer-ubuntu" {00000000-0000-0000-0000-000000000001}`,
+	}
+	driver.IsRunningReturn = true
+	dir := t.TempDir()
+	metadata := &ArtifactMetadata{
+		VMName:     "packer-ubuntu",
+		VMUUID:     "00000000-0000-0000-0000-000000000001",
+		Registered: true,
+	}
+	a, err := NewArtifact(dir, driver, metadata, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := a.Destroy(); err == nil {
+		t.Fatal("should error")
+	}
+	if driver.DeleteCalled {
+		t.Fatal("should not delete a running VM")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("should remove the output directory: %s", err)
+	}
+}
+
+func TestArtifactDestroy_unregistered(t *testing.T) {
+	driver := new(DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list vms": `"packer-ubuntu" {00000000-0000-0000-0000-000000000002}`,
+	}
+	metadata := &ArtifactMetadata{
+		VMName:     "packer-ubuntu",
+		VMUUID:     "00000000-0000-0000-0000-000000000001",
+		Registered: true,
+	}
+	a, err := NewArtifact(t.TempDir(), driver, metadata, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := a.Destroy(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if driver.DeleteCalled {
+		t.Fatal("should not delete another VM with the same name")
+	}
+}
+
+func TestArtifactDestroy_snapshot(t *testing.T) {
+	driver := new(DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list vms": `"base" {00000000-0000-0000-0000-000000000001}`,
+	}
+	snapshots, err := ParseSnapshotData(getTestData())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	driver.LoadSnapshotsResult = snapshots
+	metadata := &ArtifactMetadata{
+		VMName:       "base",
+		VMUUID:       "00000000-0000-0000-0000-000000000001",
+		SnapshotUUID: "8e12833b-c6b5-4cbd-b42b-09eff8ffc173",
+	}
+	a, err := NewArtifact(t.TempDir(), driver, metadata, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := a.Destroy(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if driver.DeleteCalled {
+		t.Fatal("should not delete the VM")
+	}
+	if len(driver.DeleteSnapshotCalled) != 1 || driver.DeleteSnapshotCalled[0].Name != "Snapshot 2" {
+		t.Fatalf("should delete the snapshot: %#v", driver.DeleteSnapshotCalled)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type CloudExportConfig
+
+package common
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// The build variable holding the ID of the image exported to the cloud,
+// available to provisioners and post-processors as
+// `{{ build.CloudImageID }}`.
+var CloudExportGeneratedData = []string{"CloudImageID"}
+
+// CloudExportConfig configures the export of the VM to Oracle Cloud
+// Infrastructure, used when `cloud` is one of the export formats.
+//
+// The export needs VirtualBox 6.0 or later and a cloud profile, created with
+// `VBoxManage cloudprofile add`. The ID of the image is available as
+// `{{ build.CloudImageID }}`.
+type CloudExportConfig struct {
+	// The VirtualBox cloud profile holding the OCI credentials, tenancy,
+	// compartment and region (`--cloudprofile`).
+	Profile string `mapstructure:"profile" required:"true"`
+	// The shape of the instance the image is made for, such as
+	// `VM.Standard2.1` (`--cloudshape`).
+	Shape string `mapstructure:"shape" required:"true"`
+	// The Object Storage bucket the disk is uploaded to (`--cloudbucket`).
+	Bucket string `mapstructure:"bucket" required:"true"`
+	// The display name of the image and instance (`--vmname`). Defaults to
+	// `output_filename`.
+	DisplayName string `mapstructure:"display_name" required:"false"`
+	// The size of the boot volume in GB (`--clouddisksize`). Defaults to the
+	// size of the disk of the VM.
+	DiskSize int `mapstructure:"disk_size" required:"false"`
+	// Keep the uploaded disk in the bucket once the image is created
+	// (`--cloudkeepobject`). Defaults to `false`.
+	KeepObject bool `mapstructure:"keep_object" required:"false"`
+	// Launch an instance of the image (`--cloudlaunchinstance`). Requires
+	// `domain` and `subnet`. Defaults to `false`.
+	LaunchInstance bool `mapstructure:"launch_instance" required:"false"`
+	// The launch mode of the instance, `EMULATED` or `PARAVIRTUALIZED`
+	// (`--cloudlaunchmode`). Defaults to `PARAVIRTUALIZED`.
+	LaunchMode string `mapstructure:"launch_mode" required:"false"`
+	// The availability domain of the instance (`--clouddomain`).
+	Domain string `mapstructure:"domain" required:"false"`
+	// The OCID of the virtual cloud network of the instance
+	// (`--cloudocivcn`).
+	VCN string `mapstructure:"vcn" required:"false"`
+	// The OCID of the subnet of the instance (`--cloudocisubnet`).
+	Subnet string `mapstructure:"subnet" required:"false"`
+	// Give the instance a public IP address (`--cloudpublicip`). Defaults to
+	// `false`.
+	PublicIP bool `mapstructure:"public_ip" required:"false"`
+	// An HTTP endpoint standing in for Oracle Cloud, to test the export
+	// against a local mock. When set, the VM is exported to an OVA which is
+	// uploaded with `PUT <endpoint>/b/<bucket>/o/<display_name>.ova`, then
+	// the image is created with `POST <endpoint>/images`, which gets the
+	// other options as JSON and answers with the image as JSON, such as
+	// `{"id": "ocid1.image.oc1..example"}`. Unless `keep_object` is set, the
+	// upload is then removed with `DELETE`. VBoxManage isn't used for the
+	// upload, and no cloud profile is needed.
+	Endpoint string `mapstructure:"endpoint" required:"false"`
+}
+
+// prepare validates the configuration of a cloud export.
+func (c *CloudExportConfig) prepare() []error {
+	var errs []error
+
+	if c.Endpoint != "" {
+		u, err := url.Parse(c.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("cloud_export: endpoint must be an http or https URL"))
+		}
+	} else if c.Profile == "" {
+		errs = append(errs, fmt.Errorf("cloud_export: profile must be set"))
+	}
+	if c.Shape == "" {
+		errs = append(errs, fmt.Errorf("cloud_export: shape must be set"))
+	}
+	if c.Bucket == "" {
+		errs = append(errs, fmt.Errorf("cloud_export: bucket must be set"))
+	}
+	if c.DiskSize < 0 {
+		errs = append(errs, fmt.Errorf("cloud_export: disk_size must be positive"))
+	}
+
+	if c.LaunchMode == "" {
+		c.LaunchMode = "PARAVIRTUALIZED"
+	}
+	if c.LaunchMode != "EMULATED" && c.LaunchMode != "PARAVIRTUALIZED" {
+		errs = append(errs, fmt.Errorf("cloud_export: launch_mode must be EMULATED or PARAVIRTUALIZED"))
+	}
+	if c.LaunchInstance && (c.Domain == "" || c.Subnet == "") {
+		errs = append(errs, fmt.Errorf("cloud_export: domain and subnet must be set to launch an instance"))
+	}
+
+	return errs
+}
+
+// exportArgs returns the `VBoxManage export` arguments of the cloud export.
+func (c *CloudExportConfig) exportArgs(displayName string) []string {
+	args := []string{
+		"--output", "OCI://",
+		"--cloud", "0",
+		"--vmname", displayName,
+		"--cloudprofile", c.Profile,
+		"--cloudshape", c.Shape,
+		"--cloudbucket", c.Bucket,
+		"--cloudkeepobject", fmt.Sprint(c.KeepObject),
+		"--cloudlaunchinstance", fmt.Sprint(c.LaunchInstance),
+	}
+	if c.DiskSize > 0 {
+		args = append(args, "--clouddisksize", fmt.Sprint(c.DiskSize))
+	}
+	if c.LaunchInstance {
+		args = append(args,
+			"--cloudlaunchmode", c.LaunchMode,
+			"--clouddomain", c.Domain,
+			"--cloudocisubnet", c.Subnet,
+			"--cloudpublicip", fmt.Sprint(c.PublicIP),
+		)
+		if c.VCN != "" {
+			args = append(args, "--cloudocivcn", c.VCN)
+		}
+	}
+	return args
+}
@@ -0,0 +1,55 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package common
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatCloudExportConfig is an auto-generated flat version of CloudExportConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatCloudExportConfig struct {
+	Profile        *string `mapstructure:"profile" required:"true" cty:"profile" hcl:"profile"`
+	Shape          *string `mapstructure:"shape" required:"true" cty:"shape" hcl:"shape"`
+	Bucket         *string `mapstructure:"bucket" required:"true" cty:"bucket" hcl:"bucket"`
+	DisplayName    *string `mapstructure:"display_name" required:"false" cty:"display_name" hcl:"display_name"`
+	DiskSize       *int    `mapstructure:"disk_size" required:"false" cty:"disk_size" hcl:"disk_size"`
+	KeepObject     *bool   `mapstructure:"keep_object" required:"false" cty:"keep_object" hcl:"keep_object"`
+	LaunchInstance *bool   `mapstructure:"launch_instance" required:"false" cty:"launch_instance" hcl:"launch_instance"`
+	LaunchMode     *string `mapstructure:"launch_mode" required:"false" cty:"launch_mode" hcl:"launch_mode"`
+	Domain         *string `mapstructure:"domain" required:"false" cty:"domain" hcl:"domain"`
+	VCN            *string `mapstructure:"vcn" required:"false" cty:"vcn" hcl:"vcn"`
+	Subnet         *string `mapstructure:"subnet" required:"false" cty:"subnet" hcl:"subnet"`
+	PublicIP       *bool   `mapstructure:"public_ip" required:"false" cty:"public_ip" hcl:"public_ip"`
+	Endpoint       *string `mapstructure:"endpoint" required:"false" cty:"endpoint" hcl:"endpoint"`
+}
+
+// FlatMapstructure returns a new FlatCloudExportConfig.
+// FlatCloudExportConfig is an auto-generated flat version of CloudExportConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*CloudExportConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatCloudExportConfig)
+}
+
+// HCL2Spec returns the hcl spec of a CloudExportConfig.
+// This spec is used by HCL to read the fields of CloudExportConfig.
+// The decoded values from this spec will then be applied to a FlatCloudExportConfig.
+func (*FlatCloudExportConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"profile":         &hcldec.AttrSpec{Name: "profile", Type: cty.String, Required: false},
+		"shape":           &hcldec.AttrSpec{Name: "shape", Type: cty.String, Required: false},
+		"bucket":          &hcldec.AttrSpec{Name: "bucket", Type: cty.String, Required: false},
+		"display_name":    &hcldec.AttrSpec{Name: "display_name", Type: cty.String, Required: false},
+		"disk_size":       &hcldec.AttrSpec{Name: "disk_size", Type: cty.Number, Required: false},
+		"keep_object":     &hcldec.AttrSpec{Name: "keep_object", Type: cty.Bool, Required: false},
+		"launch_instance": &hcldec.AttrSpec{Name: "launch_instance", Type: cty.Bool, Required: false},
+		"launch_mode":     &hcldec.AttrSpec{Name: "launch_mode", Type: cty.String, Required: false},
+		"domain":          &hcldec.AttrSpec{Name: "domain", Type: cty.String, Required: false},
+		"vcn":             &hcldec.AttrSpec{Name: "vcn", Type: cty.String, Required: false},
+		"subnet":          &hcldec.AttrSpec{Name: "subnet", Type: cty.String, Required: false},
+		"public_ip":       &hcldec.AttrSpec{Name: "public_ip", Type: cty.Bool, Required: false},
+		"endpoint":        &hcldec.AttrSpec{Name: "endpoint", Type: cty.String, Required: false},
+	}
+	return s
+}
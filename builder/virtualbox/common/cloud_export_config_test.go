@@ -0,0 +1,85 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudExportConfigPrepare(t *testing.T) {
+	tcs := []struct {
+		Name   string
+		Config CloudExportConfig
+		Errs   []string
+	}{
+		{
+			Name:   "profile",
+			Config: CloudExportConfig{Profile: "packer", Shape: "VM.Standard2.1", Bucket: "images"},
+		},
+		{
+			Name:   "endpoint",
+			Config: CloudExportConfig{Endpoint: "http://127.0.0.1:8080", Shape: "VM.Standard2.1", Bucket: "images"},
+		},
+		{
+			Name:   "bad endpoint",
+			Config: CloudExportConfig{Endpoint: "127.0.0.1:8080", Shape: "VM.Standard2.1", Bucket: "images"},
+			Errs:   []string{"cloud_export: endpoint must be an http or https URL"},
+		},
+		{
+			Name: "empty",
+			Errs: []string{
+				"cloud_export: profile must be set",
+				"cloud_export: shape must be set",
+				"cloud_export: bucket must be set",
+			},
+		},
+		{
+			Name: "bad launch",
+			Config: CloudExportConfig{Profile: "packer", Shape: "VM.Standard2.1", Bucket: "images",
+				DiskSize: -1, LaunchInstance: true, LaunchMode: "NATIVE"},
+			Errs: []string{
+				"cloud_export: disk_size must be positive",
+				"cloud_export: launch_mode must be EMULATED or PARAVIRTUALIZED",
+				"cloud_export: domain and subnet must be set to launch an instance",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.Name, func(t *testing.T) {
+			var errs []string
+			for _, err := range tc.Config.prepare() {
+				errs = append(errs, err.Error())
+			}
+			assert.Equal(t, tc.Errs, errs)
+		})
+	}
+}
+
+func TestCloudExportConfig_exportArgs(t *testing.T) {
+	c := CloudExportConfig{Profile: "packer", Shape: "VM.Standard2.1", Bucket: "images"}
+	assert.Empty(t, c.prepare())
+	assert.Equal(t, []string{
+		"--output", "OCI://", "--cloud", "0", "--vmname", "ubuntu",
+		"--cloudprofile", "packer", "--cloudshape", "VM.Standard2.1", "--cloudbucket", "images",
+		"--cloudkeepobject", "false", "--cloudlaunchinstance", "false",
+	}, c.exportArgs("ubuntu"))
+
+	c.DiskSize = 50
+	c.LaunchInstance = true
+	c.Domain = "AD-1"
+	c.Subnet = "ocid1.subnet.oc1..subnet"
+	c.VCN = "ocid1.vcn.oc1..vcn"
+	assert.Equal(t, []string{
+		"--output", "OCI://", "--cloud", "0", "--vmname", "ubuntu",
+		"--cloudprofile", "packer", "--cloudshape", "VM.Standard2.1", "--cloudbucket", "images",
+		"--cloudkeepobject", "false", "--cloudlaunchinstance", "true",
+		"--clouddisksize", "50",
+		"--cloudlaunchmode", "PARAVIRTUALIZED", "--clouddomain", "AD-1",
+		"--cloudocisubnet", "ocid1.subnet.oc1..subnet", "--cloudpublicip", "false",
+		"--cloudocivcn", "ocid1.vcn.oc1..vcn",
+	}, c.exportArgs("ubuntu"))
+}
@@ -7,11 +7,17 @@ package common
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/hashicorp/packer-plugin-sdk/communicator"
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
 )
 
+const (
+	IPFamilyIPv4 = "ipv4"
+	IPFamilyIPv6 = "ipv6"
+)
+
 type CommConfig struct {
 	Comm communicator.Config `mapstructure:",squash"`
 	// The minimum port to use for the Communicator port on the host machine which is forwarded
@@ -26,6 +32,30 @@ type CommConfig struct {
 	// does not setup forwarded port mapping for communicator (SSH or WinRM) requests and uses ssh_port or winrm_port
 	// on the host to communicate to the virtual machine.
 	SkipNatMapping bool `mapstructure:"skip_nat_mapping" required:"false"`
+	// The IP family used to reach the guest, either `ipv4` or `ipv6`. Defaults
+	// to `ipv4`. A NAT adapter has no IPv6, and VBoxManage has no `--natpf6`
+	// option to forward IPv6 ports to it: `ipv6` requires `nat_network`.
+	//
+	// With `ipv6`, the first network adapter is attached to the NAT Network,
+	// the communicator port is forwarded from `::1` on the host with a
+	// `--port-forward-6` rule of the network, the communicator host defaults
+	// to `::1`, and `{{ .HTTPIP }}` resolves to the address of the NAT
+	// Network mapped to the host loopback. Remember to wrap `{{ .HTTPIP }}` in
+	// brackets when building URLs in boot commands, e.g.
+	// `http://[{{ .HTTPIP }}]:{{ .HTTPPort }}/`.
+	IPFamily string `mapstructure:"ip_family" required:"false"`
+	// The name of the VirtualBox NAT Network used with `ip_family = "ipv6"`.
+	// It needs IPv6 and the host loopback mapped to the second address of its
+	// prefix, for the guest to reach the HTTP server, such as:
+	//
+	// ```shell
+	// VBoxManage natnetwork add --netname packer6 --network 10.0.2.0/24 --ipv6 on --loopback-6 2 --enable
+	// ```
+	//
+	// The communicator port is forwarded to the address the guest configures
+	// from its MAC address with SLAAC: the guest must not use privacy or
+	// stable private IPv6 addresses.
+	NATNetwork string `mapstructure:"nat_network" required:"false"`
 
 	// These are deprecated, but we keep them around for backwards compatibility
 	// TODO: remove later
@@ -53,9 +83,25 @@ func (c *CommConfig) Prepare(ctx *interpolate.Context) []error {
 		c.SkipNatMapping = c.SSHSkipNatMapping
 	}
 
+	var errs []error
+	if c.IPFamily == "" {
+		c.IPFamily = IPFamilyIPv4
+	}
+	if c.IPFamily != IPFamilyIPv4 && c.IPFamily != IPFamilyIPv6 {
+		errs = append(errs,
+			fmt.Errorf("ip_family must be one of %q or %q", IPFamilyIPv4, IPFamilyIPv6))
+	}
+	if c.IPFamily == IPFamilyIPv6 && c.NATNetwork == "" {
+		errs = append(errs,
+			errors.New("ip_family = \"ipv6\" requires nat_network, NAT adapters have no IPv6"))
+	}
+	if c.IPFamily != IPFamilyIPv6 && c.NATNetwork != "" {
+		errs = append(errs, errors.New("nat_network is only used with ip_family = \"ipv6\""))
+	}
+
 	if c.Comm.Host() == "" {
-		c.Comm.SSHHost = "127.0.0.1"
-		c.Comm.WinRMHost = "127.0.0.1"
+		c.Comm.SSHHost = LoopbackAddress(c.IPFamily)
+		c.Comm.WinRMHost = LoopbackAddress(c.IPFamily)
 	}
 
 	if c.HostPortMin == 0 {
@@ -66,7 +112,7 @@ func (c *CommConfig) Prepare(ctx *interpolate.Context) []error {
 		c.HostPortMax = 4444
 	}
 
-	errs := c.Comm.Prepare(ctx)
+	errs = append(errs, c.Comm.Prepare(ctx)...)
 	if c.HostPortMin > c.HostPortMax {
 		errs = append(errs,
 			errors.New("host_port_min must be less than host_port_max"))
@@ -74,3 +120,11 @@ func (c *CommConfig) Prepare(ctx *interpolate.Context) []error {
 
 	return errs
 }
+
+// LoopbackAddress returns the host loopback address for the given IP family.
+func LoopbackAddress(ipFamily string) string {
+	if ipFamily == IPFamilyIPv6 {
+		return "::1"
+	}
+	return "127.0.0.1"
+}
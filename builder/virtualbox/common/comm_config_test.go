@@ -182,3 +182,57 @@ eHLc1nkCgYEApm/motCTPN32nINZ+Vvywbv64ZD+gtpeMNP3CLrbe1X9O+H52AXa
 3bfQ8hKYcSnTfE0gPtLDnqCIxTocaGLSHeG3TH9fTw+dA8FvWpUztI4=
 -----END RSA PRIVATE KEY-----
 `
+
+func TestCommConfigPrepare_IPFamily(t *testing.T) {
+	var c *CommConfig
+	var errs []error
+
+	// Default
+	c = testCommConfig()
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("should not have error: %#v", errs)
+	}
+	if c.IPFamily != IPFamilyIPv4 {
+		t.Errorf("bad ip family: %s", c.IPFamily)
+	}
+	if c.Comm.SSHHost != "127.0.0.1" {
+		t.Errorf("bad communicator host: %s", c.Comm.SSHHost)
+	}
+
+	// IPv6
+	c = testCommConfig()
+	c.IPFamily = IPFamilyIPv6
+	c.NATNetwork = "packer6"
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("should not have error: %#v", errs)
+	}
+	if c.Comm.SSHHost != "::1" {
+		t.Errorf("bad communicator host: %s", c.Comm.SSHHost)
+	}
+
+	// Bad, NAT adapters have no IPv6
+	c = testCommConfig()
+	c.IPFamily = IPFamilyIPv6
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+
+	// Bad, the NAT Network is only used with IPv6
+	c = testCommConfig()
+	c.NATNetwork = "packer6"
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+
+	// Bad
+	c = testCommConfig()
+	c.IPFamily = "ipx"
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+}
@@ -64,6 +64,10 @@ type Driver interface {
 	// and returns the stdout channel as string
 	VBoxManageWithOutput(args ...string) (string, error)
 
+	// VBoxManageWithProgress executes the given VBoxManage command and
+	// calls progress with each percentage VBoxManage reports
+	VBoxManageWithProgress(progress func(percent int), args ...string) error
+
 	// Verify checks to make sure that this driver should function
 	// properly. If there is any indication the driver can't function,
 	// this will return an error.
@@ -76,8 +80,13 @@ type Driver interface {
 	// if no snapshots are defined nil will be returned
 	LoadSnapshots(string) (*VBoxSnapshot, error)
 
-	// CreateSnapshot Creates a snapshot for a vm with a given name
-	CreateSnapshot(string, string) error
+	// CreateSnapshot Creates a snapshot for a vm with a given name and
+	// an optional description
+	CreateSnapshot(string, string, string) error
+
+	// CreateLiveSnapshot Creates a snapshot of a running vm without pausing
+	// it, with a given name and an optional description
+	CreateLiveSnapshot(string, string, string) error
 
 	// HasSnapshots tests if a vm has snapshots
 	HasSnapshots(string) (bool, error)
@@ -90,6 +99,10 @@ type Driver interface {
 
 	// DeleteSnapshot deletes the specified snapshot from a vm
 	DeleteSnapshot(string, *VBoxSnapshot) error
+
+	// LoadSnapshotTimeStamps fills in the time stamps of a snapshot tree
+	// loaded with LoadSnapshots
+	LoadSnapshotTimeStamps(string, *VBoxSnapshot) error
 }
 
 func NewDriver() (Driver, error) {
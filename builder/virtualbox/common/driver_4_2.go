@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os/exec"
 	"regexp"
@@ -249,6 +250,10 @@ func (d *VBox42Driver) SuppressMessages() error {
 }
 
 func (d *VBox42Driver) VBoxManage(args ...string) error {
+	return d.VBoxManageWithProgress(nil, args...)
+}
+
+func (d *VBox42Driver) VBoxManageWithProgress(progress func(percent int), args ...string) error {
 	ctx := context.TODO()
 	err := retry.Config{
 		Tries: 5,
@@ -257,7 +262,7 @@ func (d *VBox42Driver) VBoxManage(args ...string) error {
 		},
 		RetryDelay: func() time.Duration { return 1 * time.Minute },
 	}.Run(ctx, func(ctx context.Context) error {
-		_, err := d.VBoxManageWithOutput(args...)
+		_, err := d.vboxManage(progress, args...)
 		return err
 	})
 
@@ -265,12 +270,21 @@ func (d *VBox42Driver) VBoxManage(args ...string) error {
 }
 
 func (d *VBox42Driver) VBoxManageWithOutput(args ...string) (string, error) {
+	return d.vboxManage(nil, args...)
+}
+
+// vboxManage runs VBoxManage, passing the progress it prints to stderr to
+// progress if not nil.
+func (d *VBox42Driver) vboxManage(progress func(percent int), args ...string) (string, error) {
 	var stdout, stderr bytes.Buffer
 
 	log.Printf("Executing VBoxManage: %#v", args)
 	cmd := exec.Command(d.VBoxManagePath, args...)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	if progress != nil {
+		cmd.Stderr = &progressWriter{w: &stderr, progress: progress, last: -1}
+	}
 	err := cmd.Run()
 
 	stdoutString := strings.TrimSpace(stdout.String())
@@ -295,6 +309,37 @@ func (d *VBox42Driver) VBoxManageWithOutput(args ...string) (string, error) {
 	return stdoutString, err
 }
 
+// VBoxManage prints the progress of long operations like
+// 0%...10%...20%..., a few characters at a time.
+var progressPattern = regexp.MustCompile(`(\d+)%`)
+
+// progressWriter passes what it writes to w and the percentages in it to
+// progress, once each.
+type progressWriter struct {
+	w        io.Writer
+	progress func(percent int)
+	pending  []byte
+	last     int
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	p.pending = append(p.pending, b...)
+	// Keep the digits at the end, the rest of the percentage may follow
+	end := len(p.pending)
+	for end > 0 && p.pending[end-1] >= '0' && p.pending[end-1] <= '9' {
+		end--
+	}
+	for _, m := range progressPattern.FindAllSubmatch(p.pending[:end], -1) {
+		percent, err := strconv.Atoi(string(m[1]))
+		if err == nil && percent > p.last && percent <= 100 {
+			p.last = percent
+			p.progress(percent)
+		}
+	}
+	p.pending = append(p.pending[:0], p.pending[end:]...)
+	return p.w.Write(b)
+}
+
 func (d *VBox42Driver) Verify() error {
 	return nil
 }
@@ -352,13 +397,30 @@ func (d *VBox42Driver) LoadSnapshots(vmName string) (*VBoxSnapshot, error) {
 	return rootNode, nil
 }
 
-func (d *VBox42Driver) CreateSnapshot(vmname string, snapshotName string) error {
+func (d *VBox42Driver) CreateSnapshot(vmname string, snapshotName string, description string) error {
 	if vmname == "" {
 		panic("Argument empty exception: vmname")
 	}
 	log.Printf("Executing CreateSnapshot: VM: %s, SnapshotName %s", vmname, snapshotName)
 
-	return d.VBoxManage("snapshot", vmname, "take", snapshotName)
+	command := []string{"snapshot", vmname, "take", snapshotName}
+	if description != "" {
+		command = append(command, "--description", description)
+	}
+	return d.VBoxManage(command...)
+}
+
+func (d *VBox42Driver) CreateLiveSnapshot(vmname string, snapshotName string, description string) error {
+	if vmname == "" {
+		panic("Argument empty exception: vmname")
+	}
+	log.Printf("Executing CreateLiveSnapshot: VM: %s, SnapshotName %s", vmname, snapshotName)
+
+	command := []string{"snapshot", vmname, "take", snapshotName, "--live"}
+	if description != "" {
+		command = append(command, "--description", description)
+	}
+	return d.VBoxManage(command...)
 }
 
 func (d *VBox42Driver) HasSnapshots(vmname string) (bool, error) {
@@ -409,3 +471,28 @@ func (d *VBox42Driver) DeleteSnapshot(vmname string, sn *VBoxSnapshot) error {
 	log.Printf("Executing DeleteSnapshot: VM: %s, SnapshotName %s", vmname, sn.UUID)
 	return d.VBoxManage("snapshot", vmname, "delete", sn.UUID)
 }
+
+func (d *VBox42Driver) LoadSnapshotTimeStamps(vmname string, sn *VBoxSnapshot) error {
+	if vmname == "" {
+		panic("Argument empty exception: vmname")
+	}
+	if nil == sn {
+		return nil
+	}
+	log.Printf("Executing LoadSnapshotTimeStamps: VM: %s", vmname)
+
+	// `VBoxManage snapshot showvminfo` only prints the settings of the VM
+	// when the snapshot was taken, human readable, and not when it was
+	// taken. The descriptions come with the tree from `showvminfo
+	// --machinereadable`, the time stamps are read from the settings file.
+
+	output, err := d.VBoxManageWithOutput("showvminfo", vmname, "--machinereadable")
+	if err != nil {
+		return err
+	}
+	settingsFile, ok := ParseVMInfo(output)["CfgFile"]
+	if !ok {
+		return fmt.Errorf("Could not find the settings file of VM %s", vmname)
+	}
+	return ReadSnapshotTimeStamps(settingsFile, sn)
+}
@@ -4,9 +4,31 @@
 package common
 
 import (
+	"bytes"
+	"reflect"
 	"testing"
 )
 
 func TestVBox42Driver_impl(t *testing.T) {
 	var _ Driver = new(VBox42Driver)
 }
+
+func TestProgressWriter(t *testing.T) {
+	var out bytes.Buffer
+	var percents []int
+	w := &progressWriter{w: &out, progress: func(percent int) { percents = append(percents, percent) }, last: -1}
+
+	// VBoxManage writes a few characters at a time
+	for _, s := range []string{"0%.", "..1", "0%...20", "%...", "30%...100%\n", "Successfully exported 1 machine(s).\n"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	if !reflect.DeepEqual(percents, []int{0, 10, 20, 30, 100}) {
+		t.Fatalf("bad: %#v", percents)
+	}
+	if out.String() != "0%...10%...20%...30%...100%\nSuccessfully exported 1 machine(s).\n" {
+		t.Fatalf("bad: %q", out.String())
+	}
+}
@@ -3,7 +3,10 @@
 
 package common
 
-import "sync"
+import (
+	"strings"
+	"sync"
+)
 
 type DriverMock struct {
 	sync.Mutex
@@ -53,9 +56,15 @@ type DriverMock struct {
 
 	VBoxManageCalls [][]string
 	VBoxManageErrs  []error
+	// The percentages reported by VBoxManageWithProgress.
+	VBoxManageProgress []int
 
-	VBoxManageWithOutputCalls [][]string
-	VBoxManageWithOutputErrs  []error
+	VBoxManageWithOutputCalls  [][]string
+	VBoxManageWithOutputErrs   []error
+	VBoxManageWithOutputResult string
+	// The outputs of specific commands, by their arguments joined with
+	// spaces, returned instead of VBoxManageWithOutputResult.
+	VBoxManageWithOutputResults map[string]string
 
 	VerifyCalled bool
 	VerifyErr    error
@@ -64,16 +73,25 @@ type DriverMock struct {
 	VersionResult string
 	VersionErr    error
 
-	LoadSnapshotsCalled      []string
-	LoadSnapshotsResult      *VBoxSnapshot
-	CreateSnapshotCalled     []string
-	CreateSnapshotError      error
-	HasSnapshotsCalled       []string
-	HasSnapshotsResult       bool
-	GetCurrentSnapshotCalled []string
-	GetCurrentSnapshotResult *VBoxSnapshot
-	SetSnapshotCalled        []*VBoxSnapshot
-	DeleteSnapshotCalled     []*VBoxSnapshot
+	LoadSnapshotsCalled []string
+	LoadSnapshotsResult *VBoxSnapshot
+	// The results of the successive calls to LoadSnapshots, returned
+	// before LoadSnapshotsResult.
+	LoadSnapshotsResults      []*VBoxSnapshot
+	CreateSnapshotCalled      []string
+	CreateSnapshotDescription []string
+	CreateSnapshotError       error
+	CreateLiveSnapshotCalled  []string
+	CreateLiveSnapshotError   error
+	HasSnapshotsCalled        []string
+	HasSnapshotsResult        bool
+	GetCurrentSnapshotCalled  []string
+	GetCurrentSnapshotResult  *VBoxSnapshot
+	SetSnapshotCalled         []*VBoxSnapshot
+	DeleteSnapshotCalled      []*VBoxSnapshot
+
+	LoadSnapshotTimeStampsCalled []string
+	LoadSnapshotTimeStampsErr    error
 }
 
 func (d *DriverMock) CreateSATAController(vm string, controller string, portcount int) error {
@@ -156,13 +174,23 @@ func (d *DriverMock) VBoxManage(args ...string) error {
 	return nil
 }
 
+func (d *DriverMock) VBoxManageWithProgress(progress func(percent int), args ...string) error {
+	for _, percent := range d.VBoxManageProgress {
+		progress(percent)
+	}
+	return d.VBoxManage(args...)
+}
+
 func (d *DriverMock) VBoxManageWithOutput(args ...string) (string, error) {
 	d.VBoxManageCalls = append(d.VBoxManageCalls, args)
 
 	if len(d.VBoxManageErrs) >= len(d.VBoxManageCalls) {
 		return "", d.VBoxManageErrs[len(d.VBoxManageCalls)-1]
 	}
-	return "", nil
+	if output, ok := d.VBoxManageWithOutputResults[strings.Join(args, " ")]; ok {
+		return output, nil
+	}
+	return d.VBoxManageWithOutputResult, nil
 }
 
 func (d *DriverMock) Verify() error {
@@ -181,10 +209,13 @@ func (d *DriverMock) LoadSnapshots(vmName string) (*VBoxSnapshot, error) {
 	}
 
 	d.LoadSnapshotsCalled = append(d.LoadSnapshotsCalled, vmName)
+	if len(d.LoadSnapshotsResults) >= len(d.LoadSnapshotsCalled) {
+		return d.LoadSnapshotsResults[len(d.LoadSnapshotsCalled)-1], nil
+	}
 	return d.LoadSnapshotsResult, nil
 }
 
-func (d *DriverMock) CreateSnapshot(vmName string, snapshotName string) error {
+func (d *DriverMock) CreateSnapshot(vmName string, snapshotName string, description string) error {
 	if vmName == "" {
 		panic("Argument empty exception: vmName")
 	}
@@ -193,9 +224,22 @@ func (d *DriverMock) CreateSnapshot(vmName string, snapshotName string) error {
 	}
 
 	d.CreateSnapshotCalled = append(d.CreateSnapshotCalled, snapshotName)
+	d.CreateSnapshotDescription = append(d.CreateSnapshotDescription, description)
 	return d.CreateSnapshotError
 }
 
+func (d *DriverMock) CreateLiveSnapshot(vmName string, snapshotName string, description string) error {
+	if vmName == "" {
+		panic("Argument empty exception: vmName")
+	}
+	if snapshotName == "" {
+		panic("Argument empty exception: snapshotName")
+	}
+
+	d.CreateLiveSnapshotCalled = append(d.CreateLiveSnapshotCalled, snapshotName)
+	return d.CreateLiveSnapshotError
+}
+
 func (d *DriverMock) HasSnapshots(vmName string) (bool, error) {
 	if vmName == "" {
 		panic("Argument empty exception: vmName")
@@ -236,3 +280,11 @@ func (d *DriverMock) DeleteSnapshot(vmName string, snapshot *VBoxSnapshot) error
 	d.DeleteSnapshotCalled = append(d.DeleteSnapshotCalled, snapshot)
 	return nil
 }
+
+func (d *DriverMock) LoadSnapshotTimeStamps(vmName string, snapshot *VBoxSnapshot) error {
+	if vmName == "" {
+		panic("Argument empty exception: vmName")
+	}
+	d.LoadSnapshotTimeStampsCalled = append(d.LoadSnapshotTimeStampsCalled, vmName)
+	return d.LoadSnapshotTimeStampsErr
+}
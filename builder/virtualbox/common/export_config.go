@@ -2,19 +2,144 @@
 // SPDX-License-Identifier: MPL-2.0
 
 //go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type OVFTransform
 
 package common
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common/ovfmodel"
 )
 
+const (
+	ExportMACAddressPolicyKeep   = "keep"
+	ExportMACAddressPolicyStrip  = "strip"
+	ExportMACAddressPolicyAllNew = "all-new"
+)
+
+// The hardware items ovf_transform can remove, by CIM resource type.
+var ovfTransformDevices = map[string]int{
+	"floppy":   ovfmodel.ResourceTypeFloppyDrive,
+	"cdrom":    ovfmodel.ResourceTypeCDDrive,
+	"serial":   ovfmodel.ResourceTypeSerialPort,
+	"parallel": ovfmodel.ResourceTypeParallelPort,
+	"usb":      ovfmodel.ResourceTypeUSBController,
+	"sound":    ovfmodel.ResourceTypeSoundCard,
+}
+
+// OVFTransform lists edits of the OVF descriptor of the exported appliance,
+// made once VBoxManage exported it. The manifest of the appliance is updated
+// to match. An OVA is rewritten once for all the edits, along with its
+// product properties and manifest.
+type OVFTransform struct {
+	// Remove the `vbox:Machine` sections, which hold the VirtualBox settings
+	// of the VM. Without them, VirtualBox imports the appliance from the
+	// standard OVF sections like other consumers do.
+	RemoveVirtualBoxMachine bool `mapstructure:"remove_virtualbox_machine" required:"false"`
+	// The hardware family of the appliance, `virtualbox-2.2` by default, such
+	// as `vmx-13` for VMware consumers.
+	VirtualSystemType string `mapstructure:"virtual_system_type" required:"false"`
+	// The virtual hardware to remove from the appliance: `floppy`, `cdrom`,
+	// `serial`, `parallel`, `usb` or `sound`, or a CIM resource type number.
+	// Controllers that disks are attached to can't be removed.
+	RemoveDevices []string `mapstructure:"remove_devices" required:"false"`
+	// The CIM operating system ID of the appliance, which VirtualBox sets from
+	// the guest OS type, such as `94` for 64-bit Ubuntu.
+	OSTypeID int `mapstructure:"os_type_id" required:"false"`
+	// The description of the operating system of the appliance. Requires
+	// `os_type_id`.
+	OSDescription string `mapstructure:"os_description" required:"false"`
+}
+
+// IsEmpty returns true if there is nothing to change in the descriptor.
+func (t *OVFTransform) IsEmpty() bool {
+	return !t.RemoveVirtualBoxMachine && t.VirtualSystemType == "" && len(t.RemoveDevices) == 0 &&
+		t.OSTypeID == 0 && t.OSDescription == ""
+}
+
+// Apply makes the edits to an OVF descriptor.
+func (t *OVFTransform) Apply(d *ovfmodel.Descriptor) error {
+	if t.RemoveVirtualBoxMachine {
+		d.RemoveVirtualBoxMachine()
+	}
+	if t.VirtualSystemType != "" {
+		if err := d.SetVirtualSystemType(t.VirtualSystemType); err != nil {
+			return err
+		}
+	}
+	for _, device := range t.RemoveDevices {
+		resourceType, err := ovfTransformDevice(device)
+		if err != nil {
+			return err
+		}
+		if _, err := d.RemoveItems(resourceType); err != nil {
+			return err
+		}
+	}
+	if t.OSTypeID != 0 {
+		if err := d.SetOperatingSystem(t.OSTypeID, t.OSDescription); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *OVFTransform) prepare() []error {
+	var errs []error
+	for _, device := range t.RemoveDevices {
+		if _, err := ovfTransformDevice(device); err != nil {
+			errs = append(errs, fmt.Errorf("ovf_transform: %s", err))
+		}
+	}
+	if t.OSTypeID < 0 {
+		errs = append(errs, fmt.Errorf("ovf_transform: os_type_id must be positive"))
+	}
+	if t.OSDescription != "" && t.OSTypeID == 0 {
+		errs = append(errs, fmt.Errorf("ovf_transform: os_description requires os_type_id"))
+	}
+	return errs
+}
+
+func ovfTransformDevice(device string) (int, error) {
+	if resourceType, ok := ovfTransformDevices[device]; ok {
+		return resourceType, nil
+	}
+	if resourceType, err := strconv.Atoi(device); err == nil && resourceType > 0 {
+		return resourceType, nil
+	}
+	return 0, fmt.Errorf("unknown device %q in remove_devices", device)
+}
+
 type ExportConfig struct {
-	// Either ovf or ova, this specifies the output format
-	// of the exported virtual machine. This defaults to ovf.
+	// Either ovf, ova or cloud, this specifies the output format
+	// of the exported virtual machine. This defaults to ovf. To export to
+	// several formats, use `formats` instead.
 	Format string `mapstructure:"format" required:"false"`
+	// The formats to export the virtual machine to, from the same powered
+	// off VM. Besides `ovf` and `ova`, the disks of the VM can be exported as
+	// `raw`, `vmdk`, `vhd` or `vdi` disk images, converted by `VBoxManage
+	// clonemedium`. A raw image can be converted to qcow2 with `qemu-img
+	// convert -f raw -O qcow2`. The first disk image, the boot disk of the
+	// VM, is named after the VM like the appliances, the next ones get a
+	// number, such as `packer-vm-2.raw`. With `cloud`, the VM is exported to Oracle Cloud as
+	// configured by `cloud_export`.
+	//
+	// When both `ovf` and `ova` are listed, the VM is exported once and the
+	// OVA is packed from the files of the OVF. The options about the
+	// appliance, such as `ovf_transform` or `export_manifest`, apply to the
+	// `ovf` and `ova` exports only. For example, in HCL2:
+	//
+	// ```hcl
+	//   formats = ["ova", "ovf", "raw"]
+	// ```
+	//
+	// Cannot be used with `format`.
+	Formats []string `mapstructure:"formats" required:"false"`
 	// Additional options to pass to the [VBoxManage
 	// export](https://www.virtualbox.org/manual/ch09.html#vboxmanage-export).
 	// This can be useful for passing product information to include in the
@@ -69,22 +194,190 @@ type ExportConfig struct {
 	//     "packer_conf.json"
 	// ```
 	ExportOpts []string `mapstructure:"export_opts" required:"false"`
+	// What to do with the MAC addresses of the network adapters in the
+	// exported appliance. When set to `keep`, the MAC addresses of the built
+	// VM are exported as is. When set to `strip`, the appliance is exported
+	// without MAC addresses (`--options nomacs`) so that VirtualBox generates
+	// new ones on every import. When set to `all-new`, new MAC addresses are
+	// generated for the VM right before it is exported, and its own MAC
+	// addresses are put back once it is exported. By default, MAC
+	// addresses are kept unless `export_opts` says otherwise; setting this
+	// option together with a conflicting `--options` value in `export_opts`
+	// is an error.
+	ExportMACAddressPolicy string `mapstructure:"export_mac_address_policy" required:"false"`
+	// Edits of the OVF descriptor of the exported appliance, for consumers
+	// other than VirtualBox. For example, in HCL2:
+	//
+	// ```hcl
+	//   ovf_transform {
+	//     remove_virtualbox_machine = true
+	//     virtual_system_type       = "vmx-13"
+	//     remove_devices            = ["serial", "sound"]
+	//     os_type_id                = 94
+	//   }
+	// ```
+	//
+	// See the [OVF transform configuration](#ovf-transform-configuration)
+	// reference for the edits available.
+	OVFTransform OVFTransform `mapstructure:"ovf_transform" required:"false"`
+	// The export to Oracle Cloud Infrastructure, required when `cloud` is one
+	// of the formats. For example, in HCL2:
+	//
+	// ```hcl
+	//   formats = ["ova", "cloud"]
+	//   cloud_export {
+	//     profile      = "packer"
+	//     shape        = "VM.Standard2.1"
+	//     bucket       = "images"
+	//     display_name = "ubuntu-${local.timestamp}"
+	//   }
+	// ```
+	//
+	// See the [cloud export configuration](#cloud-export-configuration)
+	// reference for the options available.
+	CloudExport CloudExportConfig `mapstructure:"cloud_export" required:"false"`
+	// Export the boot disk of the VM alone instead of an appliance, by
+	// converting it with `VBoxManage clonemedium`. The boot disk is the one
+	// on the lowest port of the first bootable storage controller. The artifact is the disk
+	// image. Cannot be used with `format` or `formats`. Defaults to `false`.
+	ExportDiskOnly bool `mapstructure:"export_disk_only" required:"false"`
+	// The format of the disk image of `export_disk_only`: `raw`, `vmdk`,
+	// `vhd` or `vdi`. A `vmdk` image is stream optimized, the variant other
+	// hypervisors and clouds import. Defaults to `raw`.
+	ExportDiskFormat string `mapstructure:"export_disk_format" required:"false"`
+	// Compress the disk image of `export_disk_only` with `gzip` or `zstd`,
+	// adding `.gz` or `.zst` to its name. Not compressed by default.
+	ExportDiskCompression string `mapstructure:"export_disk_compression" required:"false"`
+	// Export to a temporary directory in the output directory, and move the
+	// files in place once all the exports succeeded, the `.ovf`, `.ova` or
+	// first disk image last. Tools watching the output directory never see
+	// a partial export under its final name. Defaults to `false`.
+	//
+	// Whether or not this is set, a failed export is removed from the output
+	// directory, as VBoxManage can't resume it.
+	ExportAtomic bool `mapstructure:"export_atomic" required:"false"`
 }
 
 func (c *ExportConfig) Prepare(ctx *interpolate.Context) []error {
-	if c.Format == "" {
-		c.Format = "ovf"
-	}
-
 	var errs []error
-	if c.Format != "ovf" && c.Format != "ova" {
-		errs = append(errs,
-			errors.New("invalid format, only 'ovf' or 'ova' are allowed"))
+	if c.ExportDiskOnly {
+		errs = append(errs, c.prepareDiskOnly()...)
+	} else {
+		if len(c.Formats) == 0 {
+			if c.Format == "" {
+				c.Format = "ovf"
+			}
+			if c.Format != "ovf" && c.Format != "ova" && c.Format != "cloud" {
+				errs = append(errs,
+					errors.New("invalid format, only 'ovf', 'ova' or 'cloud' are allowed"))
+			}
+		} else if c.Format != "" {
+			errs = append(errs, errors.New("format and formats cannot both be set"))
+		}
+		errs = append(errs, c.prepareFormats()...)
 	}
 
 	if c.ExportOpts == nil {
 		c.ExportOpts = make([]string, 0)
 	}
 
+	errs = append(errs, c.prepareMACAddressPolicy()...)
+	errs = append(errs, c.OVFTransform.prepare()...)
+	if contains(c.formats(), "cloud") {
+		errs = append(errs, c.CloudExport.prepare()...)
+	}
+
+	return errs
+}
+
+func (c *ExportConfig) prepareDiskOnly() []error {
+	var errs []error
+	if c.Format != "" || len(c.Formats) > 0 {
+		errs = append(errs, errors.New("export_disk_only cannot be used with format or formats"))
+	}
+	if c.ExportDiskFormat == "" {
+		c.ExportDiskFormat = "raw"
+	}
+	if !isDiskImageFormat(c.ExportDiskFormat) {
+		errs = append(errs, fmt.Errorf("export_disk_format must be one of %s",
+			"'"+strings.Join(diskImageFormats, "', '")+"'"))
+	}
+	switch c.ExportDiskCompression {
+	case "", "gzip", "zstd":
+	default:
+		errs = append(errs, errors.New("export_disk_compression must be 'gzip' or 'zstd'"))
+	}
 	return errs
 }
+
+// formats returns the formats exported to: Formats, or Format alone.
+func (c *ExportConfig) formats() []string {
+	if len(c.Formats) == 0 && c.Format != "" {
+		return []string{c.Format}
+	}
+	return c.Formats
+}
+
+func (c *ExportConfig) prepareFormats() []error {
+	var errs []error
+	seen := make(map[string]bool)
+	for _, format := range c.Formats {
+		if format != "ovf" && format != "ova" && format != "cloud" && !isDiskImageFormat(format) {
+			errs = append(errs, fmt.Errorf("invalid format %q in formats, only 'ovf', 'ova', 'cloud', %s are allowed",
+				format, "'"+strings.Join(diskImageFormats, "', '")+"'"))
+		}
+		if seen[format] {
+			errs = append(errs, fmt.Errorf("format %q is listed more than once in formats", format))
+		}
+		seen[format] = true
+	}
+	return errs
+}
+
+func (c *ExportConfig) prepareMACAddressPolicy() []error {
+	optionsIdx := -1
+	var options []string
+	for i, opt := range c.ExportOpts {
+		if opt == "--options" && i+1 < len(c.ExportOpts) {
+			optionsIdx = i + 1
+		} else if strings.HasPrefix(opt, "--options=") {
+			optionsIdx = i
+		} else {
+			continue
+		}
+		options = strings.Split(strings.TrimPrefix(c.ExportOpts[optionsIdx], "--options="), ",")
+	}
+
+	var macOption string
+	for _, option := range options {
+		if option == "nomacs" || option == "nomacsbutnat" {
+			macOption = option
+		}
+	}
+
+	conflict := fmt.Errorf("export_mac_address_policy %q conflicts with the %q option in export_opts",
+		c.ExportMACAddressPolicy, macOption)
+	switch c.ExportMACAddressPolicy {
+	case "":
+	case ExportMACAddressPolicyKeep, ExportMACAddressPolicyAllNew:
+		if macOption != "" {
+			return []error{conflict}
+		}
+	case ExportMACAddressPolicyStrip:
+		switch {
+		case macOption == "nomacsbutnat":
+			return []error{conflict}
+		case macOption == "nomacs":
+			// already stripped by the user
+		case optionsIdx >= 0:
+			c.ExportOpts[optionsIdx] += ",nomacs"
+		default:
+			c.ExportOpts = append(c.ExportOpts, "--options", "nomacs")
+		}
+	default:
+		return []error{fmt.Errorf("export_mac_address_policy must be one of %q, %q or %q",
+			ExportMACAddressPolicyKeep, ExportMACAddressPolicyStrip, ExportMACAddressPolicyAllNew)}
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package common
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatOVFTransform is an auto-generated flat version of OVFTransform.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatOVFTransform struct {
+	RemoveVirtualBoxMachine *bool    `mapstructure:"remove_virtualbox_machine" required:"false" cty:"remove_virtualbox_machine" hcl:"remove_virtualbox_machine"`
+	VirtualSystemType       *string  `mapstructure:"virtual_system_type" required:"false" cty:"virtual_system_type" hcl:"virtual_system_type"`
+	RemoveDevices           []string `mapstructure:"remove_devices" required:"false" cty:"remove_devices" hcl:"remove_devices"`
+	OSTypeID                *int     `mapstructure:"os_type_id" required:"false" cty:"os_type_id" hcl:"os_type_id"`
+	OSDescription           *string  `mapstructure:"os_description" required:"false" cty:"os_description" hcl:"os_description"`
+}
+
+// FlatMapstructure returns a new FlatOVFTransform.
+// FlatOVFTransform is an auto-generated flat version of OVFTransform.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*OVFTransform) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatOVFTransform)
+}
+
+// HCL2Spec returns the hcl spec of a OVFTransform.
+// This spec is used by HCL to read the fields of OVFTransform.
+// The decoded values from this spec will then be applied to a FlatOVFTransform.
+func (*FlatOVFTransform) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"remove_virtualbox_machine": &hcldec.AttrSpec{Name: "remove_virtualbox_machine", Type: cty.Bool, Required: false},
+		"virtual_system_type":       &hcldec.AttrSpec{Name: "virtual_system_type", Type: cty.String, Required: false},
+		"remove_devices":            &hcldec.AttrSpec{Name: "remove_devices", Type: cty.List(cty.String), Required: false},
+		"os_type_id":                &hcldec.AttrSpec{Name: "os_type_id", Type: cty.Number, Required: false},
+		"os_description":            &hcldec.AttrSpec{Name: "os_description", Type: cty.String, Required: false},
+	}
+	return s
+}
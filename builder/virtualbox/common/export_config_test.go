@@ -4,6 +4,7 @@
 package common
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
@@ -52,3 +53,141 @@ func TestExportConfigPrepare_Opts(t *testing.T) {
 		t.Fatalf("should not have error: %s", errs)
 	}
 }
+
+func TestExportConfigPrepare_MACAddressPolicy(t *testing.T) {
+	tcs := []struct {
+		Policy   string
+		Opts     []string
+		Expected []string
+		Err      bool
+	}{
+		{Policy: "", Opts: []string{"--options", "nomacs"}, Expected: []string{"--options", "nomacs"}},
+		{Policy: "keep", Opts: nil, Expected: []string{}},
+		{Policy: "keep", Opts: []string{"--options", "manifest,nomacs"}, Err: true},
+		{Policy: "strip", Opts: nil, Expected: []string{"--options", "nomacs"}},
+		{Policy: "strip", Opts: []string{"--options", "manifest"}, Expected: []string{"--options", "manifest,nomacs"}},
+		{Policy: "strip", Opts: []string{"--options=manifest"}, Expected: []string{"--options=manifest,nomacs"}},
+		{Policy: "strip", Opts: []string{"--options", "nomacs"}, Expected: []string{"--options", "nomacs"}},
+		{Policy: "strip", Opts: []string{"--options", "nomacsbutnat"}, Err: true},
+		{Policy: "all-new", Opts: []string{"--options", "nomacsbutnat"}, Err: true},
+		{Policy: "all-new", Opts: []string{"--manifest"}, Expected: []string{"--manifest"}},
+		{Policy: "bad", Err: true},
+	}
+
+	for _, tc := range tcs {
+		c := &ExportConfig{
+			ExportMACAddressPolicy: tc.Policy,
+			ExportOpts:             tc.Opts,
+		}
+		errs := c.Prepare(interpolate.NewContext())
+		if tc.Err {
+			if len(errs) == 0 {
+				t.Fatalf("%s %v: should have error", tc.Policy, tc.Opts)
+			}
+			continue
+		}
+		if len(errs) > 0 {
+			t.Fatalf("%s %v: should not have error: %s", tc.Policy, tc.Opts, errs)
+		}
+		if !reflect.DeepEqual(c.ExportOpts, tc.Expected) {
+			t.Fatalf("%s: expected %v, got %v", tc.Policy, tc.Expected, c.ExportOpts)
+		}
+	}
+}
+
+func TestExportConfigPrepare_OVFTransform(t *testing.T) {
+	c := &ExportConfig{OVFTransform: OVFTransform{RemoveDevices: []string{"serial", "35"}, OSTypeID: 94, OSDescription: "Ubuntu_64"}}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+
+	for _, transform := range []OVFTransform{
+		{RemoveDevices: []string{"printer"}},
+		{OSTypeID: -1},
+		{OSDescription: "Ubuntu_64"},
+	} {
+		c := &ExportConfig{OVFTransform: transform}
+		if errs := c.Prepare(interpolate.NewContext()); len(errs) == 0 {
+			t.Fatalf("%#v: should have error", transform)
+		}
+	}
+}
+
+func TestExportConfigPrepare_Formats(t *testing.T) {
+	c := &ExportConfig{Format: "ova"}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+	if !reflect.DeepEqual(c.formats(), []string{"ova"}) {
+		t.Fatalf("bad formats: %v", c.formats())
+	}
+
+	c = &ExportConfig{Formats: []string{"ova", "ovf", "raw", "vmdk"}}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+
+	for _, c := range []*ExportConfig{
+		{Formats: []string{"qcow2"}},
+		{Formats: []string{"ova", "ova"}},
+		{Format: "ovf", Formats: []string{"ova", "raw"}},
+		{Format: "ova", Formats: []string{"ova"}},
+	} {
+		if errs := c.Prepare(interpolate.NewContext()); len(errs) == 0 {
+			t.Fatalf("%v %v: should have error", c.Format, c.Formats)
+		}
+	}
+}
+
+func TestExportConfigPrepare_CloudExport(t *testing.T) {
+	c := &ExportConfig{
+		Formats: []string{"ova", "cloud"},
+		CloudExport: CloudExportConfig{
+			Profile: "packer",
+			Shape:   "VM.Standard2.1",
+			Bucket:  "images",
+		},
+	}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+	if c.CloudExport.LaunchMode != "PARAVIRTUALIZED" {
+		t.Fatalf("bad launch mode: %s", c.CloudExport.LaunchMode)
+	}
+
+	// The cloud export is only checked when it is used
+	c = &ExportConfig{Format: "ova"}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+
+	c = &ExportConfig{Format: "cloud"}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) != 3 {
+		t.Fatalf("should have profile, shape and bucket errors: %s", errs)
+	}
+}
+
+func TestExportConfigPrepare_DiskOnly(t *testing.T) {
+	c := &ExportConfig{ExportDiskOnly: true, ExportDiskCompression: "zstd"}
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+	// Prepare runs twice in some builders
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+	if c.ExportDiskFormat != "raw" || c.Format != "" || c.Formats != nil {
+		t.Fatalf("bad disk format %q, format %q, formats %v", c.ExportDiskFormat, c.Format, c.Formats)
+	}
+
+	for _, c := range []*ExportConfig{
+		{ExportDiskOnly: true, Format: "ova"},
+		{ExportDiskOnly: true, Formats: []string{"raw"}},
+		{ExportDiskOnly: true, ExportDiskFormat: "qcow2"},
+		{ExportDiskOnly: true, ExportDiskCompression: "xz"},
+	} {
+		if errs := c.Prepare(interpolate.NewContext()); len(errs) == 0 {
+			t.Fatalf("%#v: should have error", c)
+		}
+	}
+}
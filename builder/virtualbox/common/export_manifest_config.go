@@ -0,0 +1,63 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+
+package common
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+type ExportManifestConfig struct {
+	// The digest of the OVF manifest (`.mf`) written with the exported
+	// appliance: `sha1`, `sha256` or `sha512`. The manifest lists the digest
+	// of the descriptor and of every disk image, and replaces the one
+	// `--manifest` in `export_opts` would write. A checksum file named after
+	// the appliance, such as `packer-vm.ova.sha256`, is also written next to
+	// it, in the format read by `sha256sum --check`. Disk image exports have
+	// no manifest, they only get the checksum file of their images, named
+	// after the first one. By default, no manifest is written. Defaults to
+	// `sha256` when the manifest is signed.
+	ExportManifest string `mapstructure:"export_manifest" required:"false"`
+	// The path to a PEM encoded X.509 certificate to sign the manifest with.
+	// The signature and the certificate are written to a `.cert` file next to
+	// the manifest, or in the OVA. Requires `export_signing_key`.
+	ExportSigningCertificate string `mapstructure:"export_signing_certificate" required:"false"`
+	// The path to the PEM encoded, unencrypted RSA or ECDSA private key of
+	// `export_signing_certificate`, in PKCS #1, SEC 1 or PKCS #8 form.
+	ExportSigningKey string `mapstructure:"export_signing_key" required:"false"`
+}
+
+func (c *ExportManifestConfig) Prepare(ctx *interpolate.Context) []error {
+	var errs []error
+
+	if (c.ExportSigningCertificate == "") != (c.ExportSigningKey == "") {
+		errs = append(errs, fmt.Errorf("export_signing_certificate and export_signing_key must be set together"))
+	}
+	if c.ExportManifest == "" && c.ExportSigningCertificate != "" {
+		c.ExportManifest = "sha256"
+	}
+
+	c.ExportManifest = strings.ToLower(c.ExportManifest)
+	switch c.ExportManifest {
+	case "", "sha1", "sha256", "sha512":
+	default:
+		errs = append(errs, fmt.Errorf("export_manifest must be one of \"sha1\", \"sha256\" or \"sha512\""))
+	}
+
+	if c.ExportSigningCertificate != "" && c.ExportSigningKey != "" {
+		signer, err := loadManifestSigner(c.ExportSigningCertificate, c.ExportSigningKey)
+		if err != nil {
+			errs = append(errs, err)
+		} else if notAfter := signer.cert.NotAfter; time.Now().After(notAfter) {
+			errs = append(errs, fmt.Errorf("export_signing_certificate expired on %s", notAfter.Format(time.RFC3339)))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,38 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportManifestConfigPrepare(t *testing.T) {
+	c := &ExportManifestConfig{ExportManifest: "SHA512"}
+	assert.Empty(t, c.Prepare(interpolate.NewContext()))
+	assert.Equal(t, "sha512", c.ExportManifest)
+
+	// Signing defaults to a SHA256 manifest
+	certPath, keyPath := writeTestCertificate(t, t.TempDir(), time.Now().Add(time.Hour))
+	c = &ExportManifestConfig{ExportSigningCertificate: certPath, ExportSigningKey: keyPath}
+	assert.Empty(t, c.Prepare(interpolate.NewContext()))
+	assert.Equal(t, "sha256", c.ExportManifest)
+}
+
+func TestExportManifestConfigPrepare_invalid(t *testing.T) {
+	dir := t.TempDir()
+	expiredCert, expiredKey := writeTestCertificate(t, dir, time.Now().Add(-time.Hour))
+	for name, c := range map[string]*ExportManifestConfig{
+		"bad digest":       {ExportManifest: "md5"},
+		"certificate only": {ExportSigningCertificate: expiredCert},
+		"key only":         {ExportSigningKey: expiredKey},
+		"missing files":    {ExportSigningCertificate: "does-not-exist.pem", ExportSigningKey: "does-not-exist.key"},
+		"expired":          {ExportSigningCertificate: expiredCert, ExportSigningKey: expiredKey},
+	} {
+		assert.NotEmpty(t, c.Prepare(interpolate.NewContext()), name)
+	}
+}
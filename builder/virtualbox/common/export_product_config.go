@@ -0,0 +1,185 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type ExportProperty
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common/ovfmodel"
+)
+
+// The value types of the OVF ProductSection properties.
+var exportPropertyTypes = map[string]int{
+	"boolean": 0,
+	"string":  0,
+	"uint8":   8, "sint8": 8,
+	"uint16": 16, "sint16": 16,
+	"uint32": 32, "sint32": 32,
+	"uint64": 64, "sint64": 64,
+	"real32": 32, "real64": 64,
+}
+
+var exportPropertyKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ExportProperty is a property of the OVF ProductSection, read by vApp style
+// consumers of the appliance.
+type ExportProperty struct {
+	// The key of the property, such as `guestinfo.hostname`.
+	Key string `mapstructure:"key" required:"true"`
+	// The OVF type of the property: `string`, `boolean`, `uint8` to `uint64`,
+	// `sint8` to `sint64`, `real32` or `real64`. Defaults to `string`.
+	Type string `mapstructure:"type" required:"false"`
+	// The default value of the property, which must be valid for its type.
+	Value string `mapstructure:"value" required:"false"`
+	// Whether the user deploying the appliance may change the value.
+	// Defaults to `false`.
+	UserConfigurable bool `mapstructure:"user_configurable" required:"false"`
+	// A short label for the property.
+	Label string `mapstructure:"label" required:"false"`
+	// A description of the property.
+	Description string `mapstructure:"description" required:"false"`
+}
+
+type ExportProductConfig struct {
+	// The product name of the exported appliance (`--product`). Like the
+	// other product options, it can use templates such as `{{timestamp}}` or
+	// `{{isotime "2006-01-02"}}`.
+	ExportProduct string `mapstructure:"export_product" required:"false"`
+	// The URL of the product (`--producturl`).
+	ExportProductURL string `mapstructure:"export_product_url" required:"false"`
+	// The vendor of the product (`--vendor`).
+	ExportVendor string `mapstructure:"export_vendor" required:"false"`
+	// The URL of the vendor (`--vendorurl`).
+	ExportVendorURL string `mapstructure:"export_vendor_url" required:"false"`
+	// The version of the product (`--version`).
+	ExportVersion string `mapstructure:"export_version" required:"false"`
+	// The description of the appliance (`--description`).
+	ExportDescription string `mapstructure:"export_description" required:"false"`
+	// The end user license agreement of the appliance (`--eula`).
+	ExportEULA string `mapstructure:"export_eula" required:"false"`
+	// A file holding the end user license agreement of the appliance
+	// (`--eulafile`). Cannot be used with `export_eula`.
+	ExportEULAFile string `mapstructure:"export_eula_file" required:"false"`
+	// Properties added to the OVF ProductSection of the appliance, for
+	// example:
+	//
+	// ```hcl
+	//   export_property {
+	//     key               = "guestinfo.hostname"
+	//     type              = "string"
+	//     value             = "appliance"
+	//     user_configurable = true
+	//     label             = "Host name"
+	//   }
+	// ```
+	//
+	// The exported descriptor is checked to hold them, along with the other
+	// product options.
+	ExportProperties []ExportProperty `mapstructure:"export_property" required:"false"`
+}
+
+func (c *ExportProductConfig) Prepare(ctx *interpolate.Context) []error {
+	var errs []error
+
+	if c.ExportEULA != "" && c.ExportEULAFile != "" {
+		errs = append(errs, fmt.Errorf("export_eula and export_eula_file cannot both be set"))
+	}
+	if c.ExportEULAFile != "" {
+		if _, err := os.Stat(c.ExportEULAFile); err != nil {
+			errs = append(errs, fmt.Errorf("export_eula_file is invalid: %s", err))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := range c.ExportProperties {
+		p := &c.ExportProperties[i]
+		if p.Type == "" {
+			p.Type = "string"
+		}
+		if !exportPropertyKey.MatchString(p.Key) {
+			errs = append(errs, fmt.Errorf("export_property: invalid key %q", p.Key))
+			continue
+		}
+		if seen[p.Key] {
+			errs = append(errs, fmt.Errorf("export_property: key %q is listed more than once", p.Key))
+		}
+		seen[p.Key] = true
+		if err := validateExportPropertyValue(p.Type, p.Value); err != nil {
+			errs = append(errs, fmt.Errorf("export_property %s: %s", p.Key, err))
+		}
+	}
+
+	return errs
+}
+
+// VSysArgs returns the `VBoxManage export` arguments setting the product
+// information.
+func (c *ExportProductConfig) VSysArgs() []string {
+	var args []string
+	for _, option := range []struct{ flag, value string }{
+		{"--product", c.ExportProduct},
+		{"--producturl", c.ExportProductURL},
+		{"--vendor", c.ExportVendor},
+		{"--vendorurl", c.ExportVendorURL},
+		{"--version", c.ExportVersion},
+		{"--description", c.ExportDescription},
+		{"--eula", c.ExportEULA},
+		{"--eulafile", c.ExportEULAFile},
+	} {
+		if option.value != "" {
+			args = append(args, option.flag, option.value)
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	return append([]string{"--vsys", "0"}, args...)
+}
+
+// ovfProperties returns the properties to add to the ProductSection of the
+// exported descriptor, which VBoxManage can't set.
+func (c *ExportProductConfig) ovfProperties() []ovfmodel.Property {
+	var properties []ovfmodel.Property
+	for _, p := range c.ExportProperties {
+		properties = append(properties, ovfmodel.Property(p))
+	}
+	return properties
+}
+
+func validateExportPropertyValue(typ, value string) error {
+	bits, ok := exportPropertyTypes[typ]
+	if !ok {
+		return fmt.Errorf("unknown type %q", typ)
+	}
+	if value == "" {
+		return nil
+	}
+
+	var err error
+	switch typ[0] {
+	case 'b':
+		if value != "true" && value != "false" {
+			err = fmt.Errorf("invalid boolean")
+		}
+	case 'u':
+		_, err = strconv.ParseUint(value, 10, bits)
+	case 's':
+		if typ != "string" {
+			_, err = strconv.ParseInt(value, 10, bits)
+		}
+	case 'r':
+		_, err = strconv.ParseFloat(value, bits)
+	}
+	if err != nil {
+		return fmt.Errorf("%q is not a valid %s", value, typ)
+	}
+	return nil
+}
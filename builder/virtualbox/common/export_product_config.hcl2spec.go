@@ -0,0 +1,41 @@
+// Code generated by "packer-sdc mapstructure-to-hcl2"; DO NOT EDIT.
+
+package common
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FlatExportProperty is an auto-generated flat version of ExportProperty.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatExportProperty struct {
+	Key              *string `mapstructure:"key" required:"true" cty:"key" hcl:"key"`
+	Type             *string `mapstructure:"type" required:"false" cty:"type" hcl:"type"`
+	Value            *string `mapstructure:"value" required:"false" cty:"value" hcl:"value"`
+	UserConfigurable *bool   `mapstructure:"user_configurable" required:"false" cty:"user_configurable" hcl:"user_configurable"`
+	Label            *string `mapstructure:"label" required:"false" cty:"label" hcl:"label"`
+	Description      *string `mapstructure:"description" required:"false" cty:"description" hcl:"description"`
+}
+
+// FlatMapstructure returns a new FlatExportProperty.
+// FlatExportProperty is an auto-generated flat version of ExportProperty.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*ExportProperty) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatExportProperty)
+}
+
+// HCL2Spec returns the hcl spec of a ExportProperty.
+// This spec is used by HCL to read the fields of ExportProperty.
+// The decoded values from this spec will then be applied to a FlatExportProperty.
+func (*FlatExportProperty) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"key":               &hcldec.AttrSpec{Name: "key", Type: cty.String, Required: false},
+		"type":              &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
+		"value":             &hcldec.AttrSpec{Name: "value", Type: cty.String, Required: false},
+		"user_configurable": &hcldec.AttrSpec{Name: "user_configurable", Type: cty.Bool, Required: false},
+		"label":             &hcldec.AttrSpec{Name: "label", Type: cty.String, Required: false},
+		"description":       &hcldec.AttrSpec{Name: "description", Type: cty.String, Required: false},
+	}
+	return s
+}
@@ -0,0 +1,41 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportProductConfigPrepare(t *testing.T) {
+	c := &ExportProductConfig{
+		ExportProduct: "Appliance",
+		ExportVersion: "1.2.3",
+		ExportProperties: []ExportProperty{
+			{Key: "guestinfo.hostname", Value: "appliance", UserConfigurable: true},
+			{Key: "guestinfo.port", Type: "uint16", Value: "8080"},
+		},
+	}
+	assert.Empty(t, c.Prepare(interpolate.NewContext()))
+	assert.Equal(t, "string", c.ExportProperties[0].Type)
+	assert.Equal(t, []string{"--vsys", "0", "--product", "Appliance", "--version", "1.2.3"}, c.VSysArgs())
+
+	assert.Nil(t, new(ExportProductConfig).VSysArgs())
+}
+
+func TestExportProductConfigPrepare_invalid(t *testing.T) {
+	for name, c := range map[string]*ExportProductConfig{
+		"both EULAs":    {ExportEULA: "text", ExportEULAFile: "eula.txt"},
+		"missing EULA":  {ExportEULAFile: "does-not-exist.txt"},
+		"bad key":       {ExportProperties: []ExportProperty{{Key: "host name"}}},
+		"duplicate key": {ExportProperties: []ExportProperty{{Key: "a"}, {Key: "a"}}},
+		"bad type":      {ExportProperties: []ExportProperty{{Key: "a", Type: "int"}}},
+		"out of range":  {ExportProperties: []ExportProperty{{Key: "a", Type: "uint8", Value: "256"}}},
+		"bad boolean":   {ExportProperties: []ExportProperty{{Key: "a", Type: "boolean", Value: "yes"}}},
+	} {
+		assert.NotEmpty(t, c.Prepare(interpolate.NewContext()), name)
+	}
+}
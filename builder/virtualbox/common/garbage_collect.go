@@ -0,0 +1,325 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/filelock"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// The extra data key tagging the VMs created by Packer with their creation
+// time. It is removed from the VMs kept at the end of a build.
+const createdExtraDataKey = "Packer/Created"
+
+// TagVM marks a VM as created by Packer, to be garbage collected if the
+// build doesn't get to delete it.
+func TagVM(driver Driver, name string) error {
+	return driver.VBoxManage("setextradata", name, createdExtraDataKey, time.Now().UTC().Format(time.RFC3339))
+}
+
+// The extra data key tagging the VMs kept at the end of a build, for a
+// forced build to replace them.
+const keptExtraDataKey = "Packer/Kept"
+
+// UntagVM marks a VM created by Packer as kept on purpose: it is not
+// garbage collected, but a forced build of the same VM replaces it. Its
+// disks are no longer collected either.
+func UntagVM(driver Driver, name string) error {
+	if err := driver.VBoxManage("setextradata", name, createdExtraDataKey); err != nil {
+		return err
+	}
+	if disks, err := vmDisks(driver, name); err == nil {
+		var paths []string
+		for _, disk := range disks {
+			paths = append(paths, disk.Location)
+		}
+		if err := ForgetMedia(paths...); err != nil {
+			log.Printf("Error forgetting the disks of VM %s: %s", name, err)
+		}
+	}
+	return driver.VBoxManage("setextradata", name, keptExtraDataKey, time.Now().UTC().Format(time.RFC3339))
+}
+
+// The file of the Packer cache directory recording the disk images Packer
+// created, by absolute path, with their creation time.
+const mediaRecordFilename = "created-media.json"
+
+// RecordMedium records that Packer created the disk image at path, for
+// gc_orphans to delete it once no VM uses it.
+func RecordMedium(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	return updateMediaRecord(func(media map[string]time.Time) {
+		media[path] = time.Now().UTC()
+	})
+}
+
+// ForgetMedia removes disk images from the record, once they are deleted or
+// kept on purpose.
+func ForgetMedia(paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	return updateMediaRecord(func(media map[string]time.Time) {
+		for _, path := range paths {
+			delete(media, path)
+		}
+	})
+}
+
+// recordedMedia returns the disk images Packer created, by path.
+func recordedMedia() (map[string]time.Time, error) {
+	path, err := packersdk.CachePath("virtualbox", mediaRecordFilename)
+	if err != nil {
+		return nil, err
+	}
+	media := make(map[string]time.Time)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return media, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return media, json.Unmarshal(data, &media)
+}
+
+// updateMediaRecord changes the record of the disk images Packer created,
+// locked against the builds running in parallel.
+func updateMediaRecord(update func(map[string]time.Time)) error {
+	path, err := packersdk.CachePath("virtualbox", mediaRecordFilename)
+	if err != nil {
+		return err
+	}
+	lock := filelock.New(path + ".lock")
+	for {
+		locked, err := lock.TryLock()
+		if err != nil {
+			return err
+		}
+		if locked {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	defer lock.Unlock()
+
+	media, err := recordedMedia()
+	if err != nil {
+		return err
+	}
+	update(media)
+	data, err := json.MarshalIndent(media, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// ReplaceKeptVM deletes the VM named name if an earlier build kept it, for
+// a forced build to create it again, and reports whether it did. It refuses
+// to delete a VM no build kept, such as one a build running in parallel is
+// creating, or a running one.
+func ReplaceKeptVM(driver Driver, name string) (bool, error) {
+	output, err := driver.VBoxManageWithOutput("list", "vms")
+	if err != nil {
+		return false, err
+	}
+	uuid := ""
+	for _, vm := range parseVMList(output) {
+		if vm.Name == name {
+			uuid = vm.UUID
+		}
+	}
+	if uuid == "" {
+		return false, nil
+	}
+
+	output, err = driver.VBoxManageWithOutput("getextradata", uuid, keptExtraDataKey)
+	if err != nil {
+		return false, err
+	}
+	if parseExtraData(output) == "" {
+		return false, fmt.Errorf("VM %s was not kept by an earlier build, not replacing it", name)
+	}
+
+	running, err := driver.IsRunning(uuid)
+	if err != nil {
+		return false, err
+	}
+	if running {
+		return false, fmt.Errorf("VM %s is running, not replacing it", name)
+	}
+	return true, driver.Delete(uuid)
+}
+
+// hasLinkedClones reports whether other VMs use differencing disks of the
+// disks of the VM with the given UUID, such as the linked clones of a
+// snapshot of it.
+func hasLinkedClones(media []listedMedium, uuid string) bool {
+	byUUID := make(map[string]listedMedium)
+	for _, medium := range media {
+		byUUID[medium.UUID] = medium
+	}
+	for _, medium := range media {
+		if contains(medium.UsedBy, uuid) {
+			continue
+		}
+		for parent, ok := byUUID[medium.Parent]; ok; parent, ok = byUUID[parent.Parent] {
+			if contains(parent.UsedBy, uuid) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// VMLockPath returns the path of the lock that the builds sharing a VM,
+// such as the shared base of the ovf builder, hold while they use it.
+func VMLockPath(name string) (string, error) {
+	return packersdk.CachePath("virtualbox", name+".lock")
+}
+
+// isPackerVMName reports whether a VM is named like the VMs Packer creates.
+func isPackerVMName(name string) bool {
+	return strings.HasPrefix(name, "packer-") || strings.HasPrefix(name, "packer_temp_vm_")
+}
+
+type listedVM struct {
+	Name string
+	UUID string
+}
+
+var vmListLine = regexp.MustCompile(`^"(.*)" \{([0-9a-fA-F-]+)\}$`)
+
+// parseVMList parses the output of `VBoxManage list vms`.
+func parseVMList(output string) []listedVM {
+	var result []listedVM
+	for _, line := range strings.Split(output, "\n") {
+		if m := vmListLine.FindStringSubmatch(strings.TrimRight(line, " \r")); m != nil {
+			result = append(result, listedVM{Name: m[1], UUID: m[2]})
+		}
+	}
+	return result
+}
+
+// parseExtraData parses the output of `VBoxManage getextradata`, returning
+// an empty string if the value is not set.
+func parseExtraData(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if strings.HasPrefix(line, "Value: ") {
+			return strings.TrimPrefix(line, "Value: ")
+		}
+	}
+	return ""
+}
+
+type listedMedium struct {
+	UUID         string
+	Location     string
+	Inaccessible bool
+	InUse        bool
+	// The virtual size of the medium in bytes, if listed
+	Capacity int64
+	// The UUID of the parent of a differencing disk, or "base"
+	Parent string
+	// The UUIDs of the VMs using the medium, and of their snapshots
+	UsedBy []string
+}
+
+var mediumUserUUID = regexp.MustCompile(`\(UUID: ([0-9a-fA-F-]+)\)`)
+
+// parseMediaList parses the output of `VBoxManage list hdds`, `list dvds`
+// or `list floppies`, which describe one medium per paragraph.
+func parseMediaList(output string) []listedMedium {
+	var result []listedMedium
+	var current *listedMedium
+	var lastKey string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		// The VMs using a medium after the first are listed on the next
+		// lines, indented
+		if strings.HasPrefix(line, " ") {
+			if current != nil && lastKey == "In use by VMs" {
+				for _, m := range mediumUserUUID.FindAllStringSubmatch(line, -1) {
+					current.UsedBy = append(current.UsedBy, m[1])
+				}
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		lastKey = key
+		value = strings.TrimSpace(value)
+		switch key {
+		case "UUID":
+			result = append(result, listedMedium{UUID: value})
+			current = &result[len(result)-1]
+		case "State":
+			if current != nil {
+				current.Inaccessible = value == "inaccessible"
+			}
+		case "Location":
+			if current != nil {
+				current.Location = value
+			}
+		case "In use by VMs":
+			if current != nil {
+				current.InUse = true
+				for _, m := range mediumUserUUID.FindAllStringSubmatch(value, -1) {
+					current.UsedBy = append(current.UsedBy, m[1])
+				}
+			}
+		case "Parent UUID":
+			if current != nil {
+				current.Parent = value
+			}
+		case "Capacity":
+			if current != nil {
+				current.Capacity = parseMediumSize(value)
+			}
+		}
+	}
+	return result
+}
+
+// parseMediumSize parses a size printed by VBoxManage, such as
+// "40960 MBytes", returning 0 if it can't.
+func parseMediumSize(value string) int64 {
+	number, unit, _ := strings.Cut(value, " ")
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0
+	}
+	switch unit {
+	case "bytes":
+		return size
+	case "KBytes":
+		return size << 10
+	case "MBytes":
+		return size << 20
+	case "GBytes":
+		return size << 30
+	case "TBytes":
+		return size << 40
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+type GCConfig struct {
+	// Delete what crashed or killed Packer builds left behind before
+	// starting the build: the VMs created by Packer, which are tagged with
+	// the `Packer/Created` extra data and named `packer-*` or
+	// `packer_temp_vm_*`, along with their disks, and the disk images that
+	// Packer recorded creating, in the Packer cache directory, and that no VM
+	// uses anymore. The DVD and floppy images of the VMs deleted are
+	// unregistered but not deleted. VMs that are running, VMs and disks
+	// younger than `gc_orphans_older_than`, and VMs kept with
+	// `keep_registered` are never deleted, nor any disk Packer didn't record
+	// creating. Defaults to `false`.
+	GCOrphans bool `mapstructure:"gc_orphans" required:"false"`
+	// How old VMs and images must be to be deleted by `gc_orphans`. Defaults
+	// to `24h`, so that the VMs of builds running in parallel are left alone.
+	GCOrphansOlderThan time.Duration `mapstructure:"gc_orphans_older_than" required:"false"`
+	// Only list what `gc_orphans` would delete. Defaults to `false`.
+	GCOrphansDryRun bool `mapstructure:"gc_orphans_dry_run" required:"false"`
+}
+
+func (c *GCConfig) Prepare(ctx *interpolate.Context) []error {
+	var errs []error
+
+	if c.GCOrphansOlderThan == 0 {
+		c.GCOrphansOlderThan = 24 * time.Hour
+	}
+	if c.GCOrphansOlderThan < 0 {
+		errs = append(errs, fmt.Errorf("gc_orphans_older_than must not be negative"))
+	}
+
+	return errs
+}
@@ -0,0 +1,193 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Headers that only apply to a single connection and must not be forwarded.
+var hopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// guestProxy is a small forward proxy handed to guests. Plain HTTP requests
+// are forwarded and CONNECT requests are tunnelled, either directly or
+// through an upstream HTTP proxy whose credentials stay on the host.
+type guestProxy struct {
+	upstream  *url.URL
+	transport *http.Transport
+	dialer    net.Dialer
+}
+
+func newGuestProxy(upstream *url.URL) *guestProxy {
+	transport := &http.Transport{
+		TLSHandshakeTimeout: 30 * time.Second,
+	}
+	if upstream != nil {
+		transport.Proxy = http.ProxyURL(upstream)
+	}
+
+	return &guestProxy{
+		upstream:  upstream,
+		transport: transport,
+		dialer:    net.Dialer{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *guestProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.serveConnect(w, r)
+		return
+	}
+
+	if !r.URL.IsAbs() {
+		http.Error(w, "this is a proxy, requests must use an absolute URL", http.StatusBadRequest)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	for _, h := range hopByHopHeaders {
+		out.Header.Del(h)
+	}
+
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		log.Printf("[DEBUG] guest proxy: %s %s: %s", r.Method, r.URL, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for _, h := range hopByHopHeaders {
+		resp.Header.Del(h)
+	}
+	for k, vv := range resp.Header {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+func (p *guestProxy) serveConnect(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "CONNECT is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	remote, err := p.dial(r.Context(), r.Host)
+	if err != nil {
+		log.Printf("[DEBUG] guest proxy: CONNECT %s: %s", r.Host, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer remote.Close()
+
+	client, buf, err := hijacker.Hijack()
+	if err != nil {
+		log.Printf("[DEBUG] guest proxy: CONNECT %s: %s", r.Host, err)
+		return
+	}
+	defer client.Close()
+
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		return
+	}
+
+	// The tunnel ends when the remote side is done; closing both
+	// connections then stops the copy in the other direction.
+	go func() {
+		io.Copy(remote, buf.Reader)
+		if c, ok := remote.(interface{ CloseWrite() error }); ok {
+			c.CloseWrite()
+		}
+	}()
+	io.Copy(client, remote)
+}
+
+// dial opens a connection to addr, tunnelling through the upstream proxy
+// when there is one.
+func (p *guestProxy) dial(ctx context.Context, addr string) (net.Conn, error) {
+	if p.upstream == nil {
+		return p.dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	proxyAddr := p.upstream.Host
+	if p.upstream.Port() == "" {
+		proxyAddr = net.JoinHostPort(p.upstream.Hostname(), "80")
+	}
+	conn, err := p.dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u := p.upstream.User; u != nil {
+		password, _ := u.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("upstream proxy refused CONNECT to %s: %s", addr, resp.Status)
+	}
+
+	return &bufferedConn{Conn: conn, r: br}, nil
+}
+
+// bufferedConn is a net.Conn whose reads go through a bufio.Reader that may
+// already hold data read past the upstream proxy's response.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func (c *bufferedConn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
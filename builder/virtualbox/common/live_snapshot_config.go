@@ -0,0 +1,75 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+// The build variable listing the milestones a resumed build has already
+// passed, available to provisioners as `{{ build.CompletedMilestones }}`.
+var LiveSnapshotGeneratedData = []string{"CompletedMilestones"}
+
+type LiveSnapshotConfig struct {
+	// The names of the provisioning milestones at which a live snapshot of
+	// the running VM is taken, in the order they are reached. A provisioner
+	// reports a milestone by setting the `/Packer/Milestone` guest property,
+	// for example with `VBoxControl guestproperty set /Packer/Milestone
+	// updates`, which requires the guest additions. Packer then takes the
+	// snapshot `<live_snapshot_prefix><milestone>` and sets the
+	// `/Packer/MilestoneDone` guest property to the milestone name, which the
+	// provisioner should wait for with `VBoxControl guestproperty wait
+	// /Packer/MilestoneDone`. A failed snapshot is reported but does not stop
+	// the build.
+	LiveSnapshotMilestones []string `mapstructure:"live_snapshot_milestones" required:"false"`
+	// The prefix of the names of the live snapshots. Defaults to
+	// `packer-milestone-`.
+	LiveSnapshotPrefix string `mapstructure:"live_snapshot_prefix" required:"false"`
+	// Keep the live snapshots when the build succeeds. Defaults to `false`.
+	// When it fails, the `virtualbox-vm` builder keeps them, so that the build
+	// can be resumed with `resume_from_snapshot`. The `virtualbox-iso` and
+	// `virtualbox-ovf` builders delete the VM along with its snapshots
+	// instead, unless Packer runs with `-on-error=abort`, and cannot resume.
+	KeepLiveSnapshots bool `mapstructure:"keep_live_snapshots" required:"false"`
+}
+
+func (c *LiveSnapshotConfig) Prepare(ctx *interpolate.Context) []error {
+	var errs []error
+
+	if c.LiveSnapshotPrefix == "" {
+		c.LiveSnapshotPrefix = "packer-milestone-"
+	}
+
+	seen := make(map[string]bool)
+	for _, milestone := range c.LiveSnapshotMilestones {
+		if milestone == "" || strings.ContainsAny(milestone, " \t\n") {
+			errs = append(errs, fmt.Errorf("live_snapshot_milestones: %q is not a valid milestone name", milestone))
+		} else if seen[milestone] {
+			errs = append(errs, fmt.Errorf("live_snapshot_milestones: %q is listed more than once", milestone))
+		}
+		seen[milestone] = true
+	}
+
+	return errs
+}
+
+// Milestone returns the index of the milestone a live snapshot was taken at,
+// or false if the snapshot name does not match one.
+func (c *LiveSnapshotConfig) Milestone(snapshotName string) (int, bool) {
+	if !strings.HasPrefix(snapshotName, c.LiveSnapshotPrefix) {
+		return 0, false
+	}
+	name := strings.TrimPrefix(snapshotName, c.LiveSnapshotPrefix)
+	for i, milestone := range c.LiveSnapshotMilestones {
+		if milestone == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,42 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+func TestLiveSnapshotConfigPrepare(t *testing.T) {
+	var c *LiveSnapshotConfig
+	var errs []error
+
+	// Good
+	c = &LiveSnapshotConfig{
+		LiveSnapshotMilestones: []string{"updates", "drivers"},
+	}
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+	if c.LiveSnapshotPrefix != "packer-milestone-" {
+		t.Fatalf("bad prefix: %s", c.LiveSnapshotPrefix)
+	}
+	if i, ok := c.Milestone("packer-milestone-drivers"); !ok || i != 1 {
+		t.Fatalf("bad milestone: %d %t", i, ok)
+	}
+	if _, ok := c.Milestone("drivers"); ok {
+		t.Fatal("should not match a snapshot without the prefix")
+	}
+
+	// Bad
+	c = &LiveSnapshotConfig{
+		LiveSnapshotMilestones: []string{"updates", "", "windows updates", "updates"},
+	}
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) != 3 {
+		t.Fatalf("should have 3 errors: %s", errs)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+const (
+	MACAddressPolicyRandom = "random"
+	MACAddressPolicyFixed  = "fixed"
+	MACAddressPolicyVMName = "vm-name"
+)
+
+// The organizationally unique identifier VirtualBox uses for generated MAC
+// addresses.
+const virtualBoxOUI = "080027"
+
+type MACAddressConfig struct {
+	// How the MAC addresses of the network adapters are assigned during the
+	// build. When set to `random`, VirtualBox generates a new MAC address for
+	// every adapter. When set to `fixed`, the addresses listed in
+	// `mac_addresses` are used. When set to `vm-name`, a stable address is
+	// derived from the VM name and the adapter number, so that rebuilding a VM
+	// with the same name yields the same addresses. By default the MAC
+	// addresses assigned by VirtualBox or by the source VM are left alone.
+	// The `virtualbox-vm` builder puts the MAC addresses of the VM back as
+	// they were once the build is done.
+	MACAddressPolicy string `mapstructure:"mac_address_policy" required:"false"`
+	// The MAC addresses to assign when `mac_address_policy` is `fixed`. The
+	// first address is assigned to network adapter 1, the second to adapter
+	// 2, and so on. Addresses can be written with or without `:` or `-`
+	// separators, e.g. `08:00:27:12:34:56` or `080027123456`.
+	MACAddresses []string `mapstructure:"mac_addresses" required:"false"`
+}
+
+func (c *MACAddressConfig) Prepare(ctx *interpolate.Context) []error {
+	var errs []error
+
+	switch c.MACAddressPolicy {
+	case "", MACAddressPolicyRandom, MACAddressPolicyVMName:
+		if len(c.MACAddresses) > 0 {
+			errs = append(errs, fmt.Errorf("mac_addresses can only be set when mac_address_policy is %q", MACAddressPolicyFixed))
+		}
+	case MACAddressPolicyFixed:
+		if len(c.MACAddresses) == 0 {
+			errs = append(errs, fmt.Errorf("mac_addresses must be set when mac_address_policy is %q", MACAddressPolicyFixed))
+		}
+		if len(c.MACAddresses) > maxNetworkAdapters {
+			errs = append(errs, fmt.Errorf("mac_addresses cannot contain more than %d addresses", maxNetworkAdapters))
+		}
+		for i, address := range c.MACAddresses {
+			normalized, err := normalizeMACAddress(address)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("mac_addresses: %s", err))
+				continue
+			}
+			c.MACAddresses[i] = normalized
+		}
+	default:
+		errs = append(errs, fmt.Errorf("mac_address_policy must be one of %q, %q or %q",
+			MACAddressPolicyRandom, MACAddressPolicyFixed, MACAddressPolicyVMName))
+	}
+
+	return errs
+}
+
+// normalizeMACAddress converts a MAC address to the format expected by
+// VBoxManage, which is 12 upper case hexadecimal digits.
+func normalizeMACAddress(address string) (string, error) {
+	normalized := strings.ToUpper(strings.NewReplacer(":", "", "-", "").Replace(address))
+	raw, err := hex.DecodeString(normalized)
+	if err != nil || len(raw) != 6 {
+		return "", fmt.Errorf("invalid MAC address %q", address)
+	}
+	if raw[0]&1 == 1 {
+		return "", fmt.Errorf("MAC address %q is a multicast address", address)
+	}
+	return normalized, nil
+}
+
+// DeriveMACAddress returns a MAC address in the VirtualBox range that is
+// stable for a given VM name and network adapter.
+func DeriveMACAddress(vmName string, adapter int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", vmName, adapter)))
+	return virtualBoxOUI + strings.ToUpper(hex.EncodeToString(sum[:3]))
+}
@@ -0,0 +1,77 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMACAddressConfigPrepare(t *testing.T) {
+	var c *MACAddressConfig
+	var errs []error
+
+	// Good
+	c = new(MACAddressConfig)
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+
+	// Good
+	c = &MACAddressConfig{
+		MACAddressPolicy: MACAddressPolicyFixed,
+		MACAddresses:     []string{"08:00:27:ab:cd:ef", "080027123456"},
+	}
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+	assert.Equal(t, []string{"080027ABCDEF", "080027123456"}, c.MACAddresses)
+
+	// Bad, fixed without addresses
+	c = &MACAddressConfig{MACAddressPolicy: MACAddressPolicyFixed}
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+
+	// Bad, addresses without fixed policy
+	c = &MACAddressConfig{
+		MACAddressPolicy: MACAddressPolicyRandom,
+		MACAddresses:     []string{"080027123456"},
+	}
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+
+	// Bad, invalid and multicast addresses
+	c = &MACAddressConfig{
+		MACAddressPolicy: MACAddressPolicyFixed,
+		MACAddresses:     []string{"08:00:27", "01:00:5e:00:00:01"},
+	}
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) != 2 {
+		t.Fatalf("should have 2 errors: %s", errs)
+	}
+
+	// Bad policy
+	c = &MACAddressConfig{MACAddressPolicy: "bad"}
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+}
+
+func TestDeriveMACAddress(t *testing.T) {
+	mac := DeriveMACAddress("packer-foo", 1)
+	assert.Len(t, mac, 12)
+	assert.Equal(t, "080027", mac[:6])
+	assert.Equal(t, mac, DeriveMACAddress("packer-foo", 1))
+	assert.NotEqual(t, mac, DeriveMACAddress("packer-foo", 2))
+	assert.NotEqual(t, mac, DeriveMACAddress("packer-bar", 1))
+}
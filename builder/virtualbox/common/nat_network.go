@@ -0,0 +1,86 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// The offset in the IPv6 prefix of a NAT Network the host loopback address
+// is mapped to with `--loopback-6 2`, like 10.0.2.2 on a NAT adapter.
+const natNetworkLoopback6Offset = 2
+
+// natNetworkIPv6Prefix returns the IPv6 prefix of a NAT Network, from the
+// output of `VBoxManage natnetwork list`, which describes one network per
+// paragraph.
+func natNetworkIPv6Prefix(driver Driver, name string) (netip.Prefix, error) {
+	output, err := driver.VBoxManageWithOutput("natnetwork", "list")
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	current := ""
+	found, enabled := false, false
+	var prefix string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimRight(scanner.Text(), " \r"), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		// NetworkName before VirtualBox 7
+		case "Name", "NetworkName":
+			current = value
+			found = found || current == name
+		// IPv6 Enabled before VirtualBox 7
+		case "IPv6", "IPv6 Enabled":
+			if current == name {
+				enabled = value == "Yes"
+			}
+		case "IPv6 Prefix":
+			if current == name {
+				prefix = value
+			}
+		}
+	}
+
+	switch {
+	case !found:
+		return netip.Prefix{}, fmt.Errorf("there is no NAT Network named %q", name)
+	case !enabled || prefix == "":
+		return netip.Prefix{}, fmt.Errorf("IPv6 is disabled on NAT Network %q", name)
+	}
+	p, err := netip.ParsePrefix(prefix)
+	if err != nil || !p.Addr().Is6() || p.Bits() > 64 {
+		return netip.Prefix{}, fmt.Errorf("NAT Network %q has an invalid IPv6 prefix %q", name, prefix)
+	}
+	return p.Masked(), nil
+}
+
+// natNetworkHostIPv6 returns the address the guests of a NAT Network reach
+// the host loopback address at.
+func natNetworkHostIPv6(prefix netip.Prefix) netip.Addr {
+	a := prefix.Addr().As16()
+	a[15] = natNetworkLoopback6Offset
+	return netip.AddrFrom16(a)
+}
+
+// eui64Address returns the address a guest configures in prefix with SLAAC
+// from the MAC address of its adapter, as showvminfo prints it, when it
+// doesn't use privacy or stable private addresses.
+func eui64Address(prefix netip.Prefix, mac string) (netip.Addr, error) {
+	raw, err := hex.DecodeString(mac)
+	if err != nil || len(raw) != 6 {
+		return netip.Addr{}, fmt.Errorf("invalid MAC address %q", mac)
+	}
+	a := prefix.Addr().As16()
+	copy(a[8:], []byte{raw[0] ^ 0x02, raw[1], raw[2], 0xff, 0xfe, raw[3], raw[4], raw[5]})
+	return netip.AddrFrom16(a), nil
+}
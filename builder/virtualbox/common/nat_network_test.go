@@ -0,0 +1,75 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The output of `VBoxManage natnetwork list` with VirtualBox 7
+const testNATNetworkList = `NAT Networks:
+
+Name:         NatNetwork
+Network:      10.0.2.0/24
+Gateway:      10.0.2.1
+DHCP Server:  Yes
+IPv6:         No
+IPv6 Prefix:  fd17:625c:f037:2::/64
+IPv6 Default: No
+Enabled:      Yes
+
+Name:         packer6
+Network:      10.0.3.0/24
+Gateway:      10.0.3.1
+DHCP Server:  Yes
+IPv6:         Yes
+IPv6 Prefix:  fd17:625c:f037:3::/64
+IPv6 Default: No
+Enabled:      Yes
+
+2 networks found
+`
+
+func TestNATNetworkIPv6Prefix(t *testing.T) {
+	driver := &DriverMock{VBoxManageWithOutputResult: testNATNetworkList}
+
+	prefix, err := natNetworkIPv6Prefix(driver, "packer6")
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("fd17:625c:f037:3::/64"), prefix)
+	assert.Equal(t, "fd17:625c:f037:3::2", natNetworkHostIPv6(prefix).String())
+
+	_, err = natNetworkIPv6Prefix(driver, "NatNetwork")
+	assert.EqualError(t, err, `IPv6 is disabled on NAT Network "NatNetwork"`)
+	_, err = natNetworkIPv6Prefix(driver, "missing")
+	assert.EqualError(t, err, `there is no NAT Network named "missing"`)
+
+	// Before VirtualBox 7
+	driver.VBoxManageWithOutputResult = `NetworkName:    packer6
+IP:             10.0.3.1
+Network:        10.0.3.0/24
+IPv6 Enabled:   Yes
+IPv6 Prefix:    fd17:625c:f037:3::/64
+DHCP Enabled:   Yes
+Enabled:        Yes
+loopback mappings (ipv4)
+        127.0.0.1=2
+`
+	prefix, err = natNetworkIPv6Prefix(driver, "packer6")
+	assert.NoError(t, err)
+	assert.Equal(t, netip.MustParsePrefix("fd17:625c:f037:3::/64"), prefix)
+}
+
+func TestEUI64Address(t *testing.T) {
+	prefix := netip.MustParsePrefix("fd17:625c:f037:3::/64")
+
+	addr, err := eui64Address(prefix, "080027AB12CD")
+	assert.NoError(t, err)
+	assert.Equal(t, "fd17:625c:f037:3:a00:27ff:feab:12cd", addr.String())
+
+	_, err = eui64Address(prefix, "08:00:27:AB:12:CD")
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+type NetworkIsolationConfig struct {
+	// Defaults to `false`. When enabled, the guest cannot reach the internet
+	// while it is being built. Packer attaches the first network adapter to
+	// NAT, binds the outgoing connections of the NAT engine to the host
+	// loopback address so that only services listening on the host (such as
+	// the Packer HTTP server) are reachable through `10.0.2.2`, disables the
+	// NAT DNS proxy and host resolver, and detaches all other network
+	// adapters. Before the virtual machine is started, its configuration is
+	// checked again so that changes made through `vboxmanage` that would leak
+	// external connectivity fail the build. The `virtualbox-vm` builder puts
+	// the network settings of the virtual machine back as they were once the
+	// build is done.
+	NetworkIsolation bool `mapstructure:"network_isolation" required:"false"`
+	// The host ports, besides the communicator port, that NAT port forwarding
+	// rules are allowed to use while `network_isolation` is enabled. Rules must
+	// still bind to a loopback address on the host.
+	NetworkIsolationAllowedHostPorts []int `mapstructure:"network_isolation_allowed_host_ports" required:"false"`
+}
+
+func (c *NetworkIsolationConfig) Prepare(ctx *interpolate.Context, comm *CommConfig) []error {
+	var errs []error
+
+	if c.NetworkIsolation && comm.NATNetwork != "" {
+		errs = append(errs, fmt.Errorf("network_isolation attaches the first network adapter to NAT and can't be used with nat_network"))
+	}
+
+	if !c.NetworkIsolation && len(c.NetworkIsolationAllowedHostPorts) > 0 {
+		errs = append(errs, fmt.Errorf("network_isolation_allowed_host_ports requires network_isolation to be enabled"))
+	}
+
+	for _, port := range c.NetworkIsolationAllowedHostPorts {
+		if port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("network_isolation_allowed_host_ports: invalid port %d", port))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,58 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+func TestNetworkIsolationConfigPrepare(t *testing.T) {
+	var c *NetworkIsolationConfig
+	var errs []error
+
+	// Good
+	c = new(NetworkIsolationConfig)
+	errs = c.Prepare(interpolate.NewContext(), new(CommConfig))
+	if len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+
+	// Good
+	c = &NetworkIsolationConfig{
+		NetworkIsolation:                 true,
+		NetworkIsolationAllowedHostPorts: []int{8080},
+	}
+	errs = c.Prepare(interpolate.NewContext(), new(CommConfig))
+	if len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+
+	// Bad, ports without isolation
+	c = &NetworkIsolationConfig{
+		NetworkIsolationAllowedHostPorts: []int{8080},
+	}
+	errs = c.Prepare(interpolate.NewContext(), new(CommConfig))
+	if len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+
+	// Bad, invalid port
+	c = &NetworkIsolationConfig{
+		NetworkIsolation:                 true,
+		NetworkIsolationAllowedHostPorts: []int{0, 70000},
+	}
+	errs = c.Prepare(interpolate.NewContext(), new(CommConfig))
+	if len(errs) != 2 {
+		t.Fatalf("should have 2 errors: %s", errs)
+	}
+
+	// Bad, the first network adapter can't be on a NAT Network
+	c = &NetworkIsolationConfig{NetworkIsolation: true}
+	errs = c.Prepare(interpolate.NewContext(), &CommConfig{NATNetwork: "packer6"})
+	if len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+}
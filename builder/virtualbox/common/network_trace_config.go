@@ -0,0 +1,49 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+
+package common
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+type NetworkTraceConfig struct {
+	// The network adapters, numbered from 1, whose traffic is captured for
+	// the whole build. Each capture is written to
+	// `<output_directory>/<vm_name>-nic<N>.pcap`. Tracing is turned off
+	// before the VM is exported, and the capture files are deleted at the end
+	// of the build unless `network_trace_in_artifact` or
+	// `keep_network_trace_on_failure` says otherwise.
+	NetworkTrace []int `mapstructure:"network_trace" required:"false"`
+	// Keep the capture files of a successful build in the output directory
+	// and list them in the artifact. Defaults to `false`.
+	NetworkTraceInArtifact bool `mapstructure:"network_trace_in_artifact" required:"false"`
+	// Keep the capture files when the build fails. Because the output
+	// directory is deleted on failure, the files are moved next to it.
+	// Defaults to `false`.
+	KeepNetworkTraceOnFailure bool `mapstructure:"keep_network_trace_on_failure" required:"false"`
+}
+
+func (c *NetworkTraceConfig) Prepare(ctx *interpolate.Context) []error {
+	var errs []error
+
+	seen := make(map[int]bool)
+	for _, adapter := range c.NetworkTrace {
+		if adapter < 1 || adapter > maxNetworkAdapters {
+			errs = append(errs, fmt.Errorf("network_trace: adapter %d must be between 1 and %d", adapter, maxNetworkAdapters))
+		} else if seen[adapter] {
+			errs = append(errs, fmt.Errorf("network_trace: adapter %d is listed more than once", adapter))
+		}
+		seen[adapter] = true
+	}
+
+	if len(c.NetworkTrace) == 0 && (c.NetworkTraceInArtifact || c.KeepNetworkTraceOnFailure) {
+		errs = append(errs, fmt.Errorf("network_trace_in_artifact and keep_network_trace_on_failure require network_trace"))
+	}
+
+	return errs
+}
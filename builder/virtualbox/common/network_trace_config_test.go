@@ -0,0 +1,50 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+func TestNetworkTraceConfigPrepare(t *testing.T) {
+	var c *NetworkTraceConfig
+	var errs []error
+
+	// Good
+	c = new(NetworkTraceConfig)
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+
+	// Good
+	c = &NetworkTraceConfig{
+		NetworkTrace:              []int{1, 2},
+		KeepNetworkTraceOnFailure: true,
+	}
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+
+	// Bad adapters
+	c = &NetworkTraceConfig{
+		NetworkTrace: []int{0, 1, 1, 9},
+	}
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) != 3 {
+		t.Fatalf("should have 3 errors: %s", errs)
+	}
+
+	// Bad, options without adapters
+	c = &NetworkTraceConfig{
+		NetworkTraceInArtifact: true,
+	}
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+}
@@ -0,0 +1,456 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common/ovfmodel"
+)
+
+// manifestSigner signs OVF manifests with an X.509 certificate.
+type manifestSigner struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     crypto.Signer
+}
+
+// loadManifestSigner reads a PEM encoded certificate and its private key.
+func loadManifestSigner(certPath, keyPath string) (*manifestSigner, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("export_signing_certificate is invalid: %s", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("export_signing_certificate is invalid: no PEM encoded certificate in %s", certPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("export_signing_certificate is invalid: %s", err)
+	}
+
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("export_signing_key is invalid: %s", err)
+	}
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("export_signing_key is invalid: %s", err)
+	}
+	public, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !public.Equal(cert.PublicKey) {
+		return nil, fmt.Errorf("export_signing_key is not the key of export_signing_certificate")
+	}
+
+	return &manifestSigner{
+		cert:    cert,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
+		key:     key,
+	}, nil
+}
+
+// signer returns the signer of the manifest, or nil if it isn't signed.
+func (c *ExportManifestConfig) signer() (*manifestSigner, error) {
+	if c.ExportSigningCertificate == "" {
+		return nil, nil
+	}
+	return loadManifestSigner(c.ExportSigningCertificate, c.ExportSigningKey)
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded private key found")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "ENCRYPTED PRIVATE KEY":
+		return nil, fmt.Errorf("encrypted private keys are not supported")
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("only RSA and ECDSA keys are supported")
+}
+
+// certificate returns the content of the .cert file signing manifest: the
+// signature of the manifest digest, followed by the certificate.
+func (s *manifestSigner) certificate(algorithm, manifestName string, manifest []byte) ([]byte, error) {
+	var hash crypto.Hash
+	switch algorithm {
+	case "sha1":
+		hash = crypto.SHA1
+	case "sha256":
+		hash = crypto.SHA256
+	case "sha512":
+		hash = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("unsupported manifest digest %s", algorithm)
+	}
+	h := hash.New()
+	h.Write(manifest)
+	signature, err := s.key.Sign(rand.Reader, h.Sum(nil), hash)
+	if err != nil {
+		return nil, err
+	}
+
+	line := manifestEntry{manifestName, hex.EncodeToString(signature)}.line(algorithm)
+	return append([]byte(line), s.certPEM...), nil
+}
+
+// manifestEntry is the digest of a file of an appliance.
+type manifestEntry struct {
+	name   string
+	digest string
+}
+
+func (e manifestEntry) line(algorithm string) string {
+	return fmt.Sprintf("%s (%s) = %s\n", strings.ToUpper(algorithm), e.name, e.digest)
+}
+
+func digest(algorithm string, r io.Reader) (string, error) {
+	sums, err := digests(r, algorithm)
+	if err != nil {
+		return "", err
+	}
+	return sums[0], nil
+}
+
+// digests returns the digests of what r reads, one per algorithm, reading
+// it once.
+func digests(r io.Reader, algorithms ...string) ([]string, error) {
+	var writers []io.Writer
+	var hashes []hash.Hash
+	for _, algorithm := range algorithms {
+		h, err := ovfmodel.NewHash(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+		writers = append(writers, h)
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), r); err != nil {
+		return nil, err
+	}
+	var sums []string
+	for _, h := range hashes {
+		sums = append(sums, hex.EncodeToString(h.Sum(nil)))
+	}
+	return sums, nil
+}
+
+func digestFiles(path string, algorithms ...string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return digests(f, algorithms...)
+}
+
+// descriptorEdit edits the OVF descriptor of an exported appliance.
+type descriptorEdit func([]byte) ([]byte, error)
+
+// finishOVF edits the descriptor of the exported .ovf appliance at path, if
+// edit isn't nil, and writes the manifest of the appliance, signed if c says
+// so, and its checksum file, if c sets a manifest. It returns the files
+// written next to the appliance and the sha256 checksums of the files of
+// the appliance, by name, computed in the same pass as the manifest.
+func finishOVF(path string, edit descriptorEdit, c *ExportManifestConfig) ([]string, map[string]string, error) {
+	if edit != nil {
+		if err := ovfmodel.UpdateDescriptor(path, edit); err != nil {
+			return nil, nil, err
+		}
+	}
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	manifestName, certName := base+".mf", base+".cert"
+	algorithm := c.ExportManifest
+	dir := filepath.Dir(path)
+
+	entries, checksums, err := ovfDigests(path, algorithm)
+	if algorithm == "" {
+		// The checksums are only for the artifact metadata, which does
+		// without them
+		if err != nil {
+			log.Printf("Error checksumming %s: %s", path, err)
+		}
+		return nil, checksums, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, err := c.signer()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	written := []string{filepath.Join(dir, manifestName)}
+	files := map[string][]byte{manifestName: manifestContent(algorithm, entries)}
+	certPath := filepath.Join(dir, certName)
+	if signer != nil {
+		cert, err := signer.certificate(algorithm, manifestName, files[manifestName])
+		if err != nil {
+			return nil, nil, err
+		}
+		files[certName] = cert
+		written = append(written, certPath)
+	} else if err := os.Remove(certPath); err != nil && !os.IsNotExist(err) {
+		// Don't leave the certificate of an earlier manifest behind
+		return nil, nil, err
+	}
+	for _, name := range []string{manifestName, certName} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return nil, nil, err
+		}
+		sums, _ := digests(bytes.NewReader(content), algorithm, "sha256")
+		entries = append(entries, manifestEntry{name, sums[0]})
+		checksums[name] = sums[1]
+	}
+
+	checksumPath, checksum, err := writeChecksumFile(path, algorithm, entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	checksums[filepath.Base(checksumPath)] = checksum
+	return append(written, checksumPath), checksums, nil
+}
+
+// ovfDigests returns the manifest entries of the files of the .ovf
+// appliance at path, if algorithm is set, and their sha256 checksums, by
+// name, reading them once.
+func ovfDigests(path, algorithm string) ([]manifestEntry, map[string]string, error) {
+	algorithms := []string{"sha256"}
+	if algorithm != "" {
+		algorithms = append(algorithms, algorithm)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	descriptor, err := ovfmodel.Parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var entries []manifestEntry
+	checksums := make(map[string]string)
+	dir := filepath.Dir(path)
+	for _, name := range append([]string{filepath.Base(path)}, descriptor.References()...) {
+		sums, err := digestFiles(filepath.Join(dir, name), algorithms...)
+		if err != nil {
+			return nil, nil, err
+		}
+		checksums[name] = sums[0]
+		if algorithm != "" {
+			entries = append(entries, manifestEntry{name, sums[1]})
+		}
+	}
+	return entries, checksums, nil
+}
+
+// finishOVA does what finishOVF does for an exported .ova appliance, in a
+// single rewrite of the OVA: the descriptor is edited and the manifest and
+// certificate written after it, replacing those of VBoxManage. The OVA is
+// read once before, to digest its disks for the manifest.
+func finishOVA(path string, edit descriptorEdit, c *ExportManifestConfig) ([]string, map[string]string, error) {
+	if edit == nil && c.ExportManifest == "" {
+		// Nothing to rewrite, the OVA is only read for the checksum of the
+		// artifact metadata, which does without it
+		sums, err := digestFiles(path, "sha256")
+		if err != nil {
+			log.Printf("Error checksumming %s: %s", path, err)
+			return nil, nil, nil
+		}
+		return nil, map[string]string{filepath.Base(path): sums[0]}, nil
+	}
+	signer, err := c.signer()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	manifestName, certName := base+".mf", base+".cert"
+	algorithm := c.ExportManifest
+
+	var disks []manifestEntry
+	if algorithm != "" {
+		if disks, err = ovaDiskDigests(path, algorithm); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	rewrite := func(hdr *tar.Header, content []byte, files map[string][]byte) ([]ovfmodel.OVAFile, error) {
+		var err error
+		switch ext := strings.ToLower(filepath.Ext(hdr.Name)); {
+		case ext == ".ovf":
+			if edit != nil {
+				if content, err = edit(content); err != nil {
+					return nil, err
+				}
+			}
+			written := []ovfmodel.OVAFile{{Header: hdr, Content: content}}
+			if algorithm == "" {
+				return written, nil
+			}
+			sum, _ := digest(algorithm, bytes.NewReader(content))
+			manifest := manifestContent(algorithm, append([]manifestEntry{{hdr.Name, sum}}, disks...))
+			written = append(written, ovfmodel.OVAFile{Header: ovaFileHeader(hdr, manifestName), Content: manifest})
+			if signer != nil {
+				cert, err := signer.certificate(algorithm, manifestName, manifest)
+				if err != nil {
+					return nil, err
+				}
+				written = append(written, ovfmodel.OVAFile{Header: ovaFileHeader(hdr, certName), Content: cert})
+			}
+			return written, nil
+		case algorithm != "":
+			// The manifest and certificate of VBoxManage, replaced
+			return nil, nil
+		case ext == ".mf":
+			// The descriptor comes first in an OVA, the manifest after it
+			for name, descriptor := range files {
+				if strings.EqualFold(filepath.Ext(name), ".ovf") {
+					content, err = ovfmodel.UpdateManifest(content, name, descriptor)
+				}
+			}
+		}
+		return []ovfmodel.OVAFile{{Header: hdr, Content: content}}, err
+	}
+
+	// Checksum the new OVA as it is written
+	checksum := sha256.New()
+	digests := []hash.Hash{checksum}
+	var manifestDigest hash.Hash
+	if algorithm != "" {
+		if manifestDigest, err = ovfmodel.NewHash(algorithm); err != nil {
+			return nil, nil, err
+		}
+		digests = append(digests, manifestDigest)
+	}
+	if err := ovfmodel.RewriteOVA(path, rewrite, digests...); err != nil {
+		return nil, nil, err
+	}
+	checksums := map[string]string{filepath.Base(path): hex.EncodeToString(checksum.Sum(nil))}
+
+	if algorithm == "" {
+		return nil, checksums, nil
+	}
+	checksumPath, checksumFileSum, err := writeChecksumFile(path, algorithm, []manifestEntry{
+		{filepath.Base(path), hex.EncodeToString(manifestDigest.Sum(nil))},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	checksums[filepath.Base(checksumPath)] = checksumFileSum
+	return []string{checksumPath}, checksums, nil
+}
+
+// ovaDiskDigests returns the digests of the files of an OVA but its
+// descriptor, manifest and certificate.
+func ovaDiskDigests(path, algorithm string) ([]manifestEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []manifestEntry
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(filepath.Ext(hdr.Name)) {
+		case ".ovf", ".mf", ".cert":
+			continue
+		}
+		sum, err := digest(algorithm, tr)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, manifestEntry{hdr.Name, sum})
+	}
+}
+
+// isChecksumFile tells whether path is a checksum file written by
+// writeChecksumFile.
+func isChecksumFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".sha1", ".sha256", ".sha512":
+		return true
+	}
+	return false
+}
+
+// writeChecksumFile writes the checksums of an appliance next to it, in the
+// format of sha256sum and friends, and returns its path and sha256 checksum.
+func writeChecksumFile(path, algorithm string, entries []manifestEntry) (string, string, error) {
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%s  %s\n", e.digest, e.name)
+	}
+	checksumPath := path + "." + algorithm
+	if err := os.WriteFile(checksumPath, []byte(b.String()), 0644); err != nil {
+		return "", "", err
+	}
+	checksum := sha256.Sum256([]byte(b.String()))
+	return checksumPath, hex.EncodeToString(checksum[:]), nil
+}
+
+// ovaFileHeader returns the header of a file written next to the one of hdr.
+func ovaFileHeader(hdr *tar.Header, name string) *tar.Header {
+	h := *hdr
+	h.Name = name
+	return &h
+}
+
+func manifestContent(algorithm string, entries []manifestEntry) []byte {
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(e.line(algorithm))
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,273 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"archive/tar"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testReferencesDescriptor = `<?xml version="1.0"?>
+<Envelope ovf:version="1.0" xml:lang="en-US" xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1">
+  <References>
+    <File ovf:id="file1" ovf:href="packer-disk001.vmdk"/>
+  </References>
+  <VirtualSystem ovf:id="packer">
+    <Info>A virtual machine</Info>
+  </VirtualSystem>
+</Envelope>
+`
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeTestCertificate writes a self-signed certificate and its key, and
+// returns their paths.
+func writeTestCertificate(t *testing.T, dir string, notAfter time.Time) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Packer"},
+		NotBefore:    notAfter.Add(-48 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	certPath, keyPath := filepath.Join(dir, "signing.pem"), filepath.Join(dir, "signing.key")
+	err = os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	keyDER := x509.MarshalPKCS1PrivateKey(key)
+	err = os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return certPath, keyPath
+}
+
+// checkTestSignature checks that cert signs manifest.
+func checkTestSignature(t *testing.T, cert, manifestName, manifest string) {
+	line, certPEM, ok := strings.Cut(cert, "\n")
+	assert.True(t, ok)
+	prefix := "SHA256 (" + manifestName + ") = "
+	assert.True(t, strings.HasPrefix(line, prefix), line)
+	signature, err := hex.DecodeString(strings.TrimPrefix(line, prefix))
+	assert.NoError(t, err)
+
+	block, _ := pem.Decode([]byte(certPEM))
+	if block == nil {
+		t.Fatal("no certificate in the .cert file")
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	assert.NoError(t, err)
+	assert.NoError(t, certificate.CheckSignature(x509.SHA256WithRSA, []byte(manifest), signature))
+}
+
+func TestFinishOVF(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "packer.ovf")
+	disk := strings.Repeat("disk", 1000)
+	assert.NoError(t, os.WriteFile(path, []byte(testReferencesDescriptor), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "packer-disk001.vmdk"), []byte(disk), 0644))
+	certPath, keyPath := writeTestCertificate(t, t.TempDir(), time.Now().Add(time.Hour))
+
+	written, checksums, err := finishOVF(path, nil, &ExportManifestConfig{
+		ExportManifest:           "sha256",
+		ExportSigningCertificate: certPath,
+		ExportSigningKey:         keyPath,
+	})
+	assert.NoError(t, err)
+	checksumPath := path + ".sha256"
+	assert.Equal(t, []string{filepath.Join(dir, "packer.mf"), filepath.Join(dir, "packer.cert"), checksumPath}, written)
+	assert.Equal(t, sha256Hex(disk), checksums["packer-disk001.vmdk"])
+
+	manifest, err := os.ReadFile(filepath.Join(dir, "packer.mf"))
+	assert.NoError(t, err)
+	assert.Equal(t, "SHA256 (packer.ovf) = "+sha256Hex(testReferencesDescriptor)+
+		"\nSHA256 (packer-disk001.vmdk) = "+sha256Hex(disk)+"\n", string(manifest))
+
+	cert, err := os.ReadFile(filepath.Join(dir, "packer.cert"))
+	assert.NoError(t, err)
+	checkTestSignature(t, string(cert), "packer.mf", string(manifest))
+
+	checksumFile, err := os.ReadFile(checksumPath)
+	assert.NoError(t, err)
+	assert.Equal(t, sha256Hex(testReferencesDescriptor)+"  packer.ovf\n"+
+		sha256Hex(disk)+"  packer-disk001.vmdk\n"+
+		sha256Hex(string(manifest))+"  packer.mf\n"+
+		sha256Hex(string(cert))+"  packer.cert\n", string(checksumFile))
+	assert.Equal(t, sha256Hex(string(cert)), checksums["packer.cert"])
+
+	// Without signing, the certificate is removed
+	_, _, err = finishOVF(path, nil, &ExportManifestConfig{ExportManifest: "sha512"})
+	assert.NoError(t, err)
+	manifest, err = os.ReadFile(filepath.Join(dir, "packer.mf"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(manifest), "SHA512 (packer.ovf) = "))
+	assert.NoFileExists(t, filepath.Join(dir, "packer.cert"))
+	assert.FileExists(t, path+".sha512")
+}
+
+func TestFinishOVA(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packer.ova")
+	disk := strings.Repeat("disk", 1000)
+	// The manifest written by VBoxManage --manifest is replaced
+	writeTestOVA(t, path, map[string]string{
+		"packer.ovf":          testReferencesDescriptor,
+		"packer.mf":           "SHA1 (packer.ovf) = 0000\n",
+		"packer-disk001.vmdk": disk,
+	}, []string{"packer.ovf", "packer.mf", "packer-disk001.vmdk"})
+	certPath, keyPath := writeTestCertificate(t, t.TempDir(), time.Now().Add(time.Hour))
+
+	edited := strings.Replace(testReferencesDescriptor, "<References>", "<!-- Edited -->\n  <References>", 1)
+	edit := func(descriptor []byte) ([]byte, error) {
+		assert.Equal(t, testReferencesDescriptor, string(descriptor))
+		return []byte(edited), nil
+	}
+	written, checksums, err := finishOVA(path, edit, &ExportManifestConfig{
+		ExportManifest:           "sha256",
+		ExportSigningCertificate: certPath,
+		ExportSigningKey:         keyPath,
+	})
+	assert.NoError(t, err)
+	checksumPath := path + ".sha256"
+	assert.Equal(t, []string{checksumPath}, written)
+
+	// The manifest follows the edited descriptor
+	files, order := readTestOVA(t, path)
+	assert.Equal(t, []string{"packer.ovf", "packer.mf", "packer.cert", "packer-disk001.vmdk"}, order)
+	assert.Equal(t, edited, files["packer.ovf"])
+	assert.Equal(t, disk, files["packer-disk001.vmdk"])
+	assert.Equal(t, "SHA256 (packer.ovf) = "+sha256Hex(edited)+
+		"\nSHA256 (packer-disk001.vmdk) = "+sha256Hex(disk)+"\n", files["packer.mf"])
+	checkTestSignature(t, files["packer.cert"], "packer.mf", files["packer.mf"])
+
+	// The checksums of the OVA are computed while writing it
+	ova, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	checksumFile, err := os.ReadFile(checksumPath)
+	assert.NoError(t, err)
+	assert.Equal(t, sha256Hex(string(ova))+"  packer.ova\n", string(checksumFile))
+	assert.Equal(t, map[string]string{"packer.ova": sha256Hex(string(ova)), "packer.ova.sha256": sha256Hex(string(checksumFile))},
+		checksums)
+}
+
+func TestFinishOVA_noManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packer.ova")
+	disk := strings.Repeat("disk", 1000)
+	writeTestOVA(t, path, map[string]string{
+		"packer.ovf":          testReferencesDescriptor,
+		"packer.mf":           "SHA256 (packer.ovf) = " + sha256Hex(testReferencesDescriptor) + "\nSHA256 (packer-disk001.vmdk) = " + sha256Hex(disk) + "\n",
+		"packer-disk001.vmdk": disk,
+	}, []string{"packer.ovf", "packer.mf", "packer-disk001.vmdk"})
+
+	// Nothing to do but checksum the OVA
+	ova, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	written, checksums, err := finishOVA(path, nil, &ExportManifestConfig{})
+	assert.NoError(t, err)
+	assert.Empty(t, written)
+	assert.Equal(t, map[string]string{"packer.ova": sha256Hex(string(ova))}, checksums)
+
+	// The manifest of VBoxManage is updated
+	edited := testReferencesDescriptor + "\n"
+	written, _, err = finishOVA(path, func([]byte) ([]byte, error) { return []byte(edited), nil }, &ExportManifestConfig{})
+	assert.NoError(t, err)
+	assert.Empty(t, written)
+	files, order := readTestOVA(t, path)
+	assert.Equal(t, []string{"packer.ovf", "packer.mf", "packer-disk001.vmdk"}, order)
+	assert.Equal(t, "SHA256 (packer.ovf) = "+sha256Hex(edited)+"\nSHA256 (packer-disk001.vmdk) = "+sha256Hex(disk)+"\n", files["packer.mf"])
+}
+
+func TestLoadManifestSigner(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeTestCertificate(t, dir, time.Now().Add(time.Hour))
+	_, err := loadManifestSigner(certPath, keyPath)
+	assert.NoError(t, err)
+
+	// Swapped files
+	_, err = loadManifestSigner(keyPath, certPath)
+	assert.Error(t, err)
+
+	// A key that isn't the one of the certificate
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(other)
+	assert.NoError(t, err)
+	otherPath := filepath.Join(dir, "other.key")
+	assert.NoError(t, os.WriteFile(otherPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+	_, err = loadManifestSigner(certPath, otherPath)
+	assert.EqualError(t, err, "export_signing_key is not the key of export_signing_certificate")
+}
+
+func writeTestOVA(t *testing.T, path string, files map[string]string, order []string) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for _, name := range order {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name]))}); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func readTestOVA(t *testing.T, path string) (map[string]string, []string) {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer f.Close()
+	files := make(map[string]string)
+	var order []string
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		files[hdr.Name] = string(content)
+		order = append(order, hdr.Name)
+	}
+	return files, order
+}
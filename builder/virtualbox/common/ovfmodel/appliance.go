@@ -0,0 +1,278 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfmodel
+
+import (
+	"archive/tar"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ReadDescriptor returns the OVF descriptor of an exported .ovf or .ova
+// appliance.
+func ReadDescriptor(path string) ([]byte, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".ova") {
+		return os.ReadFile(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no OVF descriptor in %s", path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(filepath.Ext(hdr.Name), ".ovf") {
+			return io.ReadAll(tr)
+		}
+	}
+}
+
+// UpdateDescriptor rewrites the OVF descriptor of an exported .ovf or .ova
+// appliance with update, and its digest in the manifest if there is one.
+func UpdateDescriptor(path string, update func([]byte) ([]byte, error)) error {
+	if !strings.EqualFold(filepath.Ext(path), ".ova") {
+		descriptor, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if descriptor, err = update(descriptor); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, descriptor, 0644); err != nil {
+			return err
+		}
+
+		manifestPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".mf"
+		manifest, err := os.ReadFile(manifestPath)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		manifest, err = UpdateManifest(manifest, filepath.Base(path), descriptor)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(manifestPath, manifest, 0644)
+	}
+
+	return RewriteOVA(path, func(hdr *tar.Header, content []byte, files map[string][]byte) ([]OVAFile, error) {
+		var err error
+		switch strings.ToLower(filepath.Ext(hdr.Name)) {
+		case ".ovf":
+			content, err = update(content)
+		case ".mf":
+			// The descriptor comes first in an OVA, the manifest after it
+			for name, descriptor := range files {
+				if strings.EqualFold(filepath.Ext(name), ".ovf") {
+					content, err = UpdateManifest(content, name, descriptor)
+				}
+			}
+		}
+		return []OVAFile{{Header: hdr, Content: content}}, err
+	})
+}
+
+// OVAFile is a file written to an OVA by RewriteOVA.
+type OVAFile struct {
+	Header  *tar.Header
+	Content []byte
+}
+
+// RewriteOVA copies an OVA in place, keeping the order of its files and
+// passing the small ones to rewrite, which returns the files to write in
+// their place. The disk images are streamed as is. The new OVA is written to
+// digests too, to checksum it without reading it again.
+func RewriteOVA(path string, rewrite func(hdr *tar.Header, content []byte, files map[string][]byte) ([]OVAFile, error), digests ...hash.Hash) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	tr := tar.NewReader(in)
+	tw := tar.NewWriter(digestWriter(tmp, digests))
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch strings.ToLower(filepath.Ext(hdr.Name)) {
+		case ".ovf", ".mf", ".cert":
+			content, err := io.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			written, err := rewrite(hdr, content, files)
+			if err != nil {
+				return err
+			}
+			for _, f := range written {
+				files[f.Header.Name] = f.Content
+				f.Header.Size = int64(len(f.Content))
+				if err := tw.WriteHeader(f.Header); err != nil {
+					return err
+				}
+				if _, err := tw.Write(f.Content); err != nil {
+					return err
+				}
+			}
+		default:
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Rename(tmp.Name(), path)
+}
+
+var manifestLine = regexp.MustCompile(`^(\w+) ?\((.+)\) ?= ?([0-9a-fA-F]+)$`)
+
+// UpdateManifest replaces the digest of name in an OVF manifest.
+func UpdateManifest(manifest []byte, name string, content []byte) ([]byte, error) {
+	lines := strings.Split(string(manifest), "\n")
+	for i, line := range lines {
+		m := manifestLine.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil || m[2] != name {
+			continue
+		}
+		h, err := NewHash(m[1])
+		if err != nil {
+			return nil, err
+		}
+		h.Write(content)
+		lines[i] = fmt.Sprintf("%s (%s) = %s", m[1], name, hex.EncodeToString(h.Sum(nil)))
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+func NewHash(algorithm string) (hash.Hash, error) {
+	switch strings.ToUpper(algorithm) {
+	case "SHA1":
+		return sha1.New(), nil
+	case "SHA256":
+		return sha256.New(), nil
+	case "SHA512":
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported manifest digest %s", algorithm)
+}
+
+// PackOVA writes the OVA of the .ovf appliance at ovfPath to ovaPath: the
+// descriptor, its manifest and certificate if any, then the files it
+// references, as the OVF specification orders them. It returns the files of
+// the .ovf appliance, in that order. The OVA is written to digests too.
+func PackOVA(ovfPath, ovaPath string, digests ...hash.Hash) ([]string, error) {
+	data, err := os.ReadFile(ovfPath)
+	if err != nil {
+		return nil, err
+	}
+	descriptor, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(ovfPath)
+	base := strings.TrimSuffix(ovfPath, filepath.Ext(ovfPath))
+	files := []string{ovfPath}
+	for _, ext := range []string{".mf", ".cert"} {
+		if _, err := os.Stat(base + ext); err == nil {
+			files = append(files, base+ext)
+		}
+	}
+	for _, name := range descriptor.References() {
+		files = append(files, filepath.Join(dir, name))
+	}
+
+	out, err := os.Create(ovaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	tw := tar.NewWriter(digestWriter(out, digests))
+	for _, path := range files {
+		if err := addOVAFile(tw, path); err != nil {
+			os.Remove(ovaPath)
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		os.Remove(ovaPath)
+		return nil, err
+	}
+	return files, out.Close()
+}
+
+// digestWriter returns a writer writing to w and the digests.
+func digestWriter(w io.Writer, digests []hash.Hash) io.Writer {
+	writers := []io.Writer{w}
+	for _, h := range digests {
+		writers = append(writers, h)
+	}
+	return io.MultiWriter(writers...)
+}
+
+func addOVAFile(tw *tar.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	// The writer picks USTAR, like VirtualBox, unless a disk is too large
+	hdr := &tar.Header{
+		Name:    filepath.Base(path),
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
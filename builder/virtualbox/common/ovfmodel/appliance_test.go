@@ -0,0 +1,109 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfmodel
+
+import (
+	"archive/tar"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateDescriptor_ovf(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "packer.ovf")
+	digest := func(content string) string {
+		sum := sha1.Sum([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+	assert.NoError(t, os.WriteFile(path, []byte("<Envelope/>"), 0644))
+	manifest := "SHA1 (packer.ovf) = " + digest("<Envelope/>") + "\nSHA1 (packer-disk001.vmdk) = 0123abcd\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "packer.mf"), []byte(manifest), 0644))
+
+	err := UpdateDescriptor(path, func(descriptor []byte) ([]byte, error) {
+		return []byte("<Envelope></Envelope>"), nil
+	})
+	assert.NoError(t, err)
+
+	descriptor, err := ReadDescriptor(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "<Envelope></Envelope>", string(descriptor))
+	updated, err := os.ReadFile(filepath.Join(dir, "packer.mf"))
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Replace(manifest, digest("<Envelope/>"), digest("<Envelope></Envelope>"), 1), string(updated))
+}
+
+func TestUpdateDescriptor_ova(t *testing.T) {
+	dir := t.TempDir()
+	digest := func(content string) string {
+		sum := sha1.Sum([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+	disk := strings.Repeat("disk", 1000)
+	descriptor := `<Envelope><References><File ovf:href="packer-disk001.vmdk"/></References></Envelope>`
+	manifest := "SHA1 (packer.ovf) = " + digest(descriptor) + "\nSHA1 (packer-disk001.vmdk) = " + digest(disk) + "\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "packer.ovf"), []byte(descriptor), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "packer.mf"), []byte(manifest), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "packer-disk001.vmdk"), []byte(disk), 0644))
+	path := filepath.Join(dir, "packer.ova")
+	_, err := PackOVA(filepath.Join(dir, "packer.ovf"), path)
+	assert.NoError(t, err)
+
+	updated := strings.Replace(descriptor, "<Envelope>", `<Envelope xml:lang="en-US">`, 1)
+	err = UpdateDescriptor(path, func(descriptor []byte) ([]byte, error) {
+		return []byte(updated), nil
+	})
+	assert.NoError(t, err)
+
+	// The files keep their order, the manifest matches the new descriptor
+	var names []string
+	files := make(map[string]string)
+	err = RewriteOVA(path, func(hdr *tar.Header, content []byte, _ map[string][]byte) ([]OVAFile, error) {
+		names = append(names, hdr.Name)
+		files[hdr.Name] = string(content)
+		return []OVAFile{{Header: hdr, Content: content}}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"packer.ovf", "packer.mf"}, names)
+	assert.Equal(t, updated, files["packer.ovf"])
+	assert.Equal(t, strings.Replace(manifest, digest(descriptor), digest(updated), 1), files["packer.mf"])
+}
+
+func TestUpdateManifest_unsupported(t *testing.T) {
+	_, err := UpdateManifest([]byte("MD5 (packer.ovf) = 0123abcd\n"), "packer.ovf", nil)
+	assert.EqualError(t, err, "unsupported manifest digest MD5")
+}
+
+func TestPackOVA(t *testing.T) {
+	dir := t.TempDir()
+	ovfPath := filepath.Join(dir, "packer.ovf")
+	descriptor := `<Envelope><References><File ovf:href="packer-disk001.vmdk"/></References></Envelope>`
+	assert.NoError(t, os.WriteFile(ovfPath, []byte(descriptor), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "packer.mf"), []byte("manifest"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "packer-disk001.vmdk"), []byte("disk"), 0644))
+
+	ovaPath := filepath.Join(dir, "packer.ova")
+	files, err := PackOVA(ovfPath, ovaPath)
+	assert.NoError(t, err)
+	expected := []string{ovfPath, filepath.Join(dir, "packer.mf"), filepath.Join(dir, "packer-disk001.vmdk")}
+	assert.Equal(t, expected, files)
+
+	// The descriptor comes first
+	read, err := ReadDescriptor(ovaPath)
+	assert.NoError(t, err)
+	assert.Equal(t, descriptor, string(read))
+
+	var names []string
+	err = RewriteOVA(ovaPath, func(hdr *tar.Header, content []byte, files map[string][]byte) ([]OVAFile, error) {
+		names = append(names, hdr.Name)
+		return []OVAFile{{Header: hdr, Content: content}}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"packer.ovf", "packer.mf"}, names)
+}
@@ -0,0 +1,343 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+// Package ovfmodel reads, edits and writes the OVF descriptors of the
+// appliances exported by VirtualBox.
+//
+// The descriptor is kept as a tree of the XML nodes it is made of, so that
+// writing it back only changes what was edited: namespace prefixes, comments
+// and the elements the model knows nothing about are kept as they were.
+package ovfmodel
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The namespaces of the elements the model edits.
+const (
+	NamespaceOVF  = "http://schemas.dmtf.org/ovf/envelope/1"
+	NamespaceOVF2 = "http://schemas.dmtf.org/ovf/envelope/2"
+	NamespaceVBox = "http://www.virtualbox.org/ovf/machine"
+)
+
+// Node is a node of an OVF descriptor: an *Element, a CharData, a Comment,
+// a ProcInst or a Directive.
+type Node interface{}
+
+// CharData is the text between elements.
+type CharData string
+
+// Comment is an XML comment.
+type Comment string
+
+// Directive is an XML directive, such as a DOCTYPE.
+type Directive string
+
+// ProcInst is an XML processing instruction, such as the XML declaration.
+type ProcInst struct {
+	Target string
+	Inst   string
+}
+
+// Element is an XML element. Its name and the names of its attributes keep
+// the prefix they are written with in Space.
+type Element struct {
+	Name     xml.Name
+	Attr     []xml.Attr
+	Children []Node
+
+	// The namespace URI of the element, resolved from its prefix.
+	Namespace string
+}
+
+// Descriptor is an OVF descriptor.
+type Descriptor struct {
+	// The nodes around the root element: the XML declaration, comments and
+	// white space.
+	Prolog []Node
+	Root   *Element
+	Epilog []Node
+}
+
+// Parse reads an OVF descriptor.
+func Parse(data []byte) (*Descriptor, error) {
+	d := new(Descriptor)
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	var stack []*Element
+	var scopes []map[string]string
+	add := func(n Node) {
+		switch {
+		case len(stack) > 0:
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, n)
+		case d.Root == nil:
+			d.Prolog = append(d.Prolog, n)
+		default:
+			d.Epilog = append(d.Epilog, n)
+		}
+	}
+
+	for {
+		// RawToken keeps the prefixes, Token would replace them with the
+		// namespace URIs
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid OVF descriptor: %s", err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 && d.Root != nil {
+				return nil, fmt.Errorf("invalid OVF descriptor: more than one root element")
+			}
+			scope := make(map[string]string)
+			if len(scopes) > 0 {
+				for prefix, uri := range scopes[len(scopes)-1] {
+					scope[prefix] = uri
+				}
+			}
+			for _, a := range tok.Attr {
+				if a.Name.Space == "" && a.Name.Local == "xmlns" {
+					scope[""] = a.Value
+				} else if a.Name.Space == "xmlns" {
+					scope[a.Name.Local] = a.Value
+				}
+			}
+			e := &Element{
+				Name:      tok.Name,
+				Attr:      append([]xml.Attr(nil), tok.Attr...),
+				Namespace: scope[tok.Name.Space],
+			}
+			if len(stack) == 0 {
+				d.Root = e
+			} else {
+				add(e)
+			}
+			stack = append(stack, e)
+			scopes = append(scopes, scope)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].Name != tok.Name {
+				return nil, fmt.Errorf("invalid OVF descriptor: unexpected end element %s", qualifiedName(tok.Name))
+			}
+			stack = stack[:len(stack)-1]
+			scopes = scopes[:len(scopes)-1]
+		case xml.CharData:
+			add(CharData(tok))
+		case xml.Comment:
+			add(Comment(tok))
+		case xml.ProcInst:
+			add(ProcInst{Target: tok.Target, Inst: string(tok.Inst)})
+		case xml.Directive:
+			add(Directive(tok))
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("invalid OVF descriptor: element %s is not closed", qualifiedName(stack[len(stack)-1].Name))
+	}
+	if d.Root == nil {
+		return nil, fmt.Errorf("invalid OVF descriptor: no root element")
+	}
+	if d.Root.Name.Local != "Envelope" {
+		return nil, fmt.Errorf("invalid OVF descriptor: the root element is %s, not Envelope", qualifiedName(d.Root.Name))
+	}
+	return d, nil
+}
+
+// Bytes writes the descriptor back.
+func (d *Descriptor) Bytes() []byte {
+	var b bytes.Buffer
+	for _, n := range d.Prolog {
+		writeNode(&b, n)
+	}
+	writeNode(&b, d.Root)
+	for _, n := range d.Epilog {
+		writeNode(&b, n)
+	}
+	return b.Bytes()
+}
+
+func writeNode(b *bytes.Buffer, n Node) {
+	switch n := n.(type) {
+	case *Element:
+		b.WriteString("<" + qualifiedName(n.Name))
+		for _, a := range n.Attr {
+			fmt.Fprintf(b, ` %s="%s"`, qualifiedName(a.Name), attrEscaper.Replace(a.Value))
+		}
+		if len(n.Children) == 0 {
+			b.WriteString("/>")
+			return
+		}
+		b.WriteString(">")
+		for _, c := range n.Children {
+			writeNode(b, c)
+		}
+		b.WriteString("</" + qualifiedName(n.Name) + ">")
+	case CharData:
+		b.WriteString(textEscaper.Replace(string(n)))
+	case Comment:
+		b.WriteString("<!--" + string(n) + "-->")
+	case ProcInst:
+		b.WriteString("<?" + n.Target)
+		if n.Inst != "" {
+			b.WriteString(" " + n.Inst)
+		}
+		b.WriteString("?>")
+	case Directive:
+		b.WriteString("<!" + string(n) + ">")
+	}
+}
+
+// encoding/xml escapes new lines too, which would change the layout of the
+// descriptor.
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;",
+		"\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;")
+)
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+// Elements returns the child elements of e with the local name local, or all
+// of them if local is empty.
+func (e *Element) Elements(local string) []*Element {
+	var elements []*Element
+	for _, c := range e.Children {
+		if c, ok := c.(*Element); ok && (local == "" || c.Name.Local == local) {
+			elements = append(elements, c)
+		}
+	}
+	return elements
+}
+
+// Element returns the first child element of e with the local name local,
+// or nil.
+func (e *Element) Element(local string) *Element {
+	if elements := e.Elements(local); len(elements) > 0 {
+		return elements[0]
+	}
+	return nil
+}
+
+// Text returns the text of e.
+func (e *Element) Text() string {
+	var b strings.Builder
+	for _, c := range e.Children {
+		if c, ok := c.(CharData); ok {
+			b.WriteString(string(c))
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// SetText replaces the content of e with text.
+func (e *Element) SetText(text string) {
+	e.Children = []Node{CharData(text)}
+}
+
+// AttrValue returns the value of the attribute of e with the local name
+// local, whatever its prefix.
+func (e *Element) AttrValue(local string) (string, bool) {
+	for _, a := range e.Attr {
+		if a.Name.Local == local && a.Name.Space != "xmlns" {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetAttr sets the attribute of e with the local name of name, or adds name
+// if e has no such attribute.
+func (e *Element) SetAttr(name xml.Name, value string) {
+	for i, a := range e.Attr {
+		if a.Name.Local == name.Local && a.Name.Space != "xmlns" {
+			e.Attr[i].Value = value
+			return
+		}
+	}
+	e.Attr = append(e.Attr, xml.Attr{Name: name, Value: value})
+}
+
+// RemoveElements removes the child elements of e for which remove returns
+// true, along with the white space indenting them, and returns how many were
+// removed.
+func (e *Element) RemoveElements(remove func(*Element) bool) int {
+	var children []Node
+	removed := 0
+	for _, c := range e.Children {
+		if c, ok := c.(*Element); ok && remove(c) {
+			// Drop the indentation of the removed element
+			if n := len(children); n > 0 {
+				if text, ok := children[n-1].(CharData); ok && strings.TrimSpace(string(text)) == "" {
+					children = children[:n-1]
+				}
+			}
+			removed++
+			continue
+		}
+		children = append(children, c)
+	}
+	e.Children = children
+	return removed
+}
+
+// InsertElement inserts child into e after the element after, or at the end
+// if after is nil, with the indentation of the other children.
+func (e *Element) InsertElement(child, after *Element) {
+	indent := e.indentation()
+	i := len(e.Children)
+	for j, c := range e.Children {
+		if c == Node(after) {
+			i = j + 1
+		}
+	}
+	if after == nil {
+		// Before the indentation of the end element
+		if n := len(e.Children); n > 0 {
+			if text, ok := e.Children[n-1].(CharData); ok && strings.TrimSpace(string(text)) == "" {
+				i = n - 1
+			}
+		}
+	}
+
+	children := append([]Node{}, e.Children[:i]...)
+	children = append(children, indent, child)
+	e.Children = append(children, e.Children[i:]...)
+}
+
+// indentation returns the white space before the first child element of e.
+func (e *Element) indentation() CharData {
+	for _, c := range e.Children {
+		if text, ok := c.(CharData); ok && strings.TrimSpace(string(text)) == "" {
+			return text
+		}
+	}
+	return CharData("\n")
+}
+
+// Walk calls fn on e and all the elements below it, depth first, until fn
+// returns false.
+func (e *Element) Walk(fn func(*Element) bool) bool {
+	if !fn(e) {
+		return false
+	}
+	for _, c := range e.Elements("") {
+		if !c.Walk(fn) {
+			return false
+		}
+	}
+	return true
+}
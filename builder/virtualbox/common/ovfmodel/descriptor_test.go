@@ -0,0 +1,123 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfmodel
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testDescriptor(t *testing.T) (*Descriptor, string) {
+	data, err := os.ReadFile("testdata/virtualbox.ovf")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	d, err := Parse(data)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return d, string(data)
+}
+
+func TestParse_roundTrip(t *testing.T) {
+	d, data := testDescriptor(t)
+	assert.Equal(t, data, string(d.Bytes()))
+	assert.Equal(t, []string{"packer-disk001.vmdk"}, d.References())
+
+	systems := d.VirtualSystems()
+	assert.Len(t, systems, 1)
+	machine := systems[0].Element("Machine")
+	assert.Equal(t, NamespaceVBox, machine.Namespace)
+	assert.Equal(t, NamespaceOVF, machine.Element("Info").Namespace)
+}
+
+func TestParse_invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"empty":        "",
+		"not closed":   "<Envelope><VirtualSystem></Envelope>",
+		"two roots":    "<Envelope/><Envelope/>",
+		"not envelope": "<Disk/>",
+	} {
+		_, err := Parse([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestDescriptor_RemoveVirtualBoxMachine(t *testing.T) {
+	d, data := testDescriptor(t)
+	assert.Equal(t, 1, d.RemoveVirtualBoxMachine())
+
+	start := strings.Index(data, "\n    <vbox:Machine")
+	end := strings.Index(data, "</vbox:Machine>") + len("</vbox:Machine>")
+	assert.Equal(t, data[:start]+data[end:], string(d.Bytes()))
+
+	// The OS type of the OperatingSystemSection is kept
+	assert.Contains(t, string(d.Bytes()), "<vbox:OSType ovf:required=\"false\">Ubuntu_64</vbox:OSType>")
+}
+
+func TestDescriptor_SetVirtualSystemType(t *testing.T) {
+	d, data := testDescriptor(t)
+	assert.NoError(t, d.SetVirtualSystemType("vmx-13"))
+	assert.Equal(t, strings.Replace(data, "virtualbox-2.2", "vmx-13", 1), string(d.Bytes()))
+}
+
+func TestDescriptor_RemoveItems(t *testing.T) {
+	d, data := testDescriptor(t)
+	removed, err := d.RemoveItems(ResourceTypeSerialPort)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NotContains(t, string(d.Bytes()), "serial0")
+	assert.Equal(t, len(data)-len(d.Bytes()), strings.Index(data, "    </VirtualHardwareSection>")-
+		strings.LastIndex(data, "\n      <Item>")-1)
+
+	// The disk controller holds the disk
+	_, err = d.RemoveItems(20)
+	assert.EqualError(t, err, "cannot remove item 2 of VirtualSystem packer, other items are attached to it")
+	assert.Contains(t, string(d.Bytes()), "sataController0")
+}
+
+func TestDescriptor_SetOperatingSystem(t *testing.T) {
+	d, data := testDescriptor(t)
+	assert.NoError(t, d.SetOperatingSystem(96, "Debian_64"))
+	expected := strings.Replace(data, `<OperatingSystemSection ovf:id="94">`, `<OperatingSystemSection ovf:id="96">`, 1)
+	expected = strings.Replace(expected, "<Description>Ubuntu_64</Description>", "<Description>Debian_64</Description>", 1)
+	assert.Equal(t, expected, string(d.Bytes()))
+
+	// Without a description, one is added after the Info
+	data = strings.Replace(data, "      <Description>Ubuntu_64</Description>\n", "", 1)
+	d, err := Parse([]byte(data))
+	assert.NoError(t, err)
+	assert.NoError(t, d.SetOperatingSystem(94, "Ubuntu_64"))
+	assert.Contains(t, string(d.Bytes()), "operating system</Info>\n      <Description>Ubuntu_64</Description>\n      <vbox:OSType")
+}
+
+func TestDescriptor_Disks(t *testing.T) {
+	d, _ := testDescriptor(t)
+	disks, err := d.Disks()
+	assert.NoError(t, err)
+	assert.Equal(t, []Disk{{
+		ID:       "vmdisk1",
+		File:     "packer-disk001.vmdk",
+		Format:   "http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized",
+		Capacity: 42949672960,
+	}}, disks)
+
+	d, err = Parse([]byte(`<Envelope><DiskSection>` +
+		`<Disk ovf:capacity="40" ovf:capacityAllocationUnits="byte * 2^30" ovf:diskId="vmdisk1"/>` +
+		`</DiskSection></Envelope>`))
+	assert.NoError(t, err)
+	disks, err = d.Disks()
+	assert.NoError(t, err)
+	assert.Equal(t, []Disk{{ID: "vmdisk1", Capacity: 40 << 30}}, disks)
+
+	d, err = Parse([]byte(`<Envelope><DiskSection>` +
+		`<Disk ovf:capacity="40" ovf:capacityAllocationUnits="KiB" ovf:diskId="vmdisk1"/>` +
+		`</DiskSection></Envelope>`))
+	assert.NoError(t, err)
+	_, err = d.Disks()
+	assert.EqualError(t, err, `disk vmdisk1: unsupported capacity units "KiB"`)
+}
@@ -0,0 +1,269 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfmodel
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The CIM resource types of the virtual hardware items VirtualBox exports.
+const (
+	ResourceTypeFloppyDrive   = 14
+	ResourceTypeCDDrive       = 15
+	ResourceTypeSerialPort    = 21
+	ResourceTypeParallelPort  = 22
+	ResourceTypeUSBController = 23
+	ResourceTypeSoundCard     = 35
+)
+
+// VirtualSystems returns the virtual systems of the descriptor, including
+// those of a VirtualSystemCollection.
+func (d *Descriptor) VirtualSystems() []*Element {
+	var systems []*Element
+	d.Root.Walk(func(e *Element) bool {
+		if e.Name.Local == "VirtualSystem" {
+			systems = append(systems, e)
+		}
+		return true
+	})
+	return systems
+}
+
+// References returns the files referenced by the descriptor, such as the
+// disk images.
+func (d *Descriptor) References() []string {
+	var files []string
+	if references := d.Root.Element("References"); references != nil {
+		for _, f := range references.Elements("File") {
+			if href, ok := f.AttrValue("href"); ok {
+				files = append(files, href)
+			}
+		}
+	}
+	return files
+}
+
+// RemoveVirtualBoxMachine removes the vbox:Machine sections, which describe
+// the VM in the VirtualBox settings format, and returns how many were
+// removed. VirtualBox reads them instead of the virtual hardware sections on
+// import, other consumers ignore them.
+func (d *Descriptor) RemoveVirtualBoxMachine() int {
+	removed := 0
+	d.Root.Walk(func(e *Element) bool {
+		removed += e.RemoveElements(func(c *Element) bool {
+			return c.Namespace == NamespaceVBox && c.Name.Local == "Machine"
+		})
+		return true
+	})
+	return removed
+}
+
+// SetVirtualSystemType sets the hardware family of the virtual systems,
+// `virtualbox-2.2` in VirtualBox exports, such as `vmx-13`.
+func (d *Descriptor) SetVirtualSystemType(systemType string) error {
+	systems := d.VirtualSystems()
+	if len(systems) == 0 {
+		return fmt.Errorf("no VirtualSystem in the OVF descriptor")
+	}
+	for _, vs := range systems {
+		hardware := vs.Element("VirtualHardwareSection")
+		if hardware == nil {
+			return fmt.Errorf("no VirtualHardwareSection in VirtualSystem %s", systemID(vs))
+		}
+		system := hardware.Element("System")
+		if system == nil || system.Element("VirtualSystemType") == nil {
+			return fmt.Errorf("no VirtualSystemType in VirtualSystem %s", systemID(vs))
+		}
+		system.Element("VirtualSystemType").SetText(systemType)
+	}
+	return nil
+}
+
+// RemoveItems removes the virtual hardware items of the given CIM resource
+// type, such as ResourceTypeSerialPort, and returns how many were removed.
+// Items other items are attached to, such as disk controllers, can't be
+// removed.
+func (d *Descriptor) RemoveItems(resourceType int) (int, error) {
+	removed := 0
+	for _, vs := range d.VirtualSystems() {
+		hardware := vs.Element("VirtualHardwareSection")
+		if hardware == nil {
+			continue
+		}
+
+		parents := make(map[string]bool)
+		for _, item := range hardwareItems(hardware) {
+			if parent := item.Element("Parent"); parent != nil {
+				parents[parent.Text()] = true
+			}
+		}
+
+		var err error
+		removed += hardware.RemoveElements(func(item *Element) bool {
+			if !isHardwareItem(item) || itemResourceType(item) != resourceType {
+				return false
+			}
+			if id := item.Element("InstanceID"); id != nil && parents[id.Text()] {
+				err = fmt.Errorf("cannot remove item %s of VirtualSystem %s, other items are attached to it",
+					id.Text(), systemID(vs))
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// SetOperatingSystem sets the CIM operating system ID of the virtual systems
+// and, if it isn't empty, the description of the operating system.
+func (d *Descriptor) SetOperatingSystem(id int, description string) error {
+	systems := d.VirtualSystems()
+	if len(systems) == 0 {
+		return fmt.Errorf("no VirtualSystem in the OVF descriptor")
+	}
+	for _, vs := range systems {
+		section := vs.Element("OperatingSystemSection")
+		if section == nil {
+			return fmt.Errorf("no OperatingSystemSection in VirtualSystem %s", systemID(vs))
+		}
+		section.SetAttr(xml.Name{Space: d.ovfPrefix(), Local: "id"}, strconv.Itoa(id))
+		if description == "" {
+			continue
+		}
+		if e := section.Element("Description"); e != nil {
+			e.SetText(description)
+			continue
+		}
+		e := &Element{Name: xml.Name{Space: section.Name.Space, Local: "Description"}, Namespace: section.Namespace}
+		e.SetText(description)
+		section.InsertElement(e, section.Element("Info"))
+	}
+	return nil
+}
+
+// ovfPrefix returns the prefix of the OVF namespace in the descriptor, for
+// the attributes of the OVF elements. The ovf prefix is declared on the
+// Envelope if the descriptor only uses the OVF namespace by default.
+func (d *Descriptor) ovfPrefix() string {
+	for _, a := range d.Root.Attr {
+		if a.Name.Space == "xmlns" && (a.Value == NamespaceOVF || a.Value == NamespaceOVF2) {
+			return a.Name.Local
+		}
+	}
+	namespace := d.Root.Namespace
+	if namespace != NamespaceOVF2 {
+		namespace = NamespaceOVF
+	}
+	d.Root.Attr = append(d.Root.Attr, xml.Attr{Name: xml.Name{Space: "xmlns", Local: "ovf"}, Value: namespace})
+	return "ovf"
+}
+
+func hardwareItems(hardware *Element) []*Element {
+	var items []*Element
+	for _, e := range hardware.Elements("") {
+		if isHardwareItem(e) {
+			items = append(items, e)
+		}
+	}
+	return items
+}
+
+func isHardwareItem(e *Element) bool {
+	switch e.Name.Local {
+	case "Item", "StorageItem", "EthernetPortItem":
+		return true
+	}
+	return false
+}
+
+func itemResourceType(item *Element) int {
+	if e := item.Element("ResourceType"); e != nil {
+		if t, err := strconv.Atoi(e.Text()); err == nil {
+			return t
+		}
+	}
+	return -1
+}
+
+func systemID(vs *Element) string {
+	if id, ok := vs.AttrValue("id"); ok {
+		return id
+	}
+	return "without ID"
+}
+
+// Disk is a virtual disk of the DiskSection of a descriptor.
+type Disk struct {
+	ID string
+	// The name of the disk image, from References, if any.
+	File string
+	// The URI of the format of the disk image, such as
+	// http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized
+	Format string
+	// The virtual size of the disk, in bytes.
+	Capacity int64
+}
+
+// Disks returns the virtual disks of the descriptor.
+func (d *Descriptor) Disks() ([]Disk, error) {
+	files := make(map[string]string)
+	if references := d.Root.Element("References"); references != nil {
+		for _, f := range references.Elements("File") {
+			id, _ := f.AttrValue("id")
+			files[id], _ = f.AttrValue("href")
+		}
+	}
+
+	section := d.Root.Element("DiskSection")
+	if section == nil {
+		return nil, nil
+	}
+	var disks []Disk
+	for _, e := range section.Elements("Disk") {
+		disk := Disk{}
+		disk.ID, _ = e.AttrValue("diskId")
+		disk.Format, _ = e.AttrValue("format")
+		if ref, ok := e.AttrValue("fileRef"); ok {
+			disk.File = files[ref]
+		}
+
+		capacity, _ := e.AttrValue("capacity")
+		units, _ := e.AttrValue("capacityAllocationUnits")
+		size, err := capacityBytes(capacity, units)
+		if err != nil {
+			return nil, fmt.Errorf("disk %s: %s", disk.ID, err)
+		}
+		disk.Capacity = size
+		disks = append(disks, disk)
+	}
+	return disks, nil
+}
+
+// capacityBytes returns the size in bytes of a disk capacity given in
+// programmatic units, such as "byte * 2^30".
+func capacityBytes(capacity, units string) (int64, error) {
+	size, err := strconv.ParseInt(capacity, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid capacity %q", capacity)
+	}
+	units = strings.ReplaceAll(units, " ", "")
+	if units == "" || units == "byte" {
+		return size, nil
+	}
+	exponent, ok := strings.CutPrefix(units, "byte*2^")
+	if !ok {
+		return 0, fmt.Errorf("unsupported capacity units %q", units)
+	}
+	shift, err := strconv.Atoi(exponent)
+	if err != nil || shift < 0 || shift > 62 {
+		return 0, fmt.Errorf("unsupported capacity units %q", units)
+	}
+	return size << shift, nil
+}
@@ -0,0 +1,92 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfmodel
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// Property is a property of the ProductSection of a virtual system, read by
+// vApp style consumers of the appliance.
+type Property struct {
+	Key              string
+	Type             string
+	Value            string
+	UserConfigurable bool
+	Label            string
+	Description      string
+}
+
+// AddProductProperties adds properties to the ProductSection of every
+// virtual system of the descriptor. A virtual system without one gets a
+// ProductSection after its Info and Name, where VirtualBox writes it.
+func (d *Descriptor) AddProductProperties(properties []Property) error {
+	systems := d.VirtualSystems()
+	if len(systems) == 0 {
+		return fmt.Errorf("no VirtualSystem in the OVF descriptor")
+	}
+	prefix := d.ovfPrefix()
+	for _, vs := range systems {
+		section := vs.Element("ProductSection")
+		if section == nil {
+			section = newChildElement(vs, "ProductSection")
+			info := newChildElement(section, "Info")
+			info.SetText("Meta-information about the installed software")
+			section.setIndentedChildren(vs.indentation(), info)
+
+			after := vs.Element("Name")
+			if after == nil {
+				after = vs.Element("Info")
+			}
+			vs.InsertElement(section, after)
+		}
+
+		for _, p := range properties {
+			property := newChildElement(section, "Property")
+			for _, a := range []struct{ name, value string }{
+				{"key", p.Key},
+				{"type", p.Type},
+				{"value", p.Value},
+				{"userConfigurable", strconv.FormatBool(p.UserConfigurable)},
+			} {
+				property.SetAttr(xml.Name{Space: prefix, Local: a.name}, a.value)
+			}
+
+			var children []*Element
+			for _, text := range []struct{ name, value string }{
+				{"Label", p.Label},
+				{"Description", p.Description},
+			} {
+				if text.value != "" {
+					e := newChildElement(property, text.name)
+					e.SetText(text.value)
+					children = append(children, e)
+				}
+			}
+			if len(children) > 0 {
+				property.setIndentedChildren(section.indentation(), children...)
+			}
+			section.InsertElement(property, nil)
+		}
+	}
+	return nil
+}
+
+// newChildElement returns a new element for parent, in the namespace and
+// with the prefix of parent.
+func newChildElement(parent *Element, local string) *Element {
+	return &Element{Name: xml.Name{Space: parent.Name.Space, Local: local}, Namespace: parent.Namespace}
+}
+
+// setIndentedChildren sets the children of e, an element indented with
+// indent, one more level in.
+func (e *Element) setIndentedChildren(indent CharData, children ...*Element) {
+	e.Children = nil
+	for _, c := range children {
+		e.Children = append(e.Children, indent+"  ", c)
+	}
+	e.Children = append(e.Children, indent)
+}
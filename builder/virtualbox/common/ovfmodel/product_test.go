@@ -0,0 +1,81 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package ovfmodel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testProperties = []Property{
+	{Key: "guestinfo.hostname", Type: "string", Value: "a<b", UserConfigurable: true, Label: "Host name"},
+	{Key: "guestinfo.debug", Type: "boolean", Value: "false"},
+}
+
+func TestDescriptor_AddProductProperties(t *testing.T) {
+	d, data := testDescriptor(t)
+	assert.NoError(t, d.AddProductProperties(testProperties))
+
+	// The section goes after the Info of the virtual system
+	section := `
+    <ProductSection>
+      <Info>Meta-information about the installed software</Info>
+      <Property ovf:key="guestinfo.hostname" ovf:type="string" ovf:value="a&lt;b" ovf:userConfigurable="true">
+        <Label>Host name</Label>
+      </Property>
+      <Property ovf:key="guestinfo.debug" ovf:type="boolean" ovf:value="false" ovf:userConfigurable="false"/>
+    </ProductSection>`
+	info := "<Info>A virtual machine</Info>"
+	assert.Equal(t, strings.Replace(data, info, info+section, 1), string(d.Bytes()))
+
+	// The properties are added to an existing section
+	d, err := Parse(d.Bytes())
+	assert.NoError(t, err)
+	assert.NoError(t, d.AddProductProperties([]Property{{Key: "guestinfo.domain", Type: "string"}}))
+	assert.Contains(t, string(d.Bytes()), `ovf:userConfigurable="false"/>
+      <Property ovf:key="guestinfo.domain" ovf:type="string" ovf:value="" ovf:userConfigurable="false"/>
+    </ProductSection>`)
+}
+
+func TestDescriptor_AddProductProperties_prefixes(t *testing.T) {
+	// Every virtual system of a collection gets the properties, with the
+	// prefixes of the descriptor
+	data := `<o:Envelope xmlns:o="http://schemas.dmtf.org/ovf/envelope/2">
+  <o:VirtualSystemCollection o:id="vapp">
+    <o:Info>A collection</o:Info>
+    <o:VirtualSystem o:id="web">
+      <o:Info>A virtual machine</o:Info>
+      <o:Name>web</o:Name>
+    </o:VirtualSystem>
+    <o:VirtualSystem o:id="db">
+      <o:Info>A virtual machine</o:Info>
+    </o:VirtualSystem>
+  </o:VirtualSystemCollection>
+</o:Envelope>`
+	d, err := Parse([]byte(data))
+	assert.NoError(t, err)
+	assert.NoError(t, d.AddProductProperties(testProperties[1:]))
+	section := `
+      <o:ProductSection>
+        <o:Info>Meta-information about the installed software</o:Info>
+        <o:Property o:key="guestinfo.debug" o:type="boolean" o:value="false" o:userConfigurable="false"/>
+      </o:ProductSection>`
+	expected := strings.Replace(data, "<o:Name>web</o:Name>", "<o:Name>web</o:Name>"+section, 1)
+	expected = strings.Replace(expected, "<o:Info>A virtual machine</o:Info>\n    </o:VirtualSystem>\n  </o:VirtualSystemCollection>",
+		"<o:Info>A virtual machine</o:Info>"+section+"\n    </o:VirtualSystem>\n  </o:VirtualSystemCollection>", 1)
+	assert.Equal(t, expected, string(d.Bytes()))
+
+	// Without a prefix for the OVF namespace, the ovf prefix is declared
+	d, err = Parse([]byte(`<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1"><VirtualSystem><Info/></VirtualSystem></Envelope>`))
+	assert.NoError(t, err)
+	assert.NoError(t, d.AddProductProperties(testProperties[1:]))
+	assert.Contains(t, string(d.Bytes()), `<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1">`)
+	assert.Contains(t, string(d.Bytes()), `<Property ovf:key="guestinfo.debug"`)
+
+	d, err = Parse([]byte("<Envelope/>"))
+	assert.NoError(t, err)
+	assert.EqualError(t, d.AddProductProperties(testProperties), "no VirtualSystem in the OVF descriptor")
+}
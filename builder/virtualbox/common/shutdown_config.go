@@ -6,6 +6,7 @@
 package common
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
@@ -40,6 +41,18 @@ type ShutdownConfig struct {
 	// If it's set to true, it will shutdown the VM via power button. It could be a good option
 	// when keeping the machine state is necessary after shutting it down.
 	ACPIShutdown bool `mapstructure:"acpi_shutdown" required:"false"`
+	// If set to `true`, Packer saves the state of the running virtual machine
+	// with `VBoxManage controlvm savestate` instead of shutting it down, so
+	// that it resumes in seconds where the build left it. The result is a
+	// registered virtual machine in saved state, or with the `vm` builder a
+	// `target_snapshot` including the saved state, so this requires
+	// `keep_registered` or `target_snapshot` and `skip_export`. As the
+	// virtual machine cannot be reconfigured in saved state, the boot and
+	// guest additions ISOs and the floppy disk are ejected but their drives
+	// are left in place, and `vboxmanage_post` commands changing the
+	// hardware will fail. Cannot be used with `shutdown_command`,
+	// `acpi_shutdown` or `disable_shutdown`. Defaults to `false`.
+	SaveState bool `mapstructure:"save_state" required:"false"`
 }
 
 func (c *ShutdownConfig) Prepare(ctx *interpolate.Context) []error {
@@ -51,5 +64,10 @@ func (c *ShutdownConfig) Prepare(ctx *interpolate.Context) []error {
 		c.PostShutdownDelay = 2 * time.Second
 	}
 
-	return nil
+	var errs []error
+	if c.SaveState && (c.ShutdownCommand != "" || c.ACPIShutdown || c.DisableShutdown) {
+		errs = append(errs, fmt.Errorf("save_state cannot be used with shutdown_command, acpi_shutdown or disable_shutdown"))
+	}
+
+	return errs
 }
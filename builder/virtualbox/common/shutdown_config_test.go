@@ -91,3 +91,18 @@ func TestShutdownConfigPrepare_DisableShutdown(t *testing.T) {
 		t.Fatalf("bad: %t", c.DisableShutdown)
 	}
 }
+
+func TestShutdownConfigPrepare_SaveState(t *testing.T) {
+	c := testShutdownConfig()
+	c.SaveState = true
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	c = testShutdownConfig()
+	c.SaveState = true
+	c.ShutdownCommand = "shutdown -P now"
+	if errs := c.Prepare(interpolate.NewContext()); len(errs) != 1 {
+		t.Fatalf("should error with shutdown_command: %#v", errs)
+	}
+}
@@ -5,79 +5,145 @@ package common
 
 import (
 	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"log"
+	"os"
 	"regexp"
 	"strings"
-
-	"github.com/golang-collections/collections/stack"
+	"time"
 )
 
 // VBoxSnapshot stores the hierarchy of snapshots for a VM instance
 type VBoxSnapshot struct {
-	Name      string
-	UUID      string
-	IsCurrent bool
-	Parent    *VBoxSnapshot // nil if topmost (root) snapshot
-	Children  []*VBoxSnapshot
+	Name        string
+	UUID        string
+	IsCurrent   bool
+	Description string
+	TimeStamp   time.Time     // zero unless loaded with LoadSnapshotTimeStamps
+	Parent      *VBoxSnapshot // nil if topmost (root) snapshot
+	Children    []*VBoxSnapshot
 }
 
+var snapshotDataRe = regexp.MustCompile(`^(Current)?Snapshot(Name|UUID|Description|Node)((?:-[0-9]+)*)="(.*)"$`)
+
 // ParseSnapshotData parses the machinereadable representation of a virtualbox snapshot tree
 func ParseSnapshotData(snapshotData string) (*VBoxSnapshot, error) {
 	scanner := bufio.NewScanner(strings.NewReader(snapshotData))
-	SnapshotNamePartsRe := regexp.MustCompile("Snapshot(?P<Type>Name|UUID)(?P<Path>(-[1-9]+)*)=\"(?P<Value>[^\"]*)\"")
-	var currentIndicator string
-	parentStack := stack.New()
-	var node *VBoxSnapshot
+	nodes := make(map[string]*VBoxSnapshot)
 	var rootNode *VBoxSnapshot
+	var currentUUID, currentPath string
 
-	for scanner.Scan() {
-		txt := scanner.Text()
-		if !strings.Contains(txt, "=") {
-			log.Printf("Invalid key,value pair [%s]", txt)
+	for line := 1; scanner.Scan(); line++ {
+		txt := strings.TrimSpace(scanner.Text())
+		if txt == "" {
 			continue
 		}
-		if strings.HasPrefix(txt, "Current") {
-			node.IsCurrent = true
+		if !strings.Contains(txt, "=") {
+			return nil, fmt.Errorf("invalid snapshot data on line %d: %q", line, txt)
+		}
+		if !strings.HasPrefix(txt, "Snapshot") && !strings.HasPrefix(txt, "CurrentSnapshot") {
+			log.Printf("Ignoring unknown snapshot data [%s]", txt)
 			continue
 		}
-		matches := SnapshotNamePartsRe.FindStringSubmatch(txt)
-		if len(matches) < 2 {
+		matches := snapshotDataRe.FindStringSubmatch(txt)
+		if matches == nil {
+			return nil, fmt.Errorf("invalid snapshot data on line %d: %q", line, txt)
+		}
+		current, kind, path := matches[1] != "", matches[2], matches[3]
+		value := unquoteMachineReadable(matches[4])
+
+		if current {
+			switch kind {
+			case "UUID":
+				currentUUID = value
+			case "Node":
+				if !strings.HasPrefix(value, "SnapshotName") {
+					return nil, fmt.Errorf("invalid current snapshot node on line %d: %q", line, value)
+				}
+				currentPath = strings.TrimPrefix(value, "SnapshotName")
+			}
 			continue
 		}
 
-		switch matches[1] {
-		case "UUID":
-			node.UUID = matches[4]
+		switch kind {
 		case "Name":
-			if nil == rootNode {
-				node = new(VBoxSnapshot)
+			if _, ok := nodes[path]; ok {
+				return nil, fmt.Errorf("snapshot %q is defined twice on line %d", "SnapshotName"+path, line)
+			}
+			node := &VBoxSnapshot{Name: value}
+			if path == "" {
 				rootNode = node
-				currentIndicator = matches[2]
 			} else {
-				pathLenCur := strings.Count(currentIndicator, "-")
-				pathLen := strings.Count(matches[2], "-")
-				if pathLen > pathLenCur {
-					currentIndicator = matches[2]
-					parentStack.Push(node)
-				} else if pathLen < pathLenCur {
-					currentIndicator = matches[2]
-					for i := 0; i < pathLenCur-pathLen; i++ {
-						parentStack.Pop()
-					}
-				}
-				node = new(VBoxSnapshot)
-				parent := parentStack.Peek().(*VBoxSnapshot)
-				if nil != parent {
-					node.Parent = parent
-					parent.Children = append(parent.Children, node)
+				parent, ok := nodes[path[:strings.LastIndex(path, "-")]]
+				if !ok {
+					return nil, fmt.Errorf("snapshot %q on line %d has no parent", "SnapshotName"+path, line)
 				}
+				node.Parent = parent
+				parent.Children = append(parent.Children, node)
+			}
+			nodes[path] = node
+		case "UUID", "Description":
+			node, ok := nodes[path]
+			if !ok {
+				return nil, fmt.Errorf("snapshot %q on line %d has no name", "Snapshot"+kind+path, line)
 			}
-			node.Name = matches[4]
+			if kind == "UUID" {
+				node.UUID = value
+			} else {
+				node.Description = value
+			}
+		default:
+			return nil, fmt.Errorf("invalid snapshot data on line %d: %q", line, txt)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for path, node := range nodes {
+		if node.UUID == "" {
+			return nil, fmt.Errorf("snapshot %q has no UUID", "SnapshotName"+path)
 		}
 	}
+
+	if currentUUID != "" || currentPath != "" {
+		var current *VBoxSnapshot
+		if currentUUID != "" && rootNode != nil {
+			current = rootNode.GetSnapshotByUUID(currentUUID)
+		} else {
+			current = nodes[currentPath]
+		}
+		if current == nil {
+			return nil, fmt.Errorf("current snapshot %q is not in the snapshot tree", currentUUID+currentPath)
+		}
+		current.IsCurrent = true
+	}
+
 	return rootNode, nil
 }
 
+// unquoteMachineReadable undoes the escaping VBoxManage applies to quoted
+// values in its machinereadable output.
+func unquoteMachineReadable(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
 // IsChildOf verifies if the current snaphot is a child of the passed as argument
 func (sn *VBoxSnapshot) IsChildOf(candidate *VBoxSnapshot) bool {
 	if nil == candidate {
@@ -204,3 +270,202 @@ func (sn *VBoxSnapshot) GetChildWithName(name string) *VBoxSnapshot {
 	}
 	return nil
 }
+
+// Path returns the names of the snapshot and its ancestors, starting at the
+// root and separated by slashes, such as `base/patched/2024-10`.
+func (sn *VBoxSnapshot) Path() string {
+	var names []string
+	for node := sn; nil != node; node = node.Parent {
+		names = append([]string{node.Name}, names...)
+	}
+	return strings.Join(names, "/")
+}
+
+// GetSnapshotsByPath finds all snapshots matching a slash separated path of
+// names starting at the root, as returned by Path. Names are compared
+// without case, and siblings may share a name, so more than one snapshot
+// can match.
+func (sn *VBoxSnapshot) GetSnapshotsByPath(path string) []*VBoxSnapshot {
+	names := strings.Split(strings.Trim(path, "/"), "/")
+	root := sn.GetRoot()
+	if !strings.EqualFold(root.Name, names[0]) {
+		return nil
+	}
+
+	result := []*VBoxSnapshot{root}
+	for _, name := range names[1:] {
+		var next []*VBoxSnapshot
+		for _, node := range result {
+			for _, child := range node.Children {
+				if strings.EqualFold(child.Name, name) {
+					next = append(next, child)
+				}
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+// SnapshotDiff lists the differences between two snapshot trees of the same
+// VM, matching snapshots by UUID.
+type SnapshotDiff struct {
+	// Added holds the snapshots only found in the new tree.
+	Added []*VBoxSnapshot
+	// Removed holds the snapshots only found in the old tree.
+	Removed []*VBoxSnapshot
+	// Changed holds the snapshots of the new tree whose name, description
+	// or parent differs from the old tree.
+	Changed []*VBoxSnapshot
+}
+
+// Empty reports whether the trees are the same.
+func (d *SnapshotDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffSnapshots compares two snapshot trees. Either tree may be nil when the
+// VM has no snapshots.
+func DiffSnapshots(oldTree, newTree *VBoxSnapshot) *SnapshotDiff {
+	diff := new(SnapshotDiff)
+
+	oldNodes := make(map[string]*VBoxSnapshot)
+	if nil != oldTree {
+		for _, node := range oldTree.GetSnapshots() {
+			oldNodes[strings.ToLower(node.UUID)] = node
+		}
+	}
+
+	seen := make(map[string]bool)
+	if nil != newTree {
+		for _, node := range newTree.GetSnapshots() {
+			uuid := strings.ToLower(node.UUID)
+			seen[uuid] = true
+			old, ok := oldNodes[uuid]
+			if !ok {
+				diff.Added = append(diff.Added, node)
+			} else if old.Name != node.Name || old.Description != node.Description || !sameSnapshot(old.Parent, node.Parent) {
+				diff.Changed = append(diff.Changed, node)
+			}
+		}
+	}
+
+	if nil != oldTree {
+		for _, node := range oldTree.GetSnapshots() {
+			if !seen[strings.ToLower(node.UUID)] {
+				diff.Removed = append(diff.Removed, node)
+			}
+		}
+	}
+
+	return diff
+}
+
+func sameSnapshot(a, b *VBoxSnapshot) bool {
+	if nil == a || nil == b {
+		return a == b
+	}
+	return strings.EqualFold(a.UUID, b.UUID)
+}
+
+type snapshotJSON struct {
+	Name        string          `json:"name"`
+	UUID        string          `json:"uuid"`
+	IsCurrent   bool            `json:"current,omitempty"`
+	Description string          `json:"description,omitempty"`
+	TimeStamp   *time.Time      `json:"timestamp,omitempty"`
+	Children    []*VBoxSnapshot `json:"children,omitempty"`
+}
+
+// MarshalJSON serializes the snapshot and its children. Parents are implied
+// by the nesting.
+func (sn *VBoxSnapshot) MarshalJSON() ([]byte, error) {
+	out := snapshotJSON{
+		Name:        sn.Name,
+		UUID:        sn.UUID,
+		IsCurrent:   sn.IsCurrent,
+		Description: sn.Description,
+		Children:    sn.Children,
+	}
+	if !sn.TimeStamp.IsZero() {
+		out.TimeStamp = &sn.TimeStamp
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads a tree written by MarshalJSON and restores the parent
+// links.
+func (sn *VBoxSnapshot) UnmarshalJSON(data []byte) error {
+	var in snapshotJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	*sn = VBoxSnapshot{
+		Name:        in.Name,
+		UUID:        in.UUID,
+		IsCurrent:   in.IsCurrent,
+		Description: in.Description,
+		Children:    in.Children,
+	}
+	if nil != in.TimeStamp {
+		sn.TimeStamp = *in.TimeStamp
+	}
+	for _, child := range sn.Children {
+		child.Parent = sn
+	}
+	return nil
+}
+
+// The parts of a VM settings (.vbox) file describing its snapshots.
+type vboxSettingsSnapshot struct {
+	UUID      string                 `xml:"uuid,attr"`
+	TimeStamp string                 `xml:"timeStamp,attr"`
+	Snapshots []vboxSettingsSnapshot `xml:"Snapshots>Snapshot"`
+}
+
+type vboxSettings struct {
+	Snapshot *vboxSettingsSnapshot `xml:"Machine>Snapshot"`
+}
+
+// ReadSnapshotTimeStamps fills in the creation time of every snapshot in the
+// tree from the VM settings file. VBoxManage does not print the time a
+// snapshot was taken, neither `showvminfo` nor `snapshot showvminfo`, so the
+// settings file is the only place it can be read from.
+func ReadSnapshotTimeStamps(settingsFile string, sn *VBoxSnapshot) error {
+	data, err := os.ReadFile(settingsFile)
+	if err != nil {
+		return err
+	}
+
+	var settings vboxSettings
+	if err := xml.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("Error parsing %s: %s", settingsFile, err)
+	}
+	if nil == settings.Snapshot || nil == sn {
+		return nil
+	}
+
+	root := sn.GetRoot()
+	var apply func(s *vboxSettingsSnapshot) error
+	apply = func(s *vboxSettingsSnapshot) error {
+		node := root.GetSnapshotByUUID(strings.Trim(s.UUID, "{}"))
+		if nil == node {
+			return fmt.Errorf("snapshot %s from %s is not in the snapshot tree", s.UUID, settingsFile)
+		}
+		if s.TimeStamp != "" {
+			t, err := time.Parse(time.RFC3339, s.TimeStamp)
+			if err != nil {
+				return fmt.Errorf("invalid time stamp for snapshot %s: %s", s.UUID, err)
+			}
+			node.TimeStamp = t
+		}
+		for i := range s.Snapshots {
+			if err := apply(&s.Snapshots[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return apply(settings.Snapshot)
+}
@@ -4,7 +4,11 @@
 package common
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -144,3 +148,118 @@ func TestSnapshot_WrongSnapshot(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, rootNode)
 }
+
+func TestSnapshot_ParseErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"no value":     `SnapshotName`,
+		"uuid first":   `SnapshotUUID="7e5b4165-91ec-4091-a74c-a5709d584530"`,
+		"current only": `CurrentSnapshotUUID="7e5b4165-91ec-4091-a74c-a5709d584530"`,
+		"orphan": `SnapshotName="Imported"
+SnapshotUUID="7e5b4165-91ec-4091-a74c-a5709d584530"
+SnapshotName-1-1="Snapshot 2"
+SnapshotUUID-1-1="8e12833b-c6b5-4cbd-b42b-09eff8ffc173"`,
+		"missing uuid": `SnapshotName="Imported"`,
+		"unquoted":     `SnapshotName=Imported`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rootNode, err := ParseSnapshotData(data)
+			assert.Error(t, err)
+			assert.Nil(t, rootNode)
+		})
+	}
+}
+
+func TestSnapshot_ParseDescription(t *testing.T) {
+	rootNode, err := ParseSnapshotData(`SnapshotName="Imported"
+SnapshotUUID="7e5b4165-91ec-4091-a74c-a5709d584530"
+SnapshotDescription="first line\nsecond \"line\""
+SnapshotName-10="Snapshot 10"
+SnapshotUUID-10="5fc461ec-da7a-40a8-a168-03134d7cdf5c"`)
+	assert.NoError(t, err)
+	assert.Equal(t, "first line\nsecond \"line\"", rootNode.Description)
+	assert.Equal(t, "Snapshot 10", rootNode.Children[0].Name)
+}
+
+func TestSnapshot_Path(t *testing.T) {
+	rootNode, err := ParseSnapshotData(getTestData())
+	assert.NoError(t, err)
+
+	node := rootNode.GetSnapshotByUUID("eb342b39-b4bd-47b0-afd8-dcd1cc5c5929")
+	assert.Equal(t, "Imported/Snapshot 1/Snapshot 2/Snapshot 3", node.Path())
+	assert.Equal(t, []*VBoxSnapshot{node}, rootNode.GetSnapshotsByPath(node.Path()))
+	assert.Equal(t, []*VBoxSnapshot{node}, rootNode.GetSnapshotsByPath("/imported/snapshot 1/snapshot 2/snapshot 3/"))
+	assert.Equal(t, []*VBoxSnapshot{rootNode}, node.GetSnapshotsByPath("Imported"))
+	assert.Empty(t, rootNode.GetSnapshotsByPath("Imported/Snapshot 2"))
+	assert.Empty(t, rootNode.GetSnapshotsByPath("Snapshot 1"))
+}
+
+func TestSnapshot_Diff(t *testing.T) {
+	oldTree, err := ParseSnapshotData(getTestData())
+	assert.NoError(t, err)
+	newTree, err := ParseSnapshotData(getTestData())
+	assert.NoError(t, err)
+	assert.True(t, DiffSnapshots(oldTree, newTree).Empty())
+
+	// Delete "Snapshot 7" and its children, rename "Snapshot 5" and add a
+	// snapshot under it
+	newTree.Children = newTree.Children[:2]
+	renamed := newTree.GetSnapshotByUUID("85646c6a-fb86-4112-b15e-cab090670778")
+	renamed.Name = "Snapshot 6"
+	added := &VBoxSnapshot{Name: "New", UUID: "d0f2a5f4-7d43-4f0e-8d8c-3c5a5b0c9a41", Parent: renamed}
+	renamed.Children = append(renamed.Children, added)
+
+	diff := DiffSnapshots(oldTree, newTree)
+	assert.Equal(t, []*VBoxSnapshot{added}, diff.Added)
+	assert.Equal(t, []*VBoxSnapshot{renamed}, diff.Changed)
+	assert.Len(t, diff.Removed, 3)
+
+	assert.Equal(t, len(oldTree.GetSnapshots()), len(DiffSnapshots(oldTree, nil).Removed))
+	assert.Equal(t, len(newTree.GetSnapshots()), len(DiffSnapshots(nil, newTree).Added))
+}
+
+func TestSnapshot_JSON(t *testing.T) {
+	rootNode, err := ParseSnapshotData(getTestData())
+	assert.NoError(t, err)
+	rootNode.Description = "base image"
+	rootNode.TimeStamp = time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	data, err := json.Marshal(rootNode)
+	assert.NoError(t, err)
+
+	var decoded VBoxSnapshot
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "base image", decoded.Description)
+	assert.True(t, rootNode.TimeStamp.Equal(decoded.TimeStamp))
+	assert.Equal(t, len(rootNode.GetSnapshots()), len(decoded.GetSnapshots()))
+	assert.True(t, DiffSnapshots(rootNode, &decoded).Empty())
+
+	current := decoded.GetCurrentSnapshot()
+	assert.NotNil(t, current)
+	assert.Equal(t, "Imported/Snapshot 1/Snapshot-Export", current.Path())
+}
+
+func TestSnapshot_ReadSnapshotTimeStamps(t *testing.T) {
+	rootNode, err := ParseSnapshotData(getTestData())
+	assert.NoError(t, err)
+
+	settings := filepath.Join(t.TempDir(), "vm.vbox")
+	err = os.WriteFile(settings, []byte(`<?xml version="1.0"?>
+<VirtualBox xmlns="http://www.virtualbox.org/" version="1.19-linux">
+  <Machine uuid="{0b3e8f1c-9a53-4d1e-8c6e-4c1f0f2d7a11}" name="vm">
+    <Snapshot uuid="{7e5b4165-91ec-4091-a74c-a5709d584530}" name="Imported" timeStamp="2024-10-01T12:00:00Z">
+      <Description>base image</Description>
+      <Snapshots>
+        <Snapshot uuid="{5fc461ec-da7a-40a8-a168-03134d7cdf5c}" name="Snapshot 1" timeStamp="2024-10-02T08:30:00Z"/>
+      </Snapshots>
+    </Snapshot>
+  </Machine>
+</VirtualBox>`), 0644)
+	assert.NoError(t, err)
+
+	// The descriptions come from VBoxManage
+	rootNode.Description = "from VBoxManage"
+	assert.NoError(t, ReadSnapshotTimeStamps(settings, rootNode))
+	assert.Equal(t, "from VBoxManage", rootNode.Description)
+	assert.Equal(t, time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC), rootNode.TimeStamp)
+	assert.Equal(t, time.Date(2024, 10, 2, 8, 30, 0, 0, time.UTC), rootNode.Children[0].TimeStamp)
+}
@@ -0,0 +1,242 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common/ovfmodel"
+)
+
+// This step describes what the build produced and writes it to the
+// manifest of the artifact, in the output directory. It runs last, while
+// the VM is still registered. The checksums are those computed by the
+// export, the exported files aren't read again. A failure is reported, but
+// the artifact is built all the same, without metadata.
+//
+// Uses:
+//
+//	exportOutputs []ExportOutput - The exports, if any.
+//	exportVMName string - The VM exported instead of vmName, if set.
+//	generated_data.TargetSnapshotUUID string - The snapshot taken, if any.
+//
+// Produces:
+//
+//	artifactMetadata *ArtifactMetadata - The description of the artifact.
+type StepArtifactMetadata struct {
+	OutputDir string
+	// The VM built is kept registered once the build is over
+	KeepRegistered bool
+}
+
+func (s *StepArtifactMetadata) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	metadata, err := s.metadata(driver, state, vmName)
+	// The vm builder has no output directory when it skips the export
+	if _, statErr := os.Stat(s.OutputDir); err == nil && statErr == nil {
+		_, err = metadata.writeManifest(s.OutputDir)
+	}
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error writing the manifest of the artifact: %s", err))
+		return multistep.ActionContinue
+	}
+	state.Put("artifactMetadata", metadata)
+
+	return multistep.ActionContinue
+}
+
+func (s *StepArtifactMetadata) metadata(driver Driver, state multistep.StateBag, vmName string) (*ArtifactMetadata, error) {
+	metadata := &ArtifactMetadata{
+		VMName:     vmName,
+		Registered: s.KeepRegistered,
+		Disks:      []ArtifactDisk{},
+		Checksums:  make(map[string]string),
+	}
+
+	version, err := driver.Version()
+	if err != nil {
+		return nil, fmt.Errorf("error getting VirtualBox version: %s", err)
+	}
+	metadata.VirtualBoxVersion = version
+
+	output, err := driver.VBoxManageWithOutput("showvminfo", vmName, "--machinereadable")
+	if err != nil {
+		return nil, err
+	}
+	metadata.VMUUID = ParseVMInfo(output)["UUID"]
+
+	if data, ok := state.GetOk("generated_data"); ok {
+		metadata.SnapshotUUID, _ = data.(map[string]interface{})["TargetSnapshotUUID"].(string)
+	}
+
+	exportVMName := vmName
+	if name, ok := state.GetOk("exportVMName"); ok {
+		exportVMName = name.(string)
+	}
+
+	metadata.Exports, _ = state.Get("exportOutputs").([]ExportOutput)
+	for _, export := range metadata.Exports {
+		var disks []ArtifactDisk
+		switch {
+		case isDiskImageFormat(export.Format):
+			disks, err = diskImageDisks(driver, exportVMName, export)
+		case export.Format == "ovf" || export.Format == "ova":
+			if metadata.Descriptor == "" {
+				metadata.Descriptor = export.Path
+			}
+			disks, err = applianceDisks(export.Path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error describing the %s disks: %s", export.Format, err)
+		}
+		metadata.Disks = append(metadata.Disks, disks...)
+
+		for _, path := range export.Files {
+			name, err := filepath.Rel(s.OutputDir, path)
+			if err != nil {
+				name = path
+			}
+			name = filepath.ToSlash(name)
+			// The files of a Vagrant box are those of the OVF export
+			if _, ok := metadata.Checksums[name]; ok {
+				continue
+			}
+			sum, ok := export.checksums[filepath.Base(path)]
+			if !ok {
+				log.Printf("The export of %s didn't checksum it", path)
+				continue
+			}
+			metadata.Checksums[name] = sum
+		}
+	}
+
+	return metadata, nil
+}
+
+func (s *StepArtifactMetadata) Cleanup(state multistep.StateBag) {}
+
+// diskImageDisks describes the disk images of an export, converted from
+// the disks of the VM in order.
+func diskImageDisks(driver Driver, vmName string, export ExportOutput) ([]ArtifactDisk, error) {
+	sources, err := vmDisks(driver, vmName)
+	if err != nil {
+		return nil, err
+	}
+
+	var disks []ArtifactDisk
+	for _, path := range export.Files {
+		if isChecksumFile(path) {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		disk := ArtifactDisk{
+			Path:       path,
+			Format:     export.Format,
+			ActualSize: info.Size(),
+		}
+		if i := len(disks); i < len(sources) {
+			disk.VirtualSize = sources[i].Capacity
+		}
+		disks = append(disks, disk)
+	}
+	return disks, nil
+}
+
+// applianceDisks describes the disk images of an .ovf or .ova appliance.
+func applianceDisks(path string) ([]ArtifactDisk, error) {
+	data, err := ovfmodel.ReadDescriptor(path)
+	if err != nil {
+		return nil, err
+	}
+	descriptor, err := ovfmodel.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	ovfDisks, err := descriptor.Disks()
+	if err != nil {
+		return nil, err
+	}
+
+	ova := strings.EqualFold(filepath.Ext(path), ".ova")
+	var sizes map[string]int64
+	if ova {
+		if sizes, err = ovaFileSizes(path); err != nil {
+			return nil, err
+		}
+	}
+
+	var disks []ArtifactDisk
+	for _, d := range ovfDisks {
+		if d.File == "" {
+			continue
+		}
+		disk := ArtifactDisk{
+			Format:      diskFormatName(d.Format),
+			VirtualSize: d.Capacity,
+		}
+		if ova {
+			disk.Path = d.File
+			disk.Archive = path
+			disk.ActualSize = sizes[d.File]
+		} else {
+			disk.Path = filepath.Join(filepath.Dir(path), d.File)
+			info, err := os.Stat(disk.Path)
+			if err != nil {
+				return nil, err
+			}
+			disk.ActualSize = info.Size()
+		}
+		disks = append(disks, disk)
+	}
+	return disks, nil
+}
+
+// diskFormatName returns the short name of an OVF disk format URI, such as
+// vmdk.
+func diskFormatName(format string) string {
+	lower := strings.ToLower(format)
+	for _, name := range diskImageFormats {
+		if strings.Contains(lower, name) {
+			return name
+		}
+	}
+	return format
+}
+
+// ovaFileSizes returns the sizes of the files of an OVA, by name.
+func ovaFileSizes(path string) (map[string]int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sizes := make(map[string]int64)
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return sizes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		sizes[hdr.Name] = hdr.Size
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common/ovfmodel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepArtifactMetadata_impl(t *testing.T) {
+	var _ multistep.Step = new(StepArtifactMetadata)
+}
+
+func TestStepArtifactMetadata(t *testing.T) {
+	state := testState(t)
+	dir := t.TempDir()
+	step := &StepArtifactMetadata{OutputDir: dir}
+	state.Put("vmName", "foo")
+	state.Put("generated_data", map[string]interface{}{"TargetSnapshotUUID": "50000000-0000-0000-0000-000000000005"})
+
+	ovfPath := filepath.Join(dir, "foo.ovf")
+	vmdkPath := filepath.Join(dir, "foo-disk001.vmdk")
+	rawPath := filepath.Join(dir, "foo.raw")
+	assert.NoError(t, os.WriteFile(ovfPath, []byte(`<Envelope>`+
+		`<References><File ovf:id="file1" ovf:href="foo-disk001.vmdk"/></References>`+
+		`<DiskSection><Disk ovf:capacity="40" ovf:capacityAllocationUnits="byte * 2^30" ovf:diskId="vmdisk1" ovf:fileRef="file1"`+
+		` ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/></DiskSection>`+
+		`</Envelope>`), 0644))
+	assert.NoError(t, os.WriteFile(vmdkPath, []byte("vmdk"), 0644))
+	assert.NoError(t, os.WriteFile(rawPath, []byte("raw disk"), 0644))
+	state.Put("exportOutputs", []ExportOutput{
+		// The export checksummed the files it wrote, but the descriptor,
+		// which isn't read again
+		{Format: "ovf", Path: ovfPath, Files: []string{vmdkPath, ovfPath},
+			checksums: map[string]string{"foo-disk001.vmdk": "0123abcd"}},
+		{Format: "raw", Path: rawPath, Files: []string{rawPath},
+			checksums: map[string]string{"foo.raw": "4567cdef"}},
+	})
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VersionResult = "7.0.14"
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list hdds": "UUID:           10000000-0000-0000-0000-000000000001\nLocation:       /vms/foo/foo.vdi\n" +
+			"Capacity:       40960 MBytes\n",
+		"showvminfo foo --machinereadable": `name="foo"
+UUID="00000000-0000-0000-0000-000000000001"
+"SATA Controller-ImageUUID-0-0"="10000000-0000-0000-0000-000000000001"
+`,
+	}
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	expected := &ArtifactMetadata{
+		VMName:            "foo",
+		VMUUID:            "00000000-0000-0000-0000-000000000001",
+		VirtualBoxVersion: "7.0.14",
+		Descriptor:        ovfPath,
+		Disks: []ArtifactDisk{
+			{Path: vmdkPath, Format: "vmdk", VirtualSize: 40 << 30, ActualSize: 4},
+			{Path: rawPath, Format: "raw", VirtualSize: 40 << 30, ActualSize: 8},
+		},
+		Checksums: map[string]string{
+			"foo-disk001.vmdk": "0123abcd",
+			"foo.raw":          "4567cdef",
+		},
+		SnapshotUUID: "50000000-0000-0000-0000-000000000005",
+		Exports:      state.Get("exportOutputs").([]ExportOutput),
+	}
+	assert.Equal(t, expected, state.Get("artifactMetadata"))
+
+	data, err := os.ReadFile(filepath.Join(dir, ArtifactManifestFilename))
+	assert.NoError(t, err)
+	expectedData, err := json.Marshal(expected)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(expectedData), string(data))
+}
+
+func TestStepArtifactMetadata_error(t *testing.T) {
+	state := testState(t)
+	dir := t.TempDir()
+	step := &StepArtifactMetadata{OutputDir: dir}
+	state.Put("vmName", "foo")
+	driver := state.Get("driver").(*DriverMock)
+	driver.VersionErr = errors.New("VBoxManage not found")
+
+	// The artifact is built all the same, without metadata
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	assert.Nil(t, state.Get("error"))
+	assert.Nil(t, state.Get("artifactMetadata"))
+	assert.NoFileExists(t, filepath.Join(dir, ArtifactManifestFilename))
+}
+
+func TestApplianceDisks_ova(t *testing.T) {
+	dir := t.TempDir()
+	ovfPath := filepath.Join(dir, "foo.ovf")
+	data, err := os.ReadFile("ovfmodel/testdata/virtualbox.ovf")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(ovfPath, data, 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "packer-disk001.vmdk"), []byte("vmdk"), 0644))
+	ovaPath := filepath.Join(dir, "foo.ova")
+	_, err = ovfmodel.PackOVA(ovfPath, ovaPath)
+	assert.NoError(t, err)
+
+	disks, err := applianceDisks(ovaPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []ArtifactDisk{{
+		Path:        "packer-disk001.vmdk",
+		Archive:     ovaPath,
+		Format:      "vmdk",
+		VirtualSize: 42949672960,
+		ActualSize:  4,
+	}}, disks)
+}
@@ -105,6 +105,11 @@ func (s *StepAttachFloppy) Cleanup(state multistep.StateBag) {
 		}
 	}()
 
+	// The drive of a machine in saved state was emptied and can't be removed
+	if _, ok := state.GetOk("saved_state"); ok {
+		return
+	}
+
 	driver := state.Get("driver").(Driver)
 	vmName := state.Get("vmName").(string)
 
@@ -0,0 +1,121 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// This step assigns MAC addresses to the network adapters of the VM
+// according to the configured policy.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+type StepConfigureMACAddresses struct {
+	MACAddressPolicy string
+	MACAddresses     []string
+	// Put the MAC addresses back as they were once the build is done, for
+	// VMs that outlive it.
+	Restore bool
+
+	restore []string // The modifyvm command restoring the MAC addresses
+}
+
+func (s *StepConfigureMACAddresses) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.MACAddressPolicy == "" {
+		log.Println("No MAC address policy, keeping the current MAC addresses.")
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	if s.Restore {
+		restore, err := restoreMACAddressesCommand(driver, vmName)
+		if err != nil {
+			err := fmt.Errorf("Error reading the MAC addresses of the virtual machine: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		s.restore = restore
+	}
+
+	ui.Say(fmt.Sprintf("Assigning MAC addresses (mac_address_policy = %s)...", s.MACAddressPolicy))
+	command := []string{"modifyvm", vmName}
+	switch s.MACAddressPolicy {
+	case MACAddressPolicyRandom:
+		command = append(command, regenerateMACAddressesArgs()...)
+	case MACAddressPolicyFixed:
+		for i, address := range s.MACAddresses {
+			command = append(command, fmt.Sprintf("--macaddress%d", i+1), address)
+		}
+	case MACAddressPolicyVMName:
+		for i := 1; i <= maxNetworkAdapters; i++ {
+			command = append(command, fmt.Sprintf("--macaddress%d", i), DeriveMACAddress(vmName, i))
+		}
+	}
+
+	if err := driver.VBoxManage(command...); err != nil {
+		err := fmt.Errorf("Error assigning MAC addresses: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepConfigureMACAddresses) Cleanup(state multistep.StateBag) {
+	if s.restore == nil {
+		return
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say("Restoring the MAC addresses of the virtual machine...")
+	if err := driver.VBoxManage(s.restore...); err != nil {
+		ui.Error(fmt.Sprintf("Error restoring the MAC addresses of the virtual machine: %s", err))
+	}
+}
+
+// restoreMACAddressesCommand returns the modifyvm command putting the MAC
+// addresses of the network adapters back as they are now.
+func restoreMACAddressesCommand(driver Driver, vmName string) ([]string, error) {
+	output, err := driver.VBoxManageWithOutput("showvminfo", vmName, "--machinereadable")
+	if err != nil {
+		return nil, err
+	}
+	info := ParseVMInfo(output)
+
+	command := []string{"modifyvm", vmName}
+	for i := 1; i <= maxNetworkAdapters; i++ {
+		if address, ok := info[fmt.Sprintf("macaddress%d", i)]; ok {
+			command = append(command, fmt.Sprintf("--macaddress%d", i), address)
+		}
+	}
+	return command, nil
+}
+
+// regenerateMACAddressesArgs returns the modifyvm arguments asking
+// VirtualBox to generate a new MAC address for every network adapter.
+func regenerateMACAddressesArgs() []string {
+	var args []string
+	for i := 1; i <= maxNetworkAdapters; i++ {
+		args = append(args, fmt.Sprintf("--macaddress%d", i), "auto")
+	}
+	return args
+}
@@ -0,0 +1,92 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepConfigureMACAddresses_impl(t *testing.T) {
+	var _ multistep.Step = new(StepConfigureMACAddresses)
+}
+
+func TestStepConfigureMACAddresses(t *testing.T) {
+	tcs := []struct {
+		Step     *StepConfigureMACAddresses
+		Expected []string
+	}{
+		{
+			Step:     &StepConfigureMACAddresses{},
+			Expected: nil,
+		},
+		{
+			Step: &StepConfigureMACAddresses{
+				MACAddressPolicy: MACAddressPolicyFixed,
+				MACAddresses:     []string{"080027123456"},
+			},
+			Expected: []string{"modifyvm", "foo", "--macaddress1", "080027123456"},
+		},
+		{
+			Step: &StepConfigureMACAddresses{
+				MACAddressPolicy: MACAddressPolicyVMName,
+			},
+			Expected: []string{"modifyvm", "foo",
+				"--macaddress1", DeriveMACAddress("foo", 1),
+				"--macaddress2", DeriveMACAddress("foo", 2),
+				"--macaddress3", DeriveMACAddress("foo", 3),
+				"--macaddress4", DeriveMACAddress("foo", 4),
+				"--macaddress5", DeriveMACAddress("foo", 5),
+				"--macaddress6", DeriveMACAddress("foo", 6),
+				"--macaddress7", DeriveMACAddress("foo", 7),
+				"--macaddress8", DeriveMACAddress("foo", 8),
+			},
+		},
+		{
+			Step: &StepConfigureMACAddresses{
+				MACAddressPolicy: MACAddressPolicyRandom,
+			},
+			Expected: append([]string{"modifyvm", "foo"}, regenerateMACAddressesArgs()...),
+		},
+	}
+
+	for _, tc := range tcs {
+		state := testState(t)
+		state.Put("vmName", "foo")
+		driver := state.Get("driver").(*DriverMock)
+
+		if action := tc.Step.Run(context.Background(), state); action != multistep.ActionContinue {
+			t.Fatalf("bad action: %#v", action)
+		}
+		if tc.Expected == nil {
+			assert.Empty(t, driver.VBoxManageCalls)
+			continue
+		}
+		assert.Equal(t, [][]string{tc.Expected}, driver.VBoxManageCalls)
+	}
+}
+
+func TestStepConfigureMACAddresses_Restore(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResult = "nic1=\"nat\"\nmacaddress1=\"080027AB12CD\"\nnic2=\"none\"\nmacaddress2=\"080027AB12CE\"\n"
+	step := &StepConfigureMACAddresses{
+		MACAddressPolicy: MACAddressPolicyRandom,
+		Restore:          true,
+	}
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+	assert.Equal(t, append([]string{"modifyvm", "foo"}, regenerateMACAddressesArgs()...), driver.VBoxManageCalls[1])
+
+	// The MAC addresses of the VM are put back
+	step.Cleanup(state)
+	assert.Equal(t, []string{"modifyvm", "foo", "--macaddress1", "080027AB12CD", "--macaddress2", "080027AB12CE"},
+		driver.VBoxManageCalls[2])
+}
@@ -0,0 +1,144 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// The number of network adapters VirtualBox can configure on a VM.
+const maxNetworkAdapters = 8
+
+// This step restricts the network of the VM so that the guest can only
+// reach the host through the NAT engine of its first network adapter.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+type StepConfigureNetworkIsolation struct {
+	NetworkIsolation bool
+	// Put the network settings back as they were once the build is done,
+	// for VMs that outlive it.
+	Restore bool
+
+	restore []string // The modifyvm command restoring the settings
+}
+
+// The showvminfo values naming what a network adapter is attached to, and the
+// modifyvm option setting them.
+var networkAttachmentOptions = [][2]string{
+	{"bridgeadapter", "bridgeadapter"},
+	{"hostonlyadapter", "hostonlyadapter"},
+	{"hostonly-network", "host-only-net"},
+	{"intnet", "intnet"},
+	{"nat-network", "nat-network"},
+}
+
+func (s *StepConfigureNetworkIsolation) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.NetworkIsolation {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	if s.Restore {
+		restore, err := restoreNetworkCommand(driver, vmName)
+		if err != nil {
+			err := fmt.Errorf("Error reading the network settings of the virtual machine: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		s.restore = restore
+	}
+
+	ui.Say("Isolating the virtual machine from external networks...")
+
+	// Outgoing NAT connections bound to the loopback address cannot leave
+	// the host, while the communicator and HTTP server stay reachable.
+	command := []string{
+		"modifyvm", vmName,
+		"--nic1", "nat",
+		"--natbindip1", "127.0.0.1",
+		"--natdnsproxy1", "off",
+		"--natdnshostresolver1", "off",
+	}
+	for i := 2; i <= maxNetworkAdapters; i++ {
+		command = append(command, fmt.Sprintf("--nic%d", i), "none")
+	}
+
+	if err := driver.VBoxManage(command...); err != nil {
+		err := fmt.Errorf("Error isolating the virtual machine network: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepConfigureNetworkIsolation) Cleanup(state multistep.StateBag) {
+	if s.restore == nil {
+		return
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say("Restoring the network settings of the virtual machine...")
+	if err := driver.VBoxManage(s.restore...); err != nil {
+		ui.Error(fmt.Sprintf("Error restoring the network settings of the virtual machine: %s", err))
+	}
+}
+
+// restoreNetworkCommand returns the modifyvm command putting the network
+// settings changed by the isolation back as they are now.
+func restoreNetworkCommand(driver Driver, vmName string) ([]string, error) {
+	output, err := driver.VBoxManageWithOutput("showvminfo", vmName, "--machinereadable")
+	if err != nil {
+		return nil, err
+	}
+	info := ParseVMInfo(output)
+	nat, err := readNATSettings(info["CfgFile"], 1)
+	if err != nil {
+		return nil, err
+	}
+
+	command := []string{
+		"modifyvm", vmName,
+		"--natbindip1", nat.BindIP,
+		"--natdnsproxy1", onOff(nat.DNSProxy),
+		"--natdnshostresolver1", onOff(nat.DNSHostResolver),
+	}
+	for i := 1; i <= maxNetworkAdapters; i++ {
+		nic, ok := info[fmt.Sprintf("nic%d", i)]
+		if !ok {
+			continue
+		}
+		command = append(command, fmt.Sprintf("--nic%d", i), nic)
+		for _, option := range networkAttachmentOptions {
+			if name, ok := info[fmt.Sprintf("%s%d", option[0], i)]; ok {
+				command = append(command, fmt.Sprintf("--%s%d", option[1], i), name)
+			}
+		}
+	}
+	return command, nil
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,85 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepConfigureNetworkIsolation_impl(t *testing.T) {
+	var _ multistep.Step = new(StepConfigureNetworkIsolation)
+}
+
+func TestStepConfigureNetworkIsolation(t *testing.T) {
+	state := testState(t)
+	step := &StepConfigureNetworkIsolation{NetworkIsolation: true}
+
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	if len(driver.VBoxManageCalls) != 1 {
+		t.Fatalf("should call vboxmanage once: %#v", driver.VBoxManageCalls)
+	}
+	command := driver.VBoxManageCalls[0]
+	assert.Subset(t, command, []string{"--natbindip1", "127.0.0.1", "--nic8", "none"})
+}
+
+func TestStepConfigureNetworkIsolation_Disabled(t *testing.T) {
+	state := testState(t)
+	step := new(StepConfigureNetworkIsolation)
+
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if len(driver.VBoxManageCalls) != 0 {
+		t.Fatal("shouldn't have called vboxmanage")
+	}
+}
+
+func TestStepConfigureNetworkIsolation_Restore(t *testing.T) {
+	state := testState(t)
+	step := &StepConfigureNetworkIsolation{NetworkIsolation: true, Restore: true}
+
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResult = `CfgFile="` + testVMSettings(t, `<NAT><DNS use-host-resolver="true"/></NAT>`) + `"
+nic1="nat"
+nic2="bridged"
+bridgeadapter2="eth0"
+nic3="none"`
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	step.Cleanup(state)
+	assert.Equal(t, []string{
+		"modifyvm", "foo",
+		"--natbindip1", "",
+		"--natdnsproxy1", "off",
+		"--natdnshostresolver1", "on",
+		"--nic1", "nat",
+		"--nic2", "bridged", "--bridgeadapter2", "eth0",
+		"--nic3", "none",
+	}, driver.VBoxManageCalls[len(driver.VBoxManageCalls)-1])
+}
@@ -4,30 +4,86 @@
 package common
 
 import (
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
 	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	"github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common/ovfmodel"
+	"github.com/klauspost/compress/zstd"
 )
 
-// This step cleans up forwarded ports and exports the VM to an OVF.
+// ExportOutput is the set of files exported to one format.
+type ExportOutput struct {
+	Format string `json:"format"`
+	// The main file of the export: the .ovf, the .ova or the first disk
+	// image. Empty for a cloud export.
+	Path  string   `json:"path,omitempty"`
+	Files []string `json:"files,omitempty"`
+	// The ID of the image of a cloud export.
+	ImageID string `json:"image_id,omitempty"`
+
+	// The sha256 checksums of the files computed while exporting them, by
+	// name.
+	checksums map[string]string
+}
+
+// This step cleans up forwarded ports and exports the VM to an OVF, an OVA,
+// disk images or the cloud, or its first disk alone. The descriptor of the
+// appliances is edited and their manifest written right after VBoxManage
+// exported them, in one pass over an OVA.
 //
 // Uses:
 //
+//	networkTraceAdapters []int - The adapters whose traffic is captured.
+//	exportVMName string - The VM to export instead of vmName, if set.
+//
+// A failed export leaves nothing in OutputDir: VBoxManage can't resume
+// one, so the partial files are removed for the next build to start over.
+//
 // Produces:
 //
-//	exportPath string - The path to the resulting export.
+//	exportPath string - The path to the first export to files.
+//	exportOutputs []ExportOutput - The exports, in the order of the formats.
+//	generated_data.CloudImageID string - The ID of the cloud image, if any.
 type StepExport struct {
-	Format         string
-	OutputDir      string
-	OutputFilename string
-	ExportOpts     []string
-	Bundling       VBoxBundleConfig
-	SkipNatMapping bool
-	SkipExport     bool
+	Format           string
+	Formats          []string // Format alone if empty
+	OutputDir        string
+	OutputFilename   string
+	ExportOpts       []string
+	Bundling         VBoxBundleConfig
+	SkipNatMapping   bool
+	SkipExport       bool
+	MACAddressPolicy string
+	Product          ExportProductConfig
+	Transform        OVFTransform
+	Manifest         ExportManifestConfig
+	Cloud            CloudExportConfig
+	DiskOnly         bool
+	DiskFormat       string
+	DiskCompression  string
+	// Export to a temporary directory in OutputDir and move the files in
+	// place once all the exports succeeded
+	Atomic bool
+
+	// Where the exports are written: OutputDir, or the temporary directory
+	// with Atomic
+	workDir string
 }
 
 func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
@@ -39,6 +95,9 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 		}
 	}
 
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
+	generatedData.Put("CloudImageID", "")
+
 	driver := state.Get("driver").(Driver)
 	ui := state.Get("ui").(packersdk.Ui)
 	vmName := state.Get("vmName").(string)
@@ -54,9 +113,11 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 
 	ui.Say("Preparing to export machine...")
 
-	// Clear out the Packer-created forwarding rule
+	// Clear out the Packer-created forwarding rule. The rule of a NAT
+	// Network isn't part of the VM, and is deleted by StepPortForwarding.
 	commPort := state.Get("commHostPort")
-	if !s.SkipNatMapping && commPort != 0 {
+	_, natNetwork := state.GetOk("commNATNetworkRule")
+	if !s.SkipNatMapping && commPort != 0 && !natNetwork {
 		ui.Message(fmt.Sprintf(
 			"Deleting forwarded port mapping for the communicator (SSH, WinRM, etc) (host port %d)", commPort))
 		command := []string{"modifyvm", vmName, "--natpf1", "delete", "packercomm"}
@@ -68,8 +129,230 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 		}
 	}
 
-	// Export the VM to an OVF
-	outputPath := filepath.Join(s.OutputDir, s.OutputFilename+"."+s.Format)
+	// Stop capturing traffic so the trace settings aren't exported
+	if adapters, ok := state.GetOk("networkTraceAdapters"); ok {
+		ui.Message("Disabling network traffic capture")
+		command := disableNetworkTraceCommand(vmName, adapters.([]int))
+		if err := driver.VBoxManage(command...); err != nil {
+			err := fmt.Errorf("Error disabling network traffic capture: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	// The machine exported may be a clone of one of the snapshots of the VM
+	exportVMName := vmName
+	if name, ok := state.GetOk("exportVMName"); ok {
+		exportVMName = name.(string)
+	}
+
+	// The MAC addresses of a VM that isn't a clone are put back once it is
+	// exported
+	var restoreMACs []string
+	if s.MACAddressPolicy == ExportMACAddressPolicyAllNew {
+		if exportVMName == vmName {
+			command, err := restoreMACAddressesCommand(driver, vmName)
+			if err != nil {
+				err := fmt.Errorf("Error reading the MAC addresses of the virtual machine: %s", err)
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+			restoreMACs = command
+		}
+
+		ui.Message("Generating new MAC addresses for the exported machine")
+		command := append([]string{"modifyvm", exportVMName}, regenerateMACAddressesArgs()...)
+		if err := driver.VBoxManage(command...); err != nil {
+			err := fmt.Errorf("Error generating new MAC addresses: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	before := listDir(s.OutputDir)
+	s.workDir = s.OutputDir
+	if s.Atomic {
+		dir, err := os.MkdirTemp(s.OutputDir, ".packer-export-")
+		if err != nil {
+			err := fmt.Errorf("Error creating the temporary export directory: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		s.workDir = dir
+	}
+
+	formats := s.Formats
+	if len(formats) == 0 {
+		formats = []string{s.Format}
+	}
+	exportOutputs, err := s.export(ctx, driver, ui, generatedData, exportVMName, formats)
+	if restoreMACs != nil {
+		ui.Message("Restoring the MAC addresses of the virtual machine")
+		if restoreErr := driver.VBoxManage(restoreMACs...); restoreErr != nil && err == nil {
+			err = fmt.Errorf("Error restoring the MAC addresses of the virtual machine: %s", restoreErr)
+		}
+	}
+	if err == nil && s.Atomic {
+		err = s.moveExports(exportOutputs)
+	}
+	if err != nil {
+		state.Put("error", err)
+		ui.Error(err.Error())
+		s.removePartialExport(ui, before)
+		return multistep.ActionHalt
+	}
+
+	exportPath := ""
+	for _, output := range exportOutputs {
+		if output.Path != "" {
+			exportPath = output.Path
+			break
+		}
+	}
+	state.Put("exportPath", exportPath)
+	state.Put("exportOutputs", exportOutputs)
+
+	return multistep.ActionContinue
+}
+
+// export exports the VM to the formats, or its first disk alone, and
+// returns the exports in the order of the formats.
+func (s *StepExport) export(ctx context.Context, driver Driver, ui packersdk.Ui, generatedData *packerbuilderdata.GeneratedData, vmName string, formats []string) ([]ExportOutput, error) {
+	if s.DiskOnly {
+		output, err := s.exportDiskOnly(driver, ui, vmName)
+		if err != nil {
+			return nil, err
+		}
+		return []ExportOutput{output}, nil
+	}
+
+	// An OVA is a tar of the files of the OVF appliance, export the VM once
+	// when both are wanted
+	packOVA := contains(formats, "ovf") && contains(formats, "ova")
+
+	outputs := make(map[string]ExportOutput)
+	for _, format := range exportOrder(formats, packOVA) {
+		var output ExportOutput
+		var err error
+		switch {
+		case isDiskImageFormat(format):
+			output, err = s.exportDiskImages(driver, ui, vmName, format)
+		case format == "ova" && packOVA:
+			output, err = s.packOVA(ui, outputs["ovf"].Path)
+		case format == "cloud":
+			output, err = s.exportCloud(ctx, driver, ui, vmName, outputs["ova"])
+			generatedData.Put("CloudImageID", output.ImageID)
+		default:
+			output, err = s.exportAppliance(driver, ui, vmName, format)
+		}
+		if err != nil {
+			return nil, err
+		}
+		outputs[format] = output
+	}
+
+	exportOutputs := make([]ExportOutput, 0, len(formats))
+	for _, format := range formats {
+		exportOutputs = append(exportOutputs, outputs[format])
+	}
+	return exportOutputs, nil
+}
+
+// moveExports moves the exported files from the temporary directory to
+// OutputDir, the main file of each export last so that it only shows up
+// once the files it needs are in place, and updates the paths of the
+// exports.
+func (s *StepExport) moveExports(outputs []ExportOutput) error {
+	main := make(map[string]bool)
+	for _, output := range outputs {
+		if output.Path != "" {
+			main[filepath.Base(output.Path)] = true
+		}
+	}
+	var names []string
+	for name := range listDir(s.workDir) {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if main[names[i]] != main[names[j]] {
+			return !main[names[i]]
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		if err := os.Rename(filepath.Join(s.workDir, name), filepath.Join(s.OutputDir, name)); err != nil {
+			return fmt.Errorf("Error moving the export in place: %s", err)
+		}
+	}
+	if err := os.Remove(s.workDir); err != nil {
+		log.Printf("Error removing the temporary export directory: %s", err)
+	}
+
+	moved := func(path string) string {
+		if path == "" {
+			return ""
+		}
+		return filepath.Join(s.OutputDir, filepath.Base(path))
+	}
+	for i := range outputs {
+		outputs[i].Path = moved(outputs[i].Path)
+		for j := range outputs[i].Files {
+			outputs[i].Files[j] = moved(outputs[i].Files[j])
+		}
+	}
+	s.workDir = s.OutputDir
+	return nil
+}
+
+// removePartialExport removes what a failed export left in OutputDir: the
+// files not in before, and the temporary directory.
+func (s *StepExport) removePartialExport(ui packersdk.Ui, before map[string]bool) {
+	files := newFiles(s.OutputDir, before)
+	if len(files) == 0 {
+		return
+	}
+	ui.Message("Removing the partial export...")
+	for _, path := range files {
+		if err := os.RemoveAll(path); err != nil {
+			log.Printf("Error removing %s: %s", path, err)
+		}
+	}
+}
+
+// exportProgress reports the progress of an export to the UI, with the
+// time left estimated from the time spent so far.
+type exportProgress struct {
+	ui    packersdk.Ui
+	start time.Time
+	now   func() time.Time
+}
+
+func newExportProgress(ui packersdk.Ui) *exportProgress {
+	return &exportProgress{ui: ui, start: time.Now(), now: time.Now}
+}
+
+func (p *exportProgress) report(percent int) {
+	// VBoxManage reports 0% as soon as it starts
+	if percent <= 0 {
+		return
+	}
+	if percent >= 100 {
+		p.ui.Message("Progress: 100%")
+		return
+	}
+	elapsed := p.now().Sub(p.start)
+	left := elapsed * time.Duration(100-percent) / time.Duration(percent)
+	p.ui.Message(fmt.Sprintf("Progress: %d%% (about %s left)", percent, left.Round(time.Second)))
+}
+
+// exportAppliance exports the VM to an OVF or OVA appliance.
+func (s *StepExport) exportAppliance(driver Driver, ui packersdk.Ui, vmName, format string) (ExportOutput, error) {
+	outputPath := filepath.Join(s.workDir, s.OutputFilename+"."+format)
+	before := listDir(s.workDir)
 
 	command := []string{
 		"export",
@@ -77,21 +360,554 @@ func (s *StepExport) Run(ctx context.Context, state multistep.StateBag) multiste
 		"--output",
 		outputPath,
 	}
+	command = append(command, s.Product.VSysArgs()...)
 	command = append(command, s.ExportOpts...)
 
 	ui.Say("Exporting virtual machine...")
 	ui.Message(fmt.Sprintf("Executing: %s", strings.Join(command, " ")))
-	err := driver.VBoxManage(command...)
+	if err := driver.VBoxManageWithProgress(newExportProgress(ui).report, command...); err != nil {
+		return ExportOutput{}, fmt.Errorf("Error exporting virtual machine: %s", err)
+	}
+
+	return s.finishAppliance(ui, ExportOutput{
+		Format: format,
+		Path:   outputPath,
+		Files:  newFiles(s.workDir, before),
+	})
+}
+
+// packOVA writes the OVA of the OVF appliance exported to ovfPath.
+func (s *StepExport) packOVA(ui packersdk.Ui, ovfPath string) (ExportOutput, error) {
+	outputPath := filepath.Join(s.workDir, s.OutputFilename+".ova")
+	ui.Say(fmt.Sprintf("Packing %s from the OVF export...", filepath.Base(outputPath)))
+
+	// The OVF is finished already, the OVA only needs its checksums
+	checksum := sha256.New()
+	digests := []hash.Hash{checksum}
+	var manifestDigest hash.Hash
+	if s.Manifest.ExportManifest != "" {
+		manifestDigest, _ = ovfmodel.NewHash(s.Manifest.ExportManifest)
+		digests = append(digests, manifestDigest)
+	}
+	if _, err := ovfmodel.PackOVA(ovfPath, outputPath, digests...); err != nil {
+		return ExportOutput{}, fmt.Errorf("Error packing the OVA: %s", err)
+	}
+	output := ExportOutput{
+		Format:    "ova",
+		Path:      outputPath,
+		Files:     []string{outputPath},
+		checksums: map[string]string{filepath.Base(outputPath): hex.EncodeToString(checksum.Sum(nil))},
+	}
+
+	if manifestDigest != nil {
+		checksumPath, checksumFileSum, err := writeChecksumFile(outputPath, s.Manifest.ExportManifest, []manifestEntry{
+			{filepath.Base(outputPath), hex.EncodeToString(manifestDigest.Sum(nil))},
+		})
+		if err != nil {
+			return ExportOutput{}, fmt.Errorf("Error writing the checksums of the OVA: %s", err)
+		}
+		output.Files = append(output.Files, checksumPath)
+		output.checksums[filepath.Base(checksumPath)] = checksumFileSum
+	}
+	return output, nil
+}
+
+// exportDiskImages converts the disks of the VM to disk images of the given
+// format. The first disk is named after the VM, the next ones get a number.
+func (s *StepExport) exportDiskImages(driver Driver, ui packersdk.Ui, vmName, format string) (ExportOutput, error) {
+	disks, err := vmDisks(driver, vmName)
 	if err != nil {
-		err := fmt.Errorf("Error exporting virtual machine: %s", err)
-		state.Put("error", err)
-		ui.Error(err.Error())
-		return multistep.ActionHalt
+		return ExportOutput{}, fmt.Errorf("Error listing the disks of the virtual machine: %s", err)
+	}
+	if len(disks) == 0 {
+		return ExportOutput{}, fmt.Errorf("Error exporting %s disk images: the virtual machine has no disks", format)
 	}
 
-	state.Put("exportPath", outputPath)
+	output := ExportOutput{Format: format}
+	for i, disk := range disks {
+		name := s.OutputFilename
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d", name, i+1)
+		}
+		outputPath := filepath.Join(s.workDir, name+"."+format)
 
-	return multistep.ActionContinue
+		ui.Say(fmt.Sprintf("Converting disk %s to %s...", disk.UUID, filepath.Base(outputPath)))
+		if err := cloneDisk(driver, ui, disk.UUID, outputPath, format, ""); err != nil {
+			return output, err
+		}
+
+		if i == 0 {
+			output.Path = outputPath
+		}
+		output.Files = append(output.Files, outputPath)
+	}
+	return s.writeDiskChecksums(ui, output, nil)
+}
+
+// exportDiskOnly converts the first disk of the VM to a disk image, and
+// compresses it if asked to.
+func (s *StepExport) exportDiskOnly(driver Driver, ui packersdk.Ui, vmName string) (ExportOutput, error) {
+	disks, err := vmDisks(driver, vmName)
+	if err != nil {
+		return ExportOutput{}, fmt.Errorf("Error listing the disks of the virtual machine: %s", err)
+	}
+	if len(disks) == 0 {
+		return ExportOutput{}, fmt.Errorf("Error exporting the disk: the virtual machine has no disks")
+	}
+
+	// Other hypervisors only import stream optimized VMDKs
+	variant := ""
+	if s.DiskFormat == "vmdk" {
+		variant = "Stream"
+	}
+
+	outputPath := filepath.Join(s.workDir, s.OutputFilename+"."+s.DiskFormat)
+	ui.Say(fmt.Sprintf("Exporting disk %s to %s...", disks[0].UUID, filepath.Base(outputPath)))
+	if err := cloneDisk(driver, ui, disks[0].UUID, outputPath, s.DiskFormat, variant); err != nil {
+		return ExportOutput{}, err
+	}
+
+	output := ExportOutput{
+		Format: s.DiskFormat,
+		Path:   outputPath,
+		Files:  []string{outputPath},
+	}
+	if s.DiskCompression == "" {
+		return s.writeDiskChecksums(ui, output, nil)
+	}
+
+	// The compressed copy is checksummed as it is written
+	ui.Say(fmt.Sprintf("Compressing %s with %s...", filepath.Base(outputPath), s.DiskCompression))
+	outputPath, sums, err := compressFile(outputPath, s.DiskCompression, s.digestAlgorithms()...)
+	if err != nil {
+		return ExportOutput{}, fmt.Errorf("Error compressing the disk image: %s", err)
+	}
+	output.Path = outputPath
+	output.Files = []string{outputPath}
+	return s.writeDiskChecksums(ui, output, map[string][]string{filepath.Base(outputPath): sums})
+}
+
+// digestAlgorithms returns the algorithms of the checksums of the exported
+// files: sha256 for the artifact metadata, and the one of export_manifest if
+// set.
+func (s *StepExport) digestAlgorithms() []string {
+	if s.Manifest.ExportManifest == "" {
+		return []string{"sha256"}
+	}
+	return []string{"sha256", s.Manifest.ExportManifest}
+}
+
+// writeDiskChecksums computes the digestAlgorithms checksums of exported
+// disk images, but those sums has already, by name, and writes their
+// checksum file, named after the first one, if export_manifest is set. Disk
+// images have no OVF manifest to sign.
+func (s *StepExport) writeDiskChecksums(ui packersdk.Ui, output ExportOutput, sums map[string][]string) (ExportOutput, error) {
+	algorithm := s.Manifest.ExportManifest
+	if algorithm != "" {
+		ui.Say(fmt.Sprintf("Writing %s checksums of %s...", strings.ToUpper(algorithm), filepath.Base(output.Path)))
+	}
+
+	var entries []manifestEntry
+	output.checksums = make(map[string]string)
+	for _, path := range output.Files {
+		name := filepath.Base(path)
+		fileSums, ok := sums[name]
+		if !ok {
+			var err error
+			fileSums, err = digestFiles(path, s.digestAlgorithms()...)
+			if err != nil && algorithm == "" {
+				// The checksums are only for the artifact metadata, which
+				// does without them
+				log.Printf("Error checksumming %s: %s", path, err)
+				continue
+			}
+			if err != nil {
+				return ExportOutput{}, fmt.Errorf("Error writing the checksums of the disk images: %s", err)
+			}
+		}
+		output.checksums[name] = fileSums[0]
+		if algorithm != "" {
+			entries = append(entries, manifestEntry{name, fileSums[1]})
+		}
+	}
+	if algorithm == "" {
+		return output, nil
+	}
+
+	checksumPath, checksumFileSum, err := writeChecksumFile(output.Path, algorithm, entries)
+	if err != nil {
+		return ExportOutput{}, fmt.Errorf("Error writing the checksums of the disk images: %s", err)
+	}
+	output.Files = append(output.Files, checksumPath)
+	output.checksums[filepath.Base(checksumPath)] = checksumFileSum
+	return output, nil
 }
 
 func (s *StepExport) Cleanup(state multistep.StateBag) {}
+
+// cloneDisk converts a disk of the VM to a disk image with clonemedium,
+// reporting its progress to ui.
+func cloneDisk(driver Driver, ui packersdk.Ui, uuid, path, format, variant string) error {
+	command := []string{"clonemedium", "disk", uuid, path, "--format", strings.ToUpper(format)}
+	if variant != "" {
+		command = append(command, "--variant", variant)
+	}
+	if err := driver.VBoxManageWithProgress(newExportProgress(ui).report, command...); err != nil {
+		return fmt.Errorf("Error converting disk %s: %s", uuid, err)
+	}
+	// clonemedium registers the copy, which has nothing to do in the media
+	// registry
+	if err := driver.VBoxManage("closemedium", "disk", path); err != nil {
+		log.Printf("Error closing disk image %s: %s", path, err)
+	}
+	return nil
+}
+
+// compressFile replaces the file at path with its gzip or zstd compressed
+// copy, and returns the path of the copy and its digests, one per algorithm,
+// computed as it is written.
+func compressFile(path, compression string, algorithms ...string) (string, []string, error) {
+	var compressedPath string
+	var newWriter func(io.Writer) (io.WriteCloser, error)
+	switch compression {
+	case "gzip":
+		compressedPath = path + ".gz"
+		newWriter = func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }
+	case "zstd":
+		compressedPath = path + ".zst"
+		newWriter = func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }
+	default:
+		return "", nil, fmt.Errorf("unsupported compression %s", compression)
+	}
+
+	writers := make([]io.Writer, 0, len(algorithms)+1)
+	hashes := make([]hash.Hash, 0, len(algorithms))
+	for _, algorithm := range algorithms {
+		h, err := ovfmodel.NewHash(algorithm)
+		if err != nil {
+			return "", nil, err
+		}
+		hashes = append(hashes, h)
+		writers = append(writers, h)
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer in.Close()
+	out, err := os.Create(compressedPath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer out.Close()
+
+	w, err := newWriter(io.MultiWriter(append(writers, out)...))
+	if err == nil {
+		if _, err = io.Copy(w, in); err == nil {
+			err = w.Close()
+		}
+	}
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		os.Remove(compressedPath)
+		return "", nil, err
+	}
+
+	var sums []string
+	for _, h := range hashes {
+		sums = append(sums, hex.EncodeToString(h.Sum(nil)))
+	}
+	in.Close()
+	return compressedPath, sums, os.Remove(path)
+}
+
+// finishAppliance edits the descriptor of an exported appliance and writes
+// its manifest: it adds the product properties, which VBoxManage can't,
+// applies ovf_transform, writes the manifest if export_manifest is set, and
+// checks the product information made it.
+func (s *StepExport) finishAppliance(ui packersdk.Ui, output ExportOutput) (ExportOutput, error) {
+	var edit descriptorEdit
+	if len(s.Product.ExportProperties) > 0 || !s.Transform.IsEmpty() {
+		edit = s.editDescriptor
+	}
+	name := filepath.Base(output.Path)
+	if !s.Transform.IsEmpty() {
+		ui.Say(fmt.Sprintf("Transforming the OVF descriptor of %s...", name))
+	}
+	if s.Manifest.ExportManifest != "" {
+		ui.Say(fmt.Sprintf("Writing %s manifest of %s...", strings.ToUpper(s.Manifest.ExportManifest), name))
+		if s.Manifest.ExportSigningCertificate != "" {
+			ui.Message(fmt.Sprintf("Signing manifest with %s", s.Manifest.ExportSigningCertificate))
+		}
+	}
+
+	finish := finishOVF
+	if output.Format == "ova" {
+		finish = finishOVA
+	}
+	written, checksums, err := finish(output.Path, edit, &s.Manifest)
+	if err != nil {
+		return ExportOutput{}, fmt.Errorf("Error finishing the exported appliance: %s", err)
+	}
+	for _, path := range written {
+		if !contains(output.Files, path) {
+			output.Files = append(output.Files, path)
+		}
+	}
+	output.checksums = checksums
+
+	if s.Product.VSysArgs() == nil && len(s.Product.ExportProperties) == 0 {
+		return output, nil
+	}
+	descriptor, err := ovfmodel.ReadDescriptor(output.Path)
+	if err == nil {
+		err = verifyProductSection(descriptor, &s.Product)
+	}
+	if err != nil {
+		return ExportOutput{}, fmt.Errorf("Error checking the exported appliance: %s", err)
+	}
+	return output, nil
+}
+
+// editDescriptor adds the product properties to an exported descriptor and
+// applies ovf_transform.
+func (s *StepExport) editDescriptor(data []byte) ([]byte, error) {
+	d, err := ovfmodel.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Product.ExportProperties) > 0 {
+		if err := d.AddProductProperties(s.Product.ovfProperties()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Transform.Apply(d); err != nil {
+		return nil, fmt.Errorf("ovf_transform: %s", err)
+	}
+	return d.Bytes(), nil
+}
+
+// ovfProductSection is the part of an OVF descriptor checked after export.
+type ovfProductSection struct {
+	VirtualSystem struct {
+		Product struct {
+			Product    string `xml:"Product"`
+			Vendor     string `xml:"Vendor"`
+			Version    string `xml:"Version"`
+			ProductURL string `xml:"ProductUrl"`
+			VendorURL  string `xml:"VendorUrl"`
+			Properties []struct {
+				Key              string `xml:"key,attr"`
+				Type             string `xml:"type,attr"`
+				Value            string `xml:"value,attr"`
+				UserConfigurable bool   `xml:"userConfigurable,attr"`
+			} `xml:"Property"`
+		} `xml:"ProductSection"`
+		Annotation string `xml:"AnnotationSection>Annotation"`
+		License    string `xml:"EulaSection>License"`
+	} `xml:"VirtualSystem"`
+}
+
+// verifyProductSection checks that an OVF descriptor holds the product
+// information and properties of c.
+func verifyProductSection(descriptor []byte, c *ExportProductConfig) error {
+	var ovf ovfProductSection
+	if err := xml.Unmarshal(descriptor, &ovf); err != nil {
+		return fmt.Errorf("invalid OVF descriptor: %s", err)
+	}
+	vs := ovf.VirtualSystem
+
+	var missing []string
+	for _, field := range []struct{ name, want, got string }{
+		{"product", c.ExportProduct, vs.Product.Product},
+		{"product URL", c.ExportProductURL, vs.Product.ProductURL},
+		{"vendor", c.ExportVendor, vs.Product.Vendor},
+		{"vendor URL", c.ExportVendorURL, vs.Product.VendorURL},
+		{"version", c.ExportVersion, vs.Product.Version},
+		{"description", c.ExportDescription, vs.Annotation},
+		{"EULA", c.ExportEULA, vs.License},
+	} {
+		if field.want != "" && strings.TrimSpace(field.want) != strings.TrimSpace(field.got) {
+			missing = append(missing, fmt.Sprintf("%s %q", field.name, field.want))
+		}
+	}
+	if c.ExportEULAFile != "" && strings.TrimSpace(vs.License) == "" {
+		missing = append(missing, "EULA")
+	}
+
+	for _, want := range c.ExportProperties {
+		found := false
+		for _, got := range vs.Product.Properties {
+			if got.Key == want.Key && got.Type == want.Type && got.Value == want.Value &&
+				got.UserConfigurable == want.UserConfigurable {
+				found = true
+			}
+		}
+		if !found {
+			missing = append(missing, fmt.Sprintf("property %s", want.Key))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("the OVF descriptor is missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// The formats exported by converting the disks of the VM.
+var diskImageFormats = []string{"raw", "vmdk", "vhd", "vdi"}
+
+func isDiskImageFormat(format string) bool {
+	return contains(diskImageFormats, format)
+}
+
+// exportOrder returns the formats in the order to export them: the OVF
+// first if the OVA is packed from it, and the cloud last so that it can
+// reuse the OVA.
+func exportOrder(formats []string, packOVA bool) []string {
+	var order []string
+	if packOVA {
+		order = append(order, "ovf")
+	}
+	for _, format := range formats {
+		if format != "cloud" && !(packOVA && format == "ovf") {
+			order = append(order, format)
+		}
+	}
+	if contains(formats, "cloud") {
+		order = append(order, "cloud")
+	}
+	return order
+}
+
+// vmDisks returns the hard disks attached to a VM, the boot disk first:
+// the disks of the bootable controllers come first, then the controllers
+// follow the order of the VM, and the disks of a controller the order of
+// their port and device.
+func vmDisks(driver Driver, vmName string) ([]listedMedium, error) {
+	output, err := driver.VBoxManageWithOutput("list", "hdds")
+	if err != nil {
+		return nil, err
+	}
+	hdds := make(map[string]listedMedium)
+	for _, medium := range parseMediaList(output) {
+		hdds[medium.UUID] = medium
+	}
+
+	output, err = driver.VBoxManageWithOutput("showvminfo", vmName, "--machinereadable")
+	if err != nil {
+		return nil, err
+	}
+	info := ParseVMInfo(output)
+
+	// The controllers are listed as storagecontrollername0, 1...
+	controllers := make(map[string]int)
+	bootable := make(map[string]bool)
+	for i := 0; ; i++ {
+		name, ok := info[fmt.Sprintf("storagecontrollername%d", i)]
+		if !ok {
+			break
+		}
+		controllers[name] = i
+		bootable[name] = info[fmt.Sprintf("storagecontrollerbootable%d", i)] != "off"
+	}
+
+	var attachments []diskAttachment
+	for key, uuid := range info {
+		if _, ok := hdds[uuid]; !ok {
+			continue
+		}
+		if a, ok := parseDiskAttachment(key); ok {
+			a.uuid = uuid
+			attachments = append(attachments, a)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool {
+		a, b := attachments[i], attachments[j]
+		switch {
+		case bootable[a.controller] != bootable[b.controller]:
+			return bootable[a.controller]
+		case a.controller != b.controller:
+			ia, okA := controllers[a.controller]
+			ib, okB := controllers[b.controller]
+			if okA && okB {
+				return ia < ib
+			}
+			return a.controller < b.controller
+		case a.port != b.port:
+			return a.port < b.port
+		}
+		return a.device < b.device
+	})
+
+	var disks []listedMedium
+	for _, a := range attachments {
+		disks = append(disks, hdds[a.uuid])
+	}
+	return disks, nil
+}
+
+// diskAttachment is a medium attached to a storage controller of a VM.
+type diskAttachment struct {
+	controller   string
+	port, device int
+	uuid         string
+}
+
+// parseDiskAttachment parses a showvminfo key like
+// "SATA Controller-ImageUUID-0-0". The name of the controller may hold
+// dashes too.
+func parseDiskAttachment(key string) (diskAttachment, bool) {
+	parts := strings.Split(key, "-")
+	n := len(parts)
+	if n < 4 || parts[n-3] != "ImageUUID" {
+		return diskAttachment{}, false
+	}
+	port, err := strconv.Atoi(parts[n-2])
+	if err != nil {
+		return diskAttachment{}, false
+	}
+	device, err := strconv.Atoi(parts[n-1])
+	if err != nil {
+		return diskAttachment{}, false
+	}
+	return diskAttachment{
+		controller: strings.Join(parts[:n-3], "-"),
+		port:       port,
+		device:     device,
+	}, true
+}
+
+// listDir returns the names of the files in dir.
+func listDir(dir string) map[string]bool {
+	names := make(map[string]bool)
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		names[e.Name()] = true
+	}
+	return names
+}
+
+// newFiles returns the paths of the files of dir not in before, sorted.
+func newFiles(dir string, before map[string]bool) []string {
+	var files []string
+	for name := range listDir(dir) {
+		if !before[name] {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	versionUtil "github.com/hashicorp/go-version"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// The first VirtualBox version able to export to Oracle Cloud.
+const cloudExportMinVersion = "6.0"
+
+// VBoxManage prints the OCID of the image it created, among other things.
+var cloudImageIDPattern = regexp.MustCompile(`ocid1\.image\.[A-Za-z0-9._-]+`)
+
+// exportCloud exports the VM to Oracle Cloud, through VBoxManage or the
+// endpoint standing in for it. ova is the OVA export of the build, if any,
+// which the endpoint gets instead of a new one.
+func (s *StepExport) exportCloud(ctx context.Context, driver Driver, ui packersdk.Ui, vmName string, ova ExportOutput) (ExportOutput, error) {
+	displayName := s.Cloud.DisplayName
+	if displayName == "" {
+		displayName = s.OutputFilename
+	}
+
+	var imageID string
+	var err error
+	if s.Cloud.Endpoint != "" {
+		imageID, err = s.exportCloudEndpoint(ctx, driver, ui, vmName, displayName, ova.Path)
+	} else {
+		imageID, err = s.exportCloudVBoxManage(driver, ui, vmName, displayName)
+	}
+	if err != nil {
+		return ExportOutput{}, fmt.Errorf("Error exporting virtual machine to the cloud: %s", err)
+	}
+	if imageID != "" {
+		ui.Message(fmt.Sprintf("Cloud image: %s", imageID))
+	}
+
+	return ExportOutput{
+		Format:  "cloud",
+		ImageID: imageID,
+	}, nil
+}
+
+func (s *StepExport) exportCloudVBoxManage(driver Driver, ui packersdk.Ui, vmName, displayName string) (string, error) {
+	version, err := driver.Version()
+	if err != nil {
+		return "", fmt.Errorf("error getting VirtualBox version: %s", err)
+	}
+	currentVersion, err := versionUtil.NewVersion(version)
+	if err != nil {
+		return "", err
+	}
+	if currentVersion.LessThan(versionUtil.Must(versionUtil.NewVersion(cloudExportMinVersion))) {
+		return "", fmt.Errorf("VirtualBox %s can't export to the cloud, %s or later is needed",
+			version, cloudExportMinVersion)
+	}
+
+	command := append([]string{"export", vmName}, s.Cloud.exportArgs(displayName)...)
+	ui.Say(fmt.Sprintf("Exporting virtual machine to Oracle Cloud bucket %s...", s.Cloud.Bucket))
+	ui.Message(fmt.Sprintf("Executing: %s", strings.Join(command, " ")))
+	output, err := driver.VBoxManageWithOutput(command...)
+	if err != nil {
+		return "", err
+	}
+
+	imageID := cloudImageIDPattern.FindString(output)
+	if imageID == "" {
+		ui.Error("VBoxManage didn't print the ID of the cloud image, CloudImageID will be empty")
+	}
+	return imageID, nil
+}
+
+// exportCloudEndpoint uploads an OVA of the VM to the endpoint and creates
+// the image from it, like VBoxManage does with Oracle Cloud.
+func (s *StepExport) exportCloudEndpoint(ctx context.Context, driver Driver, ui packersdk.Ui, vmName, displayName, ovaPath string) (string, error) {
+	if ovaPath == "" {
+		dir, err := os.MkdirTemp("", "packer-cloud-export")
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(dir)
+
+		ovaPath = filepath.Join(dir, displayName+".ova")
+		ui.Say("Exporting virtual machine for the cloud...")
+		if err := driver.VBoxManageWithProgress(newExportProgress(ui).report, "export", vmName, "--output", ovaPath); err != nil {
+			return "", err
+		}
+	}
+
+	endpoint := strings.TrimSuffix(s.Cloud.Endpoint, "/")
+	object := displayName + ".ova"
+	objectURL := fmt.Sprintf("%s/b/%s/o/%s", endpoint, url.PathEscape(s.Cloud.Bucket), url.PathEscape(object))
+
+	ui.Say(fmt.Sprintf("Uploading %s to %s...", filepath.Base(ovaPath), objectURL))
+	f, err := os.Open(ovaPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, f)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if _, err := cloudEndpointDo(req); err != nil {
+		return "", fmt.Errorf("error uploading %s: %s", object, err)
+	}
+
+	ui.Say(fmt.Sprintf("Creating cloud image %s...", displayName))
+	body, err := json.Marshal(map[string]interface{}{
+		"displayName":    displayName,
+		"bucket":         s.Cloud.Bucket,
+		"object":         object,
+		"shape":          s.Cloud.Shape,
+		"diskSize":       s.Cloud.DiskSize,
+		"launchInstance": s.Cloud.LaunchInstance,
+		"launchMode":     s.Cloud.LaunchMode,
+		"domain":         s.Cloud.Domain,
+		"vcn":            s.Cloud.VCN,
+		"subnet":         s.Cloud.Subnet,
+		"publicIP":       s.Cloud.PublicIP,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/images", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	response, err := cloudEndpointDo(req)
+	if err != nil {
+		return "", fmt.Errorf("error creating the image: %s", err)
+	}
+	var image struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(response, &image); err != nil {
+		return "", fmt.Errorf("error reading the image created: %s", err)
+	}
+	if image.ID == "" {
+		return "", fmt.Errorf("the endpoint didn't return the ID of the image created")
+	}
+
+	if !s.Cloud.KeepObject {
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, objectURL, nil)
+		if err == nil {
+			_, err = cloudEndpointDo(req)
+		}
+		if err != nil {
+			log.Printf("Error deleting %s from bucket %s: %s", object, s.Cloud.Bucket, err)
+		}
+	}
+
+	return image.ID, nil
+}
+
+// cloudEndpointDo sends a request to the cloud endpoint and returns the body
+// of its answer, or an error if it isn't a success.
+func cloudEndpointDo(req *http.Request) ([]byte, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
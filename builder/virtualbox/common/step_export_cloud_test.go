@@ -0,0 +1,152 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepExport_Cloud(t *testing.T) {
+	state := testState(t)
+	step := &StepExport{
+		Formats:        []string{"cloud"},
+		OutputDir:      t.TempDir(),
+		SkipNatMapping: true,
+		Cloud: CloudExportConfig{
+			Profile:    "packer",
+			Shape:      "VM.Standard2.1",
+			Bucket:     "images",
+			LaunchMode: "PARAVIRTUALIZED",
+		},
+	}
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VersionResult = "7.0.14"
+	command := append([]string{"export", "foo"}, step.Cloud.exportArgs("foo")...)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"export foo --output OCI:// --cloud 0 --vmname foo --cloudprofile packer --cloudshape VM.Standard2.1 " +
+			"--cloudbucket images --cloudkeepobject false --cloudlaunchinstance false": "" +
+			"0%...10%...20%...30%...40%...50%...60%...70%...80%...90%...100%\n" +
+			"Successfully exported 1 machine(s).\n" +
+			"Exported image ID: ocid1.image.oc1.iad.aaaaaaaexample\n",
+	}
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	assert.Equal(t, [][]string{command}, driver.VBoxManageCalls)
+	assert.Equal(t, []ExportOutput{{Format: "cloud", ImageID: "ocid1.image.oc1.iad.aaaaaaaexample"}},
+		state.Get("exportOutputs"))
+	assert.Equal(t, "", state.Get("exportPath"))
+	assert.Equal(t, "ocid1.image.oc1.iad.aaaaaaaexample",
+		state.Get("generated_data").(map[string]interface{})["CloudImageID"])
+}
+
+func TestStepExport_CloudOldVirtualBox(t *testing.T) {
+	state := testState(t)
+	step := &StepExport{Format: "cloud", SkipNatMapping: true}
+	state.Put("vmName", "foo")
+	state.Get("driver").(*DriverMock).VersionResult = "5.2.44"
+
+	assert.Equal(t, multistep.ActionHalt, step.Run(context.Background(), state))
+	assert.EqualError(t, state.Get("error").(error),
+		"Error exporting virtual machine to the cloud: VirtualBox 5.2.44 can't export to the cloud, 6.0 or later is needed")
+}
+
+func TestStepExport_CloudEndpoint(t *testing.T) {
+	var uploaded string
+	var image map[string]interface{}
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/b/images/o/ubuntu.ova":
+			body, _ := io.ReadAll(r.Body)
+			uploaded = string(body)
+		case r.Method == http.MethodPost && r.URL.Path == "/images":
+			if err := json.NewDecoder(r.Body).Decode(&image); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"id": "ocid1.image.oc1..example", "lifecycleState": "AVAILABLE"}`))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	state := testState(t)
+	dir := t.TempDir()
+	step := &StepExport{
+		Formats:        []string{"ova", "cloud"},
+		OutputDir:      dir,
+		SkipNatMapping: true,
+		Cloud: CloudExportConfig{
+			Shape:       "VM.Standard2.1",
+			Bucket:      "images",
+			DisplayName: "ubuntu",
+			LaunchMode:  "PARAVIRTUALIZED",
+			Endpoint:    server.URL + "/",
+		},
+	}
+	state.Put("vmName", "foo")
+
+	// What VBoxManage export would write
+	ovaPath := filepath.Join(dir, "foo.ova")
+	assert.NoError(t, os.WriteFile(ovaPath, []byte("appliance"), 0644))
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	// The OVA of the build is uploaded, no other export is made
+	driver := state.Get("driver").(*DriverMock)
+	assert.Equal(t, [][]string{{"export", "foo", "--output", ovaPath}}, driver.VBoxManageCalls)
+	assert.Equal(t, "appliance", uploaded)
+	assert.Equal(t, "ubuntu", image["displayName"])
+	assert.Equal(t, "ubuntu.ova", image["object"])
+	assert.Equal(t, "VM.Standard2.1", image["shape"])
+	assert.Equal(t, []string{"/b/images/o/ubuntu.ova"}, deleted)
+
+	outputs := state.Get("exportOutputs").([]ExportOutput)
+	assert.Equal(t, ExportOutput{Format: "cloud", ImageID: "ocid1.image.oc1..example"}, outputs[1])
+	assert.Equal(t, ovaPath, state.Get("exportPath"))
+	assert.Equal(t, "ocid1.image.oc1..example",
+		state.Get("generated_data").(map[string]interface{})["CloudImageID"])
+}
+
+func TestStepExport_CloudEndpointError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bucket images not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	state := testState(t)
+	dir := t.TempDir()
+	step := &StepExport{
+		Formats:        []string{"ova", "cloud"},
+		OutputDir:      dir,
+		SkipNatMapping: true,
+		Cloud:          CloudExportConfig{Shape: "VM.Standard2.1", Bucket: "images", Endpoint: server.URL},
+	}
+	state.Put("vmName", "foo")
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "foo.ova"), []byte("appliance"), 0644))
+
+	assert.Equal(t, multistep.ActionHalt, step.Run(context.Background(), state))
+	assert.ErrorContains(t, state.Get("error").(error), "error uploading foo.ova")
+	assert.ErrorContains(t, state.Get("error").(error), "404 Not Found: bucket images not found")
+}
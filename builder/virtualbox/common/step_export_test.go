@@ -4,11 +4,25 @@
 package common
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestStepExport_impl(t *testing.T) {
@@ -115,3 +129,547 @@ func TestStepExport_SkipExport(t *testing.T) {
 	}
 
 }
+
+func TestStepExport_AllNewMACAddresses(t *testing.T) {
+	state := testState(t)
+	step := &StepExport{
+		Format:           "ova",
+		SkipNatMapping:   true,
+		MACAddressPolicy: ExportMACAddressPolicyAllNew,
+	}
+
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResult = "macaddress1=\"080027AB12CD\"\n"
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	if len(driver.VBoxManageCalls) != 4 {
+		t.Fatalf("should call vboxmanage four times: %#v", driver.VBoxManageCalls)
+	}
+	if driver.VBoxManageCalls[1][0] != "modifyvm" || driver.VBoxManageCalls[1][3] != "auto" {
+		t.Fatalf("should regenerate MAC addresses: %#v", driver.VBoxManageCalls[1])
+	}
+	if driver.VBoxManageCalls[2][0] != "export" {
+		t.Fatal("bad")
+	}
+	// The MAC addresses of the VM are put back once it is exported
+	expected := []string{"modifyvm", "foo", "--macaddress1", "080027AB12CD"}
+	if !reflect.DeepEqual(driver.VBoxManageCalls[3], expected) {
+		t.Fatalf("should restore MAC addresses: %#v", driver.VBoxManageCalls[3])
+	}
+}
+
+func TestStepExport_ExportVMName(t *testing.T) {
+	state := testState(t)
+	step := &StepExport{
+		Format:           "ova",
+		OutputDir:        "out",
+		SkipNatMapping:   true,
+		MACAddressPolicy: ExportMACAddressPolicyAllNew,
+	}
+
+	state.Put("vmName", "foo")
+	state.Put("exportVMName", "foo-export")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	// The clone is exported, under the name of the VM
+	if driver.VBoxManageCalls[0][1] != "foo-export" {
+		t.Fatalf("should regenerate the MAC addresses of the clone: %#v", driver.VBoxManageCalls[0])
+	}
+	expected := []string{"export", "foo-export", "--output", filepath.Join("out", "foo.ova")}
+	if !reflect.DeepEqual(driver.VBoxManageCalls[1], expected) {
+		t.Fatalf("bad export: %#v", driver.VBoxManageCalls[1])
+	}
+	// The clone is deleted afterwards, its MAC addresses aren't restored
+	if len(driver.VBoxManageCalls) != 2 {
+		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+	}
+}
+
+func TestStepExport_Formats(t *testing.T) {
+	state := testState(t)
+	dir := t.TempDir()
+	step := &StepExport{
+		Formats:        []string{"ova", "ovf", "raw"},
+		OutputDir:      dir,
+		SkipNatMapping: true,
+	}
+	state.Put("vmName", "foo")
+
+	// What VBoxManage export would write
+	ovfPath := filepath.Join(dir, "foo.ovf")
+	diskPath := filepath.Join(dir, "foo-disk001.vmdk")
+	descriptor := `<Envelope><References><File ovf:href="foo-disk001.vmdk"/></References></Envelope>`
+	assert.NoError(t, os.WriteFile(ovfPath, []byte(descriptor), 0644))
+	assert.NoError(t, os.WriteFile(diskPath, []byte("disk"), 0644))
+	// And clonemedium
+	rawPath := filepath.Join(dir, "foo.raw")
+	assert.NoError(t, os.WriteFile(rawPath, []byte("raw disk"), 0644))
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list hdds": "UUID:           10000000-0000-0000-0000-000000000001\nLocation:       /vms/foo/foo.vdi\n",
+		"showvminfo foo --machinereadable": `"SATA Controller-0-0"="/vms/foo/foo.vdi"
+"SATA Controller-ImageUUID-0-0"="10000000-0000-0000-0000-000000000001"
+"SATA Controller-1-0"="/isos/guest.iso"
+"SATA Controller-ImageUUID-1-0"="20000000-0000-0000-0000-000000000002"
+`,
+	}
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	// The VM is exported once, the OVA is packed from the OVF
+	assert.Equal(t, [][]string{
+		{"export", "foo", "--output", ovfPath},
+		{"list", "hdds"},
+		{"showvminfo", "foo", "--machinereadable"},
+		{"clonemedium", "disk", "10000000-0000-0000-0000-000000000001", rawPath, "--format", "RAW"},
+		{"closemedium", "disk", rawPath},
+	}, driver.VBoxManageCalls)
+
+	ovaPath := filepath.Join(dir, "foo.ova")
+	files, order := readTestOVA(t, ovaPath)
+	assert.Equal(t, []string{"foo.ovf", "foo-disk001.vmdk"}, order)
+	assert.Equal(t, descriptor, files["foo.ovf"])
+
+	outputs := state.Get("exportOutputs").([]ExportOutput)
+	assert.Equal(t, []string{"ova", "ovf", "raw"}, []string{outputs[0].Format, outputs[1].Format, outputs[2].Format})
+	ova, err := os.ReadFile(ovaPath)
+	assert.NoError(t, err)
+	assert.Equal(t, ExportOutput{Format: "ova", Path: ovaPath, Files: []string{ovaPath},
+		checksums: map[string]string{"foo.ova": sha256Hex(string(ova))}}, outputs[0])
+	assert.Equal(t, ovfPath, outputs[1].Path)
+	assert.Equal(t, map[string]string{"foo.ovf": sha256Hex(descriptor), "foo-disk001.vmdk": sha256Hex("disk")},
+		outputs[1].checksums)
+	assert.Equal(t, ExportOutput{Format: "raw", Path: rawPath, Files: []string{rawPath},
+		checksums: map[string]string{"foo.raw": sha256Hex("raw disk")}}, outputs[2])
+	assert.Equal(t, ovaPath, state.Get("exportPath"))
+}
+
+func TestStepExport_FormatsNoDisks(t *testing.T) {
+	state := testState(t)
+	step := &StepExport{Formats: []string{"vmdk"}, SkipNatMapping: true}
+	state.Put("vmName", "foo")
+
+	assert.Equal(t, multistep.ActionHalt, step.Run(context.Background(), state))
+	assert.EqualError(t, state.Get("error").(error), "Error exporting vmdk disk images: the virtual machine has no disks")
+}
+
+func TestStepExport_DiskOnly(t *testing.T) {
+	state := testState(t)
+	dir := t.TempDir()
+	step := &StepExport{
+		DiskOnly:        true,
+		DiskFormat:      "vmdk",
+		DiskCompression: "gzip",
+		OutputDir:       dir,
+		SkipNatMapping:  true,
+	}
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list hdds": "UUID:           10000000-0000-0000-0000-000000000001\nLocation:       /vms/foo/foo.vdi\n\n" +
+			"UUID:           30000000-0000-0000-0000-000000000003\nLocation:       /vms/foo/data.vdi\n",
+		"showvminfo foo --machinereadable": `"SATA Controller-ImageUUID-0-0"="10000000-0000-0000-0000-000000000001"
+"SATA Controller-ImageUUID-1-0"="30000000-0000-0000-0000-000000000003"
+`,
+	}
+
+	// What VBoxManage clonemedium would write
+	vmdkPath := filepath.Join(dir, "foo.vmdk")
+	assert.NoError(t, os.WriteFile(vmdkPath, []byte("disk"), 0644))
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	// Only the first disk is exported, stream optimized
+	assert.Equal(t, [][]string{
+		{"list", "hdds"},
+		{"showvminfo", "foo", "--machinereadable"},
+		{"clonemedium", "disk", "10000000-0000-0000-0000-000000000001", vmdkPath, "--format", "VMDK", "--variant", "Stream"},
+		{"closemedium", "disk", vmdkPath},
+	}, driver.VBoxManageCalls)
+
+	// The compressed disk is checksummed as it is written
+	gzPath := vmdkPath + ".gz"
+	gz, err := os.ReadFile(gzPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []ExportOutput{{Format: "vmdk", Path: gzPath, Files: []string{gzPath},
+		checksums: map[string]string{"foo.vmdk.gz": sha256Hex(string(gz))}}}, state.Get("exportOutputs"))
+	assert.Equal(t, gzPath, state.Get("exportPath"))
+	assert.NoFileExists(t, vmdkPath)
+
+	f, err := os.Open(gzPath)
+	assert.NoError(t, err)
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	disk, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "disk", string(disk))
+}
+
+func TestStepExport_DiskOnlyChecksums(t *testing.T) {
+	state := testState(t)
+	dir := t.TempDir()
+	step := &StepExport{
+		DiskOnly:       true,
+		DiskFormat:     "raw",
+		OutputDir:      dir,
+		SkipNatMapping: true,
+		Manifest:       ExportManifestConfig{ExportManifest: "sha256"},
+	}
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list hdds":                        "UUID:           10000000-0000-0000-0000-000000000001\nLocation:       /vms/foo/foo.vdi\n",
+		"showvminfo foo --machinereadable": `"SATA Controller-ImageUUID-0-0"="10000000-0000-0000-0000-000000000001"`,
+	}
+	rawPath := filepath.Join(dir, "foo.raw")
+	assert.NoError(t, os.WriteFile(rawPath, []byte("disk"), 0644))
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	// The checksum file is one of the files of the export
+	outputs := state.Get("exportOutputs").([]ExportOutput)
+	assert.Equal(t, []string{rawPath, rawPath + ".sha256"}, outputs[0].Files)
+	checksums, err := os.ReadFile(rawPath + ".sha256")
+	assert.NoError(t, err)
+	assert.Equal(t, sha256Hex("disk")+"  foo.raw\n", string(checksums))
+	assert.Equal(t, map[string]string{"foo.raw": sha256Hex("disk"), "foo.raw.sha256": sha256Hex(string(checksums))},
+		outputs[0].checksums)
+}
+
+func TestVMDisks(t *testing.T) {
+	driver := new(DriverMock)
+	var hdds []string
+	for i := 1; i <= 5; i++ {
+		hdds = append(hdds, fmt.Sprintf("UUID:           %d0000000-0000-0000-0000-000000000000\nLocation:       /vms/foo/%d.vdi\n", i, i))
+	}
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list hdds": strings.Join(hdds, "\n"),
+		// The boot disk is on port 2 of the SATA controller, the IDE
+		// controller can't boot
+		"showvminfo foo --machinereadable": `storagecontrollername0="SATA-1"
+storagecontrollerbootable0="on"
+storagecontrollername1="IDE"
+storagecontrollerbootable1="off"
+storagecontrollername2="SCSI"
+storagecontrollerbootable2="on"
+"IDE-ImageUUID-0-0"="50000000-0000-0000-0000-000000000000"
+"SATA-1-ImageUUID-10-0"="20000000-0000-0000-0000-000000000000"
+"SATA-1-ImageUUID-2-0"="10000000-0000-0000-0000-000000000000"
+"SATA-1-ImageUUID-3-0"="none"
+"SCSI-ImageUUID-0-1"="40000000-0000-0000-0000-000000000000"
+"SCSI-ImageUUID-0-0"="30000000-0000-0000-0000-000000000000"
+`,
+	}
+
+	disks, err := vmDisks(driver, "foo")
+	assert.NoError(t, err)
+	var locations []string
+	for _, disk := range disks {
+		locations = append(locations, disk.Location)
+	}
+	assert.Equal(t, []string{"/vms/foo/1.vdi", "/vms/foo/2.vdi", "/vms/foo/3.vdi", "/vms/foo/4.vdi", "/vms/foo/5.vdi"}, locations)
+}
+
+func TestCompressFile_zstd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.raw")
+	assert.NoError(t, os.WriteFile(path, []byte("disk"), 0644))
+
+	compressedPath, sums, err := compressFile(path, "zstd", "sha256")
+	assert.NoError(t, err)
+	assert.Equal(t, path+".zst", compressedPath)
+	assert.NoFileExists(t, path)
+
+	// The digest is the one of the compressed copy
+	data, err := os.ReadFile(compressedPath)
+	assert.NoError(t, err)
+	sum := sha256.Sum256(data)
+	assert.Equal(t, []string{hex.EncodeToString(sum[:])}, sums)
+	r, err := zstd.NewReader(nil)
+	assert.NoError(t, err)
+	defer r.Close()
+	disk, err := r.DecodeAll(data, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "disk", string(disk))
+}
+
+func TestStepExport_Progress(t *testing.T) {
+	state := testState(t)
+	step := &StepExport{
+		Format:         "ova",
+		OutputDir:      t.TempDir(),
+		SkipNatMapping: true,
+	}
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageProgress = []int{0, 50, 100}
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	out := state.Get("ui").(*packersdk.BasicUi).Writer.(*bytes.Buffer).String()
+	assert.Contains(t, out, "Progress: 50% (about")
+	assert.Contains(t, out, "Progress: 100%")
+	assert.NotContains(t, out, "Progress: 0%")
+}
+
+func TestExportProgress_report(t *testing.T) {
+	out := new(bytes.Buffer)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	p := &exportProgress{
+		ui:    &packersdk.BasicUi{Reader: new(bytes.Buffer), Writer: out},
+		start: start,
+		now:   func() time.Time { return now },
+	}
+
+	p.report(0)
+	now = start.Add(2 * time.Minute)
+	p.report(20)
+	now = start.Add(10 * time.Minute)
+	p.report(100)
+
+	assert.Equal(t, "Progress: 20% (about 8m0s left)\nProgress: 100%\n", out.String())
+}
+
+func TestStepExport_Atomic(t *testing.T) {
+	state := testState(t)
+	dir := t.TempDir()
+	step := &StepExport{
+		Format:         "ova",
+		OutputDir:      dir,
+		SkipNatMapping: true,
+		Atomic:         true,
+	}
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	// VBoxManage writes to the temporary directory
+	output := driver.VBoxManageCalls[0][3]
+	assert.Equal(t, "foo.ova", filepath.Base(output))
+	assert.Equal(t, dir, filepath.Dir(filepath.Dir(output)))
+	assert.True(t, strings.HasPrefix(filepath.Base(filepath.Dir(output)), ".packer-export-"))
+	assert.NoDirExists(t, filepath.Dir(output))
+
+	ovaPath := filepath.Join(dir, "foo.ova")
+	assert.Equal(t, ovaPath, state.Get("exportPath"))
+}
+
+func TestStepExport_moveExports(t *testing.T) {
+	dir := t.TempDir()
+	workDir := filepath.Join(dir, ".packer-export-1")
+	assert.NoError(t, os.Mkdir(workDir, 0755))
+	for _, name := range []string{"foo.ovf", "foo-disk001.vmdk"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(workDir, name), []byte(name), 0644))
+	}
+	step := &StepExport{OutputDir: dir, workDir: workDir}
+
+	outputs := []ExportOutput{{
+		Format: "ovf",
+		Path:   filepath.Join(workDir, "foo.ovf"),
+		Files:  []string{filepath.Join(workDir, "foo-disk001.vmdk"), filepath.Join(workDir, "foo.ovf")},
+	}}
+	assert.NoError(t, step.moveExports(outputs))
+
+	assert.Equal(t, []ExportOutput{{
+		Format: "ovf",
+		Path:   filepath.Join(dir, "foo.ovf"),
+		Files:  []string{filepath.Join(dir, "foo-disk001.vmdk"), filepath.Join(dir, "foo.ovf")},
+	}}, outputs)
+	assert.FileExists(t, filepath.Join(dir, "foo.ovf"))
+	assert.FileExists(t, filepath.Join(dir, "foo-disk001.vmdk"))
+	assert.NoDirExists(t, workDir)
+}
+
+func TestStepExport_RemovePartialExport(t *testing.T) {
+	state := testState(t)
+	dir := t.TempDir()
+	step := &StepExport{
+		Format:         "ova",
+		OutputDir:      dir,
+		SkipNatMapping: true,
+		Atomic:         true,
+	}
+	state.Put("vmName", "foo")
+
+	// Left by an earlier step
+	pcapPath := filepath.Join(dir, "foo-nic1.pcap")
+	assert.NoError(t, os.WriteFile(pcapPath, nil, 0644))
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageErrs = []error{errors.New("VBoxManage error: disk full")}
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.FileExists(t, pcapPath)
+}
+
+const testDescriptor = `<?xml version="1.0"?>
+<Envelope ovf:version="1.0" xml:lang="en-US" xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1">
+  <VirtualSystem ovf:id="packer">
+    <Info>A virtual machine</Info>
+    <ProductSection>
+      <Info>Meta-information about the installed software</Info>
+      <Product>Appliance</Product>
+      <Version>1.2.3</Version>
+    </ProductSection>
+  </VirtualSystem>
+</Envelope>
+`
+
+var testProduct = ExportProductConfig{
+	ExportProduct: "Appliance",
+	ExportVersion: "1.2.3",
+	ExportProperties: []ExportProperty{
+		{Key: "guestinfo.hostname", Type: "string", Value: "a<b", UserConfigurable: true, Label: "Host name"},
+	},
+}
+
+func TestVerifyProductSection(t *testing.T) {
+	err := verifyProductSection([]byte(testDescriptor), &testProduct)
+	assert.EqualError(t, err, "the OVF descriptor is missing property guestinfo.hostname")
+
+	err = verifyProductSection([]byte(testDescriptor), &ExportProductConfig{ExportVendor: "HashiCorp"})
+	assert.EqualError(t, err, `the OVF descriptor is missing vendor "HashiCorp"`)
+}
+
+func TestStepExport_finishAppliance(t *testing.T) {
+	descriptor, err := os.ReadFile(filepath.Join("ovfmodel", "testdata", "virtualbox.ovf"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "foo.ova")
+	disk := strings.Repeat("disk", 1000)
+	// What VBoxManage export --manifest would write
+	writeTestOVA(t, path, map[string]string{
+		"foo.ovf":             string(descriptor),
+		"foo.mf":              "SHA256 (foo.ovf) = " + sha256Hex(string(descriptor)) + "\nSHA256 (packer-disk001.vmdk) = " + sha256Hex(disk) + "\n",
+		"packer-disk001.vmdk": disk,
+	}, []string{"foo.ovf", "foo.mf", "packer-disk001.vmdk"})
+
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepExport{
+		Format:         "ova",
+		OutputDir:      dir,
+		SkipNatMapping: true,
+		Product:        ExportProductConfig{ExportProperties: testProduct.ExportProperties},
+		Transform: OVFTransform{
+			RemoveVirtualBoxMachine: true,
+			VirtualSystemType:       "vmx-13",
+			RemoveDevices:           []string{"serial"},
+			OSTypeID:                96,
+		},
+		Manifest: ExportManifestConfig{ExportManifest: "sha512"},
+	}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	files, order := readTestOVA(t, path)
+	assert.Equal(t, []string{"foo.ovf", "foo.mf", "packer-disk001.vmdk"}, order)
+	ovf := files["foo.ovf"]
+	assert.NotContains(t, ovf, "vbox:Machine")
+	assert.NotContains(t, ovf, "serial0")
+	assert.Contains(t, ovf, "<vssd:VirtualSystemType>vmx-13</vssd:VirtualSystemType>")
+	assert.Contains(t, ovf, `<OperatingSystemSection ovf:id="96">`)
+	assert.Contains(t, ovf, `<Property ovf:key="guestinfo.hostname"`)
+	assert.True(t, strings.HasPrefix(files["foo.mf"], "SHA512 (foo.ovf) = "))
+
+	// The checksums of the OVA are kept for the metadata of the artifact
+	ova, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	outputs := state.Get("exportOutputs").([]ExportOutput)
+	assert.Contains(t, outputs[0].Files, path+".sha512")
+	checksumFile, err := os.ReadFile(path + ".sha512")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo.ova": sha256Hex(string(ova)), "foo.ova.sha512": sha256Hex(string(checksumFile))},
+		outputs[0].checksums)
+}
+
+func TestStepExport_finishApplianceError(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "foo.ovf"), []byte("<Envelope/>"), 0644))
+
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepExport{
+		Format:         "ovf",
+		OutputDir:      dir,
+		SkipNatMapping: true,
+		Transform:      OVFTransform{VirtualSystemType: "vmx-13"},
+	}
+	assert.Equal(t, multistep.ActionHalt, step.Run(context.Background(), state))
+	assert.EqualError(t, state.Get("error").(error),
+		"Error finishing the exported appliance: ovf_transform: no VirtualSystem in the OVF descriptor")
+}
+
+func TestStepExport_FormatsManifest(t *testing.T) {
+	state := testState(t)
+	dir := t.TempDir()
+	step := &StepExport{
+		Formats:        []string{"ovf", "ova"},
+		OutputDir:      dir,
+		SkipNatMapping: true,
+		Manifest:       ExportManifestConfig{ExportManifest: "sha256"},
+	}
+	state.Put("vmName", "foo")
+
+	ovfPath := filepath.Join(dir, "foo.ovf")
+	descriptor := `<Envelope><References><File ovf:href="foo-disk001.vmdk"/></References></Envelope>`
+	assert.NoError(t, os.WriteFile(ovfPath, []byte(descriptor), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "foo-disk001.vmdk"), []byte("disk"), 0644))
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	// The OVA is packed from the finished OVF, with its manifest
+	ovaPath := filepath.Join(dir, "foo.ova")
+	files, order := readTestOVA(t, ovaPath)
+	assert.Equal(t, []string{"foo.ovf", "foo.mf", "foo-disk001.vmdk"}, order)
+	assert.Equal(t, "SHA256 (foo.ovf) = "+sha256Hex(descriptor)+"\nSHA256 (foo-disk001.vmdk) = "+sha256Hex("disk")+"\n", files["foo.mf"])
+
+	ova, err := os.ReadFile(ovaPath)
+	assert.NoError(t, err)
+	checksums, err := os.ReadFile(ovaPath + ".sha256")
+	assert.NoError(t, err)
+	assert.Equal(t, sha256Hex(string(ova))+"  foo.ova\n", string(checksums))
+
+	outputs := state.Get("exportOutputs").([]ExportOutput)
+	assert.Equal(t, []string{ovaPath, ovaPath + ".sha256"}, outputs[1].Files)
+	assert.Equal(t, sha256Hex("disk"), outputs[0].checksums["foo-disk001.vmdk"])
+}
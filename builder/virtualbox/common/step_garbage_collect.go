@@ -0,0 +1,232 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/filelock"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// This step deletes the VMs and media left behind by Packer builds that
+// didn't get to clean up. Failing to do so doesn't fail the build.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+type StepGarbageCollect struct {
+	Enabled   bool
+	OlderThan time.Duration
+	DryRun    bool
+}
+
+func (s *StepGarbageCollect) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Enabled {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	if s.DryRun {
+		ui.Say("Looking for orphaned Packer VMs and media (dry run)...")
+	} else {
+		ui.Say("Deleting orphaned Packer VMs and media...")
+	}
+	now := time.Now()
+	if err := s.collectVMs(driver, ui, now); err != nil {
+		ui.Error(fmt.Sprintf("Error collecting orphaned VMs: %s", err))
+	}
+	if err := s.collectMedia(driver, ui, now); err != nil {
+		ui.Error(fmt.Sprintf("Error collecting orphaned media: %s", err))
+	}
+	return multistep.ActionContinue
+}
+
+func (s *StepGarbageCollect) Cleanup(state multistep.StateBag) {}
+
+func (s *StepGarbageCollect) collectVMs(driver Driver, ui packersdk.Ui, now time.Time) error {
+	output, err := driver.VBoxManageWithOutput("list", "runningvms")
+	if err != nil {
+		return err
+	}
+	running := make(map[string]bool)
+	for _, vm := range parseVMList(output) {
+		running[vm.UUID] = true
+	}
+
+	// The disks, to leave alone the VMs other VMs are linked clones of
+	var media []listedMedium
+	if output, err := driver.VBoxManageWithOutput("list", "hdds"); err == nil {
+		media = parseMediaList(output)
+	} else {
+		log.Printf("Error listing disks: %s", err)
+	}
+
+	output, err = driver.VBoxManageWithOutput("list", "vms")
+	if err != nil {
+		return err
+	}
+	for _, vm := range parseVMList(output) {
+		if !isPackerVMName(vm.Name) || running[vm.UUID] {
+			continue
+		}
+		output, err := driver.VBoxManageWithOutput("getextradata", vm.UUID, createdExtraDataKey)
+		if err != nil {
+			log.Printf("Error reading extra data of VM %s: %s", vm.Name, err)
+			continue
+		}
+		value := parseExtraData(output)
+		if value == "" {
+			// Not created by Packer, or kept on purpose
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Printf("Ignoring VM %s, bad %s %q: %s", vm.Name, createdExtraDataKey, value, err)
+			continue
+		}
+		if now.Sub(created) < s.OlderThan {
+			continue
+		}
+		if hasLinkedClones(media, vm.UUID) {
+			log.Printf("Keeping VM %s, other VMs are linked clones of it", vm.Name)
+			continue
+		}
+
+		if s.DryRun {
+			ui.Message(fmt.Sprintf("Would delete VM %s, created %s", vm.Name, value))
+			continue
+		}
+		unlock, ok := lockVM(vm.Name)
+		if !ok {
+			log.Printf("Keeping VM %s, a build is using it", vm.Name)
+			continue
+		}
+		// Deleting the VM deletes its disks, but leaves its DVD and floppy
+		// images registered
+		var images []string
+		if output, err := driver.VBoxManageWithOutput("showvminfo", vm.UUID, "--machinereadable"); err == nil {
+			for key, uuid := range ParseVMInfo(output) {
+				if strings.Contains(key, "-ImageUUID-") {
+					images = append(images, uuid)
+				}
+			}
+		}
+		ui.Message(fmt.Sprintf("Deleting VM %s, created %s", vm.Name, value))
+		err = driver.Delete(vm.UUID)
+		unlock()
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error deleting VM %s: %s", vm.Name, err))
+			continue
+		}
+		s.closeImages(driver, ui, images)
+	}
+	return nil
+}
+
+// lockVM takes the lock of a VM shared by builds, if it has one, and
+// reports whether no build is using it.
+func lockVM(name string) (func(), bool) {
+	path, err := VMLockPath(name)
+	if err != nil {
+		return func() {}, true
+	}
+	if _, err := os.Stat(path); err != nil {
+		return func() {}, true
+	}
+	lock := filelock.New(path)
+	if locked, err := lock.TryLock(); err != nil || !locked {
+		return nil, false
+	}
+	return func() {
+		if err := lock.Unlock(); err != nil {
+			log.Printf("Error unlocking VM %s: %s", name, err)
+		}
+	}, true
+}
+
+// closeImages unregisters the DVD and floppy images of a deleted VM that
+// no other VM uses, without deleting them as they may be shared.
+func (s *StepGarbageCollect) closeImages(driver Driver, ui packersdk.Ui, uuids []string) {
+	if len(uuids) == 0 {
+		return
+	}
+	for _, kind := range []struct{ list, medium string }{
+		{"dvds", "dvd"},
+		{"floppies", "floppy"},
+	} {
+		output, err := driver.VBoxManageWithOutput("list", kind.list)
+		if err != nil {
+			log.Printf("Error listing %s: %s", kind.list, err)
+			continue
+		}
+		for _, medium := range parseMediaList(output) {
+			if medium.InUse || !contains(uuids, medium.UUID) {
+				continue
+			}
+			ui.Message(fmt.Sprintf("Closing %s %s", kind.medium, medium.Location))
+			if err := driver.VBoxManage("closemedium", kind.medium, medium.UUID); err != nil {
+				ui.Error(fmt.Sprintf("Error closing %s %s: %s", kind.medium, medium.Location, err))
+			}
+		}
+	}
+}
+
+// collectMedia deletes the disk images Packer recorded creating that no VM
+// uses anymore, once they are older than OlderThan.
+func (s *StepGarbageCollect) collectMedia(driver Driver, ui packersdk.Ui, now time.Time) error {
+	recorded, err := recordedMedia()
+	if err != nil {
+		return err
+	}
+	output, err := driver.VBoxManageWithOutput("list", "hdds")
+	if err != nil {
+		return err
+	}
+
+	var forget []string
+	registered := make(map[string]bool)
+	for _, medium := range parseMediaList(output) {
+		registered[medium.Location] = true
+		created, ok := recorded[medium.Location]
+		if !ok || medium.InUse || now.Sub(created) < s.OlderThan {
+			continue
+		}
+
+		command := []string{"closemedium", "disk", medium.UUID}
+		if !medium.Inaccessible {
+			command = append(command, "--delete")
+		}
+		if s.DryRun {
+			ui.Message(fmt.Sprintf("Would delete disk %s, created %s", medium.Location, created.Format(time.RFC3339)))
+			continue
+		}
+		ui.Message(fmt.Sprintf("Deleting disk %s, created %s", medium.Location, created.Format(time.RFC3339)))
+		if err := driver.VBoxManage(command...); err != nil {
+			ui.Error(fmt.Sprintf("Error deleting disk %s: %s", medium.Location, err))
+			continue
+		}
+		forget = append(forget, medium.Location)
+	}
+
+	// The disks deleted along with their VM are gone from the registry
+	for path := range recorded {
+		if _, err := os.Stat(path); os.IsNotExist(err) && !registered[path] {
+			forget = append(forget, path)
+		}
+	}
+	if s.DryRun {
+		return nil
+	}
+	return ForgetMedia(forget...)
+}
@@ -0,0 +1,301 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/filelock"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepGarbageCollect_impl(t *testing.T) {
+	var _ multistep.Step = new(StepGarbageCollect)
+}
+
+func testGarbageCollectDriver(t *testing.T) *DriverMock {
+	t.Setenv("PACKER_CACHE_DIR", t.TempDir())
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old-disk001.vmdk")
+	recent := filepath.Join(dir, "recent-disk001.vmdk")
+	foreign := filepath.Join(dir, "packer-foreign-disk001.vmdk")
+	for _, path := range []string{old, recent, foreign} {
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	if err := os.Chtimes(foreign, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The disks Packer created, the foreign one isn't
+	for path, age := range map[string]time.Duration{
+		old:                               48 * time.Hour,
+		recent:                            time.Hour,
+		"/gone/packer-gone-disk001.vmdk":  48 * time.Hour,
+		"/gone/packer-fresh-disk001.vmdk": time.Hour,
+	} {
+		created := time.Now().Add(-age)
+		if err := updateMediaRecord(func(media map[string]time.Time) { media[path] = created }); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	created := func(age time.Duration) string {
+		return "Value: " + time.Now().Add(-age).UTC().Format(time.RFC3339)
+	}
+	disk := func(uuid, state, location string) string {
+		return "UUID:           " + uuid + "\nParent UUID:    base\nState:          " + state +
+			"\nType:           normal (base)\nLocation:       " + location + "\nStorage format: VMDK\n\n"
+	}
+	driver := new(DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list runningvms": `"packer-running-1" {00000000-0000-0000-0000-000000000002}`,
+		"list vms": `"packer-old-1" {00000000-0000-0000-0000-000000000001}
+"packer-running-1" {00000000-0000-0000-0000-000000000002}
+"packer-recent-1" {00000000-0000-0000-0000-000000000003}
+"packer-kept-1" {00000000-0000-0000-0000-000000000004}
+"packer_temp_vm_1" {00000000-0000-0000-0000-000000000005}
+"mine" {00000000-0000-0000-0000-000000000006}`,
+		"getextradata 00000000-0000-0000-0000-000000000001 Packer/Created": created(48 * time.Hour),
+		"getextradata 00000000-0000-0000-0000-000000000003 Packer/Created": created(time.Hour),
+		"getextradata 00000000-0000-0000-0000-000000000004 Packer/Created": "No value set!",
+		"getextradata 00000000-0000-0000-0000-000000000005 Packer/Created": created(48 * time.Hour),
+		"showvminfo 00000000-0000-0000-0000-000000000001 --machinereadable": `"IDE Controller-ImageUUID-1-0"="20000000-0000-0000-0000-000000000001"
+"IDE Controller-ImageUUID-1-1"="20000000-0000-0000-0000-000000000002"`,
+		"list hdds": disk("10000000-0000-0000-0000-000000000001", "created", old) +
+			disk("10000000-0000-0000-0000-000000000002", "created", recent) +
+			disk("10000000-0000-0000-0000-000000000003", "inaccessible", "/gone/packer-gone-disk001.vmdk") +
+			disk("10000000-0000-0000-0000-000000000004", "inaccessible", "/gone/packer-fresh-disk001.vmdk") +
+			disk("10000000-0000-0000-0000-000000000005", "created", foreign),
+		"list dvds": `UUID:           20000000-0000-0000-0000-000000000001
+State:          created
+Location:       /tmp/packer123.iso
+
+UUID:           20000000-0000-0000-0000-000000000002
+State:          created
+Location:       /isos/VBoxGuestAdditions.iso
+In use by VMs:  mine (UUID: 00000000-0000-0000-0000-000000000006)
+
+UUID:           20000000-0000-0000-0000-000000000003
+State:          created
+Location:       /tmp/packer456.iso
+`,
+		"list floppies": "",
+	}
+	return driver
+}
+
+func TestStepGarbageCollect(t *testing.T) {
+	state := testState(t)
+	driver := testGarbageCollectDriver(t)
+	state.Put("driver", driver)
+	step := &StepGarbageCollect{Enabled: true, OlderThan: 24 * time.Hour}
+
+	assert.Equal(t, multistep.ActionContinue, step.Run(context.Background(), state))
+
+	// packer-old-1 and then the old probe VM are deleted, the running,
+	// recent, kept and foreign VMs are left alone
+	assert.True(t, driver.DeleteCalled)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000005", driver.DeleteName)
+	assert.NotContains(t, driver.VBoxManageCalls, []string{"getextradata", "00000000-0000-0000-0000-000000000002", "Packer/Created"})
+	assert.NotContains(t, driver.VBoxManageCalls, []string{"getextradata", "00000000-0000-0000-0000-000000000006", "Packer/Created"})
+
+	// Only the unused DVD of the deleted VM is closed
+	assert.Contains(t, driver.VBoxManageCalls, []string{"closemedium", "dvd", "20000000-0000-0000-0000-000000000001"})
+	assert.NotContains(t, driver.VBoxManageCalls, []string{"closemedium", "dvd", "20000000-0000-0000-0000-000000000002"})
+	assert.NotContains(t, driver.VBoxManageCalls, []string{"closemedium", "dvd", "20000000-0000-0000-0000-000000000003"})
+
+	// Only the old disks Packer recorded are collected
+	assert.Contains(t, driver.VBoxManageCalls, []string{"closemedium", "disk", "10000000-0000-0000-0000-000000000001", "--delete"})
+	assert.Contains(t, driver.VBoxManageCalls, []string{"closemedium", "disk", "10000000-0000-0000-0000-000000000003"})
+	for _, uuid := range []string{"10000000-0000-0000-0000-000000000002", "10000000-0000-0000-0000-000000000004", "10000000-0000-0000-0000-000000000005"} {
+		for _, call := range driver.VBoxManageCalls {
+			assert.False(t, call[0] == "closemedium" && call[2] == uuid, "should not close disk %s", uuid)
+		}
+	}
+
+	media, err := recordedMedia()
+	assert.NoError(t, err)
+	assert.Len(t, media, 2)
+}
+
+func TestStepGarbageCollect_dryRun(t *testing.T) {
+	state := testState(t)
+	driver := testGarbageCollectDriver(t)
+	state.Put("driver", driver)
+	step := &StepGarbageCollect{Enabled: true, OlderThan: 24 * time.Hour, DryRun: true}
+
+	assert.Equal(t, multistep.ActionContinue, step.Run(context.Background(), state))
+	assert.False(t, driver.DeleteCalled)
+	for _, call := range driver.VBoxManageCalls {
+		assert.NotEqual(t, "closemedium", call[0])
+	}
+}
+
+func TestParseMediaList(t *testing.T) {
+	media := parseMediaList(`UUID:           10000000-0000-0000-0000-000000000001
+State:          created
+Type:           normal (base)
+Location:       C:\Users\packer\packer-disk001.vmdk
+Storage format: VMDK
+Parent UUID:    base
+Capacity:       40960 MBytes
+In use by VMs:  mine (UUID: 00000000-0000-0000-0000-000000000006)
+                other (UUID: 00000000-0000-0000-0000-000000000007)
+
+UUID:           10000000-0000-0000-0000-000000000002
+State:          inaccessible
+Location:       /tmp/packer.iso
+`)
+	assert.Equal(t, []listedMedium{
+		{UUID: "10000000-0000-0000-0000-000000000001", Location: `C:\Users\packer\packer-disk001.vmdk`, InUse: true,
+			Capacity: 40 << 30, Parent: "base",
+			UsedBy: []string{"00000000-0000-0000-0000-000000000006", "00000000-0000-0000-0000-000000000007"}},
+		{UUID: "10000000-0000-0000-0000-000000000002", Location: "/tmp/packer.iso", Inaccessible: true},
+	}, media)
+}
+
+func TestReplaceKeptVM(t *testing.T) {
+	newDriver := func() *DriverMock {
+		driver := new(DriverMock)
+		driver.VBoxManageWithOutputResults = map[string]string{
+			"list vms": `"packer-kept" {00000000-0000-0000-0000-000000000001}
+"mine" {00000000-0000-0000-0000-000000000002}
+"packer-building" {00000000-0000-0000-0000-000000000003}`,
+			"getextradata 00000000-0000-0000-0000-000000000001 Packer/Kept":    "Value: 2024-01-01T00:00:00Z",
+			"getextradata 00000000-0000-0000-0000-000000000001 Packer/Created": "No value set!",
+			"getextradata 00000000-0000-0000-0000-000000000002 Packer/Kept":    "No value set!",
+			"getextradata 00000000-0000-0000-0000-000000000002 Packer/Created": "No value set!",
+			"getextradata 00000000-0000-0000-0000-000000000003 Packer/Kept":    "No value set!",
+			"getextradata 00000000-0000-0000-0000-000000000003 Packer/Created": "Value: 2024-01-01T00:00:00Z",
+		}
+		return driver
+	}
+
+	driver := newDriver()
+	replaced, err := ReplaceKeptVM(driver, "packer-kept")
+	assert.NoError(t, err)
+	assert.True(t, replaced)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000001", driver.DeleteName)
+
+	driver = newDriver()
+	replaced, err = ReplaceKeptVM(driver, "packer-new")
+	assert.NoError(t, err)
+	assert.False(t, replaced)
+	assert.False(t, driver.DeleteCalled)
+
+	// A VM Packer didn't create is never deleted
+	driver = newDriver()
+	_, err = ReplaceKeptVM(driver, "mine")
+	assert.Error(t, err)
+	assert.False(t, driver.DeleteCalled)
+
+	// Nor is one another build is creating, which isn't running yet
+	driver = newDriver()
+	_, err = ReplaceKeptVM(driver, "packer-building")
+	assert.Error(t, err)
+	assert.False(t, driver.DeleteCalled)
+
+	driver = newDriver()
+	driver.IsRunningReturn = true
+	_, err = ReplaceKeptVM(driver, "packer-kept")
+	assert.Error(t, err)
+	assert.False(t, driver.DeleteCalled)
+}
+
+func TestRecordMedium(t *testing.T) {
+	t.Setenv("PACKER_CACHE_DIR", t.TempDir())
+	path := filepath.Join(t.TempDir(), "packer-ubuntu.vdi")
+
+	assert.NoError(t, RecordMedium(path))
+	media, err := recordedMedia()
+	assert.NoError(t, err)
+	assert.Contains(t, media, path)
+
+	assert.NoError(t, ForgetMedia(path))
+	media, err = recordedMedia()
+	assert.NoError(t, err)
+	assert.Empty(t, media)
+}
+
+// testSharedBaseDriver returns a driver listing an old shared base VM, and
+// the disks of a linked clone of it if linked.
+func testSharedBaseDriver(linked bool) *DriverMock {
+	hdds := `UUID:           10000000-0000-0000-0000-000000000001
+Parent UUID:    base
+State:          created
+Location:       /vms/packer-base-1/packer-base-1-disk001.vmdk
+In use by VMs:  packer-base-1 (UUID: 00000000-0000-0000-0000-000000000001) [base (UUID: 30000000-0000-0000-0000-000000000001)]
+`
+	if linked {
+		hdds += `
+UUID:           10000000-0000-0000-0000-000000000002
+Parent UUID:    10000000-0000-0000-0000-000000000001
+State:          created
+Location:       /vms/mine/Snapshots/{10000000-0000-0000-0000-000000000002}.vmdk
+In use by VMs:  mine (UUID: 00000000-0000-0000-0000-000000000006)
+`
+	}
+	driver := new(DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list runningvms": "",
+		"list vms": `"packer-base-1" {00000000-0000-0000-0000-000000000001}
+"mine" {00000000-0000-0000-0000-000000000006}`,
+		"getextradata 00000000-0000-0000-0000-000000000001 Packer/Created": "Value: " + time.Now().Add(-48*time.Hour).UTC().Format(time.RFC3339),
+		"list hdds":     hdds,
+		"list dvds":     "",
+		"list floppies": "",
+	}
+	return driver
+}
+
+func TestStepGarbageCollect_sharedBase(t *testing.T) {
+	state := testState(t)
+	t.Setenv("PACKER_CACHE_DIR", t.TempDir())
+	driver := testSharedBaseDriver(false)
+	state.Put("driver", driver)
+	step := &StepGarbageCollect{Enabled: true, OlderThan: 24 * time.Hour}
+
+	// A base no VM is a linked clone of anymore is collected
+	assert.Equal(t, multistep.ActionContinue, step.Run(context.Background(), state))
+	assert.True(t, driver.DeleteCalled)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000001", driver.DeleteName)
+}
+
+func TestStepGarbageCollect_linkedClones(t *testing.T) {
+	state := testState(t)
+	t.Setenv("PACKER_CACHE_DIR", t.TempDir())
+	driver := testSharedBaseDriver(true)
+	state.Put("driver", driver)
+	step := &StepGarbageCollect{Enabled: true, OlderThan: 24 * time.Hour}
+
+	assert.Equal(t, multistep.ActionContinue, step.Run(context.Background(), state))
+	assert.False(t, driver.DeleteCalled)
+}
+
+func TestStepGarbageCollect_locked(t *testing.T) {
+	state := testState(t)
+	t.Setenv("PACKER_CACHE_DIR", t.TempDir())
+	driver := testSharedBaseDriver(false)
+	state.Put("driver", driver)
+	step := &StepGarbageCollect{Enabled: true, OlderThan: 24 * time.Hour}
+
+	// A build is cloning the base
+	path, err := VMLockPath("packer-base-1")
+	assert.NoError(t, err)
+	lock := filelock.New(path)
+	locked, err := lock.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	defer lock.Unlock()
+
+	assert.Equal(t, multistep.ActionContinue, step.Run(context.Background(), state))
+	assert.False(t, driver.DeleteCalled)
+}
@@ -0,0 +1,123 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packernet "github.com/hashicorp/packer-plugin-sdk/net"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+)
+
+// This step works out the proxy settings handed to the guest and, if asked
+// to, starts a local proxy the guest reaches through the NAT gateway.
+//
+// Uses:
+//
+//	driver Driver
+//	http_ip string
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	proxy_url string - The proxy the guest should use, if any.
+//	no_proxy string - The hosts the guest should reach directly.
+type StepGuestProxy struct {
+	URL      string
+	NoProxy  []string
+	Local    bool
+	PortMin  int
+	PortMax  int
+	IPFamily string
+	// The VM is restored from a saved state, which keeps the NAT settings
+	// it was saved with.
+	Saved bool
+
+	l      *packernet.Listener
+	server *http.Server
+}
+
+func (s *StepGuestProxy) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+	httpIP := state.Get("http_ip").(string)
+
+	proxyURL := s.URL
+	if s.Local {
+		var upstream *url.URL
+		if s.URL != "" {
+			var err error
+			upstream, err = url.Parse(s.URL)
+			if err != nil {
+				err := fmt.Errorf("Error parsing guest proxy URL: %s", err)
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+		}
+
+		// The proxy only listens on the host loopback address, which the
+		// guest reaches through the NAT gateway.
+		if !s.Saved {
+			if err := addAccessToLocalhost(state); err != nil {
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+		}
+
+		log.Printf("Looking for available guest proxy port between %d and %d", s.PortMin, s.PortMax)
+		var err error
+		s.l, err = packernet.ListenRangeConfig{
+			Addr:    LoopbackAddress(s.IPFamily),
+			Min:     s.PortMin,
+			Max:     s.PortMax,
+			Network: "tcp",
+		}.Listen(ctx)
+		if err != nil {
+			err := fmt.Errorf("Error starting guest proxy: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+
+		ui.Say(fmt.Sprintf("Starting guest proxy on port %d", s.l.Port))
+		s.server = &http.Server{Handler: newGuestProxy(upstream)}
+		go s.server.Serve(s.l)
+
+		proxyURL = "http://" + net.JoinHostPort(httpIP, strconv.Itoa(s.l.Port))
+	}
+
+	noProxy := s.NoProxy
+	if proxyURL != "" {
+		noProxy = append([]string{httpIP}, noProxy...)
+	}
+
+	state.Put("proxy_url", proxyURL)
+	state.Put("no_proxy", strings.Join(noProxy, ","))
+
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
+	generatedData.Put("ProxyURL", proxyURL)
+	generatedData.Put("NoProxy", strings.Join(noProxy, ","))
+
+	return multistep.ActionContinue
+}
+
+func (s *StepGuestProxy) Cleanup(state multistep.StateBag) {
+	if s.l != nil {
+		s.l.Close()
+	}
+	if s.server != nil {
+		s.server.Close()
+	}
+}
@@ -5,17 +5,43 @@ package common
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 )
 
+// The address of the host as seen from a guest on the default
+// VirtualBox NAT network.
+const natGatewayIPv4 = "10.0.2.2"
+
 // Step to discover the http ip
 // which guests use to reach the vm host
 // To make sure the IP is set before boot command and http server steps
-type StepHTTPIPDiscover struct{}
+//
+// With IPv6, the guest is on a NAT Network and reaches the host loopback
+// address at the second address of the IPv6 prefix of the network.
+type StepHTTPIPDiscover struct {
+	IPFamily   string
+	NATNetwork string
+}
 
 func (s *StepHTTPIPDiscover) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	state.Put("http_ip", "10.0.2.2")
+	if s.IPFamily != IPFamilyIPv6 {
+		state.Put("http_ip", natGatewayIPv4)
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	prefix, err := natNetworkIPv6Prefix(driver, s.NATNetwork)
+	if err != nil {
+		err := fmt.Errorf("Error discovering the HTTP IP: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	state.Put("http_ip", natNetworkHostIPv6(prefix).String())
 
 	return multistep.ActionContinue
 }
@@ -27,3 +27,29 @@ func TestStepHTTPIPDiscover_Run(t *testing.T) {
 		t.Fatalf("bad: Http ip is %s but was supposed to be %s", httpIp, hostIp)
 	}
 }
+
+func TestStepHTTPIPDiscover_RunIPv6(t *testing.T) {
+	state := testState(t)
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResult = testNATNetworkList
+	step := &StepHTTPIPDiscover{IPFamily: IPFamilyIPv6, NATNetwork: "packer6"}
+	hostIp := "fd17:625c:f037:3::2"
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+	httpIp := state.Get("http_ip").(string)
+	if httpIp != hostIp {
+		t.Fatalf("bad: Http ip is %s but was supposed to be %s", httpIp, hostIp)
+	}
+
+	// Without IPv6 on the network
+	state = testState(t)
+	driver = state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResult = testNATNetworkList
+	step = &StepHTTPIPDiscover{IPFamily: IPFamilyIPv6, NATNetwork: "NatNetwork"}
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+)
+
+const (
+	// The guest property a provisioner sets to report a milestone.
+	milestoneProperty = "/Packer/Milestone"
+	// The guest property set once the snapshot of a milestone is taken.
+	milestoneDoneProperty = "/Packer/MilestoneDone"
+)
+
+// This step takes a live snapshot of the running VM whenever the guest
+// reports one of the given milestones, for the duration of provisioning.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+type StepLiveSnapshots struct {
+	Milestones []string
+	Prefix     string
+	Keep       bool
+	// The milestones passed before the build was resumed.
+	Completed []string
+
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+
+	l        sync.Mutex
+	done     map[string]bool // the milestones reported
+	repeated []string        // completed milestones reported again
+	taken    []string        // UUIDs of the snapshots taken
+}
+
+func (s *StepLiveSnapshots) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
+	generatedData.Put("CompletedMilestones", strings.Join(s.Completed, ","))
+
+	if len(s.Milestones) == 0 {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	if s.interval == 0 {
+		s.interval = 2 * time.Second
+	}
+
+	s.done = make(map[string]bool)
+
+	// Guest properties outlive the build, forget the milestones of
+	// earlier ones.
+	for _, property := range []string{milestoneProperty, milestoneDoneProperty} {
+		if err := driver.VBoxManage("guestproperty", "delete", vmName, property); err != nil {
+			err := fmt.Errorf("Error clearing guest property %s: %s", property, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	ui.Say(fmt.Sprintf("Watching for provisioning milestones: %s", strings.Join(s.Milestones, ", ")))
+	s.stop = make(chan struct{})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-time.After(s.interval):
+			}
+
+			s.poll(driver, ui, vmName)
+		}
+	}()
+
+	return multistep.ActionContinue
+}
+
+// poll takes the snapshot of the milestone the guest reports, if any.
+func (s *StepLiveSnapshots) poll(driver Driver, ui packersdk.Ui, vmName string) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	output, err := driver.VBoxManageWithOutput("guestproperty", "get", vmName, milestoneProperty)
+	if err != nil {
+		log.Printf("Error reading guest property %s: %s", milestoneProperty, err)
+		return
+	}
+	milestone := parseGuestProperty(output)
+	if milestone == "" || s.done[milestone] {
+		return
+	}
+	s.done[milestone] = true
+	if s.completed(milestone) {
+		// The milestone was passed before the build was resumed, the
+		// provisioner reporting it runs again what the snapshot has done.
+		ui.Error(fmt.Sprintf("Provisioning milestone %q was passed before the build was resumed", milestone))
+		s.repeated = append(s.repeated, milestone)
+		s.milestoneDone(driver, vmName, milestone)
+		return
+	}
+	if !s.known(milestone) {
+		ui.Error(fmt.Sprintf("Ignoring unknown provisioning milestone %q", milestone))
+		s.milestoneDone(driver, vmName, milestone)
+		return
+	}
+
+	name := s.Prefix + milestone
+	ui.Say(fmt.Sprintf("Taking live snapshot %s", name))
+	description := fmt.Sprintf("Provisioning milestone %s", milestone)
+	if err := driver.CreateLiveSnapshot(vmName, name, description); err != nil {
+		ui.Error(fmt.Sprintf("Error taking live snapshot %s: %s", name, err))
+	} else if snapshotTree, err := driver.LoadSnapshots(vmName); err == nil && snapshotTree != nil {
+		// The snapshot just taken is the current one, remember it
+		// rather than its name, which earlier builds may have used.
+		if current := snapshotTree.GetCurrentSnapshot(); current != nil {
+			s.taken = append(s.taken, current.UUID)
+		}
+	}
+	s.milestoneDone(driver, vmName, milestone)
+}
+
+// Check returns an error if the provisioners of a resumed build reported a
+// milestone passed before the build was resumed, or did not reach one of the
+// others. It is called once provisioning is over.
+func (s *StepLiveSnapshots) Check(state multistep.StateBag) error {
+	if s.stop == nil {
+		return nil
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	// The last milestone may have been reported since the guest was polled.
+	s.poll(driver, ui, vmName)
+
+	s.l.Lock()
+	defer s.l.Unlock()
+	if len(s.repeated) > 0 {
+		return fmt.Errorf("The provisioners reported milestones %s again, they must skip what was done before "+
+			"the build was resumed, which build.CompletedMilestones lists", strings.Join(s.repeated, ", "))
+	}
+	var missing []string
+	for _, milestone := range s.Milestones {
+		if !s.done[milestone] && !s.completed(milestone) {
+			missing = append(missing, milestone)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("Provisioning finished without reaching milestones %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (s *StepLiveSnapshots) completed(milestone string) bool {
+	for _, m := range s.Completed {
+		if m == milestone {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *StepLiveSnapshots) known(milestone string) bool {
+	for _, m := range s.Milestones {
+		if m == milestone {
+			return true
+		}
+	}
+	return false
+}
+
+// milestoneDone lets the guest waiting on a milestone carry on.
+func (s *StepLiveSnapshots) milestoneDone(driver Driver, vmName string, milestone string) {
+	if err := driver.VBoxManage("guestproperty", "set", vmName, milestoneDoneProperty, milestone); err != nil {
+		log.Printf("Error setting guest property %s: %s", milestoneDoneProperty, err)
+	}
+}
+
+func (s *StepLiveSnapshots) Cleanup(state multistep.StateBag) {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	s.wg.Wait()
+
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if cancelled || halted || s.Keep || len(s.taken) == 0 {
+		return
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	snapshotTree, err := driver.LoadSnapshots(vmName)
+	if err != nil || snapshotTree == nil {
+		ui.Error(fmt.Sprintf("Error loading snapshots to delete live snapshots: %v", err))
+		return
+	}
+	for _, uuid := range s.taken {
+		snapshot := snapshotTree.GetSnapshotByUUID(uuid)
+		if snapshot == nil {
+			continue
+		}
+		ui.Say(fmt.Sprintf("Deleting live snapshot %s", snapshot.Name))
+		if err := driver.DeleteSnapshot(vmName, snapshot); err != nil {
+			ui.Error(fmt.Sprintf("Error deleting live snapshot %s: %s", snapshot.Name, err))
+		}
+	}
+}
+
+// parseGuestProperty returns the value printed by `VBoxManage guestproperty
+// get`, or an empty string if the property is not set.
+func parseGuestProperty(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "Value: ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Value: "))
+		}
+	}
+	return ""
+}
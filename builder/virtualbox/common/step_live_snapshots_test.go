@@ -0,0 +1,79 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepLiveSnapshots_impl(t *testing.T) {
+	var _ multistep.Step = new(StepLiveSnapshots)
+}
+
+func TestStepLiveSnapshots(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResult = "Value: updates\n"
+	tree, err := ParseSnapshotData(`SnapshotName="packer-milestone-updates"
+SnapshotUUID="7e5b4165-91ec-4091-a74c-a5709d584530"
+CurrentSnapshotUUID="7e5b4165-91ec-4091-a74c-a5709d584530"`)
+	assert.NoError(t, err)
+	driver.LoadSnapshotsResult = tree
+
+	step := &StepLiveSnapshots{
+		Milestones: []string{"updates", "drivers"},
+		Prefix:     "packer-milestone-",
+		interval:   time.Millisecond,
+	}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	time.Sleep(100 * time.Millisecond)
+	err = step.Check(state)
+	step.Cleanup(state)
+
+	// The drivers milestone was never reached
+	assert.EqualError(t, err, "Provisioning finished without reaching milestones drivers")
+
+	// The milestone is only snapshotted once
+	assert.Equal(t, []string{"packer-milestone-updates"}, driver.CreateLiveSnapshotCalled)
+	assert.Equal(t, []string{"guestproperty", "delete", "foo", "/Packer/Milestone"}, driver.VBoxManageCalls[0])
+	assert.Contains(t, driver.VBoxManageCalls, []string{"guestproperty", "set", "foo", "/Packer/MilestoneDone", "updates"})
+
+	// The build succeeded, so the live snapshot is deleted
+	assert.Equal(t, []*VBoxSnapshot{tree}, driver.DeleteSnapshotCalled)
+}
+
+func TestStepLiveSnapshots_resumed(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	state.Put(multistep.StateHalted, true)
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResult = "Value: updates\n"
+
+	step := &StepLiveSnapshots{
+		Milestones: []string{"updates", "drivers"},
+		Prefix:     "packer-milestone-",
+		Completed:  []string{"updates"},
+		interval:   time.Millisecond,
+	}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	time.Sleep(100 * time.Millisecond)
+	err := step.Check(state)
+	step.Cleanup(state)
+
+	// Milestones passed before resuming are not snapshotted again, and
+	// reporting them fails the build
+	assert.Empty(t, driver.CreateLiveSnapshotCalled)
+	assert.ErrorContains(t, err, "reported milestones updates again")
+	assert.Equal(t, "updates", state.Get("generated_data").(map[string]interface{})["CompletedMilestones"])
+}
@@ -0,0 +1,135 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// This step captures the network traffic of the given adapters into pcap
+// files for the duration of the build.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+//
+//	networkTraceAdapters []int - The adapters being traced.
+type StepNetworkTrace struct {
+	Adapters      []int
+	OutputDir     string
+	InArtifact    bool
+	KeepOnFailure bool
+
+	vmName string
+	files  []string
+}
+
+func (s *StepNetworkTrace) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if len(s.Adapters) == 0 {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	if err := os.MkdirAll(s.OutputDir, 0755); err != nil {
+		err := fmt.Errorf("Error creating directory for network traces: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	command := []string{"modifyvm", vmName}
+	var files []string
+	for _, adapter := range s.Adapters {
+		path, err := filepath.Abs(filepath.Join(s.OutputDir, fmt.Sprintf("%s-nic%d.pcap", vmName, adapter)))
+		if err != nil {
+			err := fmt.Errorf("Error building network trace path: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		command = append(command,
+			fmt.Sprintf("--nictrace%d", adapter), "on",
+			fmt.Sprintf("--nictracefile%d", adapter), path)
+		files = append(files, path)
+	}
+
+	ui.Say("Enabling network traffic capture...")
+	if err := driver.VBoxManage(command...); err != nil {
+		err := fmt.Errorf("Error enabling network traffic capture: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	for _, file := range files {
+		ui.Message(fmt.Sprintf("Capturing to %s", file))
+	}
+
+	s.vmName = vmName
+	s.files = files
+	state.Put("networkTraceAdapters", s.Adapters)
+
+	return multistep.ActionContinue
+}
+
+func (s *StepNetworkTrace) Cleanup(state multistep.StateBag) {
+	if s.vmName == "" {
+		return
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	// Make sure a VM that outlives the build stops capturing
+	if err := driver.VBoxManage(disableNetworkTraceCommand(s.vmName, s.Adapters)...); err != nil {
+		log.Printf("Error disabling network traffic capture: %s", err)
+	}
+
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	failed := cancelled || halted
+
+	if !failed && s.InArtifact {
+		return
+	}
+
+	for _, file := range s.files {
+		if failed && s.KeepOnFailure {
+			// The output directory is removed on failure, keep the capture
+			// next to it.
+			kept := filepath.Join(filepath.Dir(filepath.Dir(file)), filepath.Base(file))
+			if err := os.Rename(file, kept); err != nil {
+				ui.Error(fmt.Sprintf("Error keeping network trace %s: %s", file, err))
+				continue
+			}
+			ui.Say(fmt.Sprintf("Keeping network trace: %s", kept))
+			continue
+		}
+
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			log.Printf("Error removing network trace %s: %s", file, err)
+		}
+	}
+}
+
+func disableNetworkTraceCommand(vmName string, adapters []int) []string {
+	command := []string{"modifyvm", vmName}
+	for _, adapter := range adapters {
+		command = append(command, fmt.Sprintf("--nictrace%d", adapter), "off")
+	}
+	return command
+}
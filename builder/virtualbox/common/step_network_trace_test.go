@@ -0,0 +1,83 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepNetworkTrace_impl(t *testing.T) {
+	var _ multistep.Step = new(StepNetworkTrace)
+}
+
+func testStepNetworkTrace(t *testing.T, step *StepNetworkTrace) (multistep.StateBag, string) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+
+	step.Adapters = []int{1}
+	step.OutputDir = filepath.Join(t.TempDir(), "output")
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	// VirtualBox would write the capture while the VM runs
+	trace := filepath.Join(step.OutputDir, "foo-nic1.pcap")
+	if err := os.WriteFile(trace, []byte("pcap"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return state, trace
+}
+
+func TestStepNetworkTrace(t *testing.T) {
+	step := new(StepNetworkTrace)
+	state, trace := testStepNetworkTrace(t, step)
+
+	driver := state.Get("driver").(*DriverMock)
+	abs, _ := filepath.Abs(trace)
+	assert.Equal(t, [][]string{{"modifyvm", "foo", "--nictrace1", "on", "--nictracefile1", abs}}, driver.VBoxManageCalls)
+	assert.Equal(t, []int{1}, state.Get("networkTraceAdapters"))
+
+	step.Cleanup(state)
+	assert.Equal(t, []string{"modifyvm", "foo", "--nictrace1", "off"}, driver.VBoxManageCalls[1])
+	assert.NoFileExists(t, trace)
+}
+
+func TestStepNetworkTrace_InArtifact(t *testing.T) {
+	step := &StepNetworkTrace{InArtifact: true}
+	state, trace := testStepNetworkTrace(t, step)
+
+	step.Cleanup(state)
+	assert.FileExists(t, trace)
+}
+
+func TestStepNetworkTrace_KeepOnFailure(t *testing.T) {
+	step := &StepNetworkTrace{KeepOnFailure: true}
+	state, trace := testStepNetworkTrace(t, step)
+	state.Put(multistep.StateHalted, true)
+
+	step.Cleanup(state)
+	assert.NoFileExists(t, trace)
+	assert.FileExists(t, filepath.Join(filepath.Dir(step.OutputDir), "foo-nic1.pcap"))
+}
+
+func TestStepNetworkTrace_Disabled(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := new(StepNetworkTrace)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	step.Cleanup(state)
+
+	driver := state.Get("driver").(*DriverMock)
+	assert.Empty(t, driver.VBoxManageCalls)
+}
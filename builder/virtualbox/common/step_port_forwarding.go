@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/packer-plugin-sdk/communicator"
@@ -18,7 +19,10 @@ import (
 )
 
 // This step adds a NAT port forwarding definition so that SSH or WinRM is available
-// on the guest machine.
+// on the guest machine. The rule is added to the NAT of the first network
+// adapter. With IPv6, the first network adapter is attached to a NAT Network
+// instead, and the rule is added to the network, for the address the guest
+// configures from its MAC address, until the step is cleaned up.
 //
 // Uses:
 //
@@ -27,13 +31,23 @@ import (
 //	vmName string
 //
 // Produces:
+//
+//	commHostPort int
+//	commNATNetworkRule string - the rule of the NAT Network, with IPv6
 type StepPortForwarding struct {
 	CommConfig     *communicator.Config
 	HostPortMin    int
 	HostPortMax    int
 	SkipNatMapping bool
+	IPFamily       string
+	NATNetwork     string
+	// The VM is restored from a saved state, whose settings can't change:
+	// the host port of the rule it was saved with is reused.
+	Saved bool
 
 	l *net.Listener
+	// The port forwarding rule added to the NAT Network
+	rule string
 }
 
 var vboxVerMinNeedLHReachable string
@@ -91,13 +105,33 @@ func (s *StepPortForwarding) Run(ctx context.Context, state multistep.StateBag)
 
 	guestPort := s.CommConfig.Port()
 	commHostPort := guestPort
-	if !s.SkipNatMapping {
+	if s.IPFamily == IPFamilyIPv6 && !s.SkipNatMapping {
+		port, err := s.forwardNATNetwork(ctx, state, guestPort)
+		if err != nil {
+			err := fmt.Errorf("Error creating port forwarding rule: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		commHostPort = port
+	} else if s.Saved && !s.SkipNatMapping {
+		port, err := s.savedHostPort(ctx, driver, vmName)
+		if err != nil {
+			err := fmt.Errorf("Error reusing the port forwarding rule of the saved VM: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		ui.Say(fmt.Sprintf("Reusing forwarded port mapping for communicator (SSH, WinRM, etc) (host port %d)", port))
+		commHostPort = port
+	} else if !s.SkipNatMapping {
 		log.Printf("Looking for available communicator (SSH, WinRM, etc) port between %d and %d",
 			s.HostPortMin, s.HostPortMax)
 
 		var err error
+		hostIP := LoopbackAddress(s.IPFamily)
 		s.l, err = net.ListenRangeConfig{
-			Addr:    "127.0.0.1",
+			Addr:    hostIP,
 			Min:     s.HostPortMin,
 			Max:     s.HostPortMax,
 			Network: "tcp",
@@ -136,7 +170,7 @@ func (s *StepPortForwarding) Run(ctx context.Context, state multistep.StateBag)
 		command = []string{
 			"modifyvm", vmName,
 			"--natpf1",
-			fmt.Sprintf("packercomm,tcp,127.0.0.1,%d,,%d", commHostPort, guestPort),
+			fmt.Sprintf("packercomm,tcp,%s,%d,,%d", hostIP, commHostPort, guestPort),
 		}
 		retried := false
 	retry:
@@ -170,7 +204,132 @@ func (s *StepPortForwarding) Run(ctx context.Context, state multistep.StateBag)
 	return multistep.ActionContinue
 }
 
+// forwardNATNetwork attaches the first network adapter of the VM to the NAT
+// Network, unless it is saved, and forwards a host port on the IPv6 loopback
+// address to the guest port. VBoxManage has no --natpf6 option: IPv6 ports
+// are only forwarded by NAT Networks, to the address of the guest.
+func (s *StepPortForwarding) forwardNATNetwork(ctx context.Context, state multistep.StateBag, guestPort int) (int, error) {
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	prefix, err := natNetworkIPv6Prefix(driver, s.NATNetwork)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("Looking for available communicator (SSH, WinRM, etc) port between %d and %d",
+		s.HostPortMin, s.HostPortMax)
+	s.l, err = net.ListenRangeConfig{
+		Addr:    LoopbackAddress(s.IPFamily),
+		Min:     s.HostPortMin,
+		Max:     s.HostPortMax,
+		Network: "tcp",
+	}.Listen(ctx)
+	if err != nil {
+		return 0, err
+	}
+	s.l.Listener.Close() // free port, but don't unlock lock file
+
+	// A saved VM is already attached to the network
+	if !s.Saved {
+		command := []string{
+			"modifyvm", vmName,
+			"--nic1", "natnetwork",
+			"--nat-network1", s.NATNetwork,
+		}
+		if err := driver.VBoxManage(command...); err != nil {
+			return 0, fmt.Errorf("failed to attach the VM to NAT Network %q: %s", s.NATNetwork, err)
+		}
+	}
+
+	output, err := driver.VBoxManageWithOutput("showvminfo", vmName, "--machinereadable")
+	if err != nil {
+		return 0, err
+	}
+	guestIP, err := eui64Address(prefix, ParseVMInfo(output)["macaddress1"])
+	if err != nil {
+		return 0, err
+	}
+
+	// The rules of a NAT Network are shared by its VMs
+	rule := "packercomm-" + strings.Map(func(r rune) rune {
+		if strings.ContainsRune(":[],", r) {
+			return '-'
+		}
+		return r
+	}, vmName)
+	ui.Say(fmt.Sprintf("Creating forwarded port mapping for communicator (SSH, WinRM, etc) (host port %d, guest address %s)",
+		s.l.Port, guestIP))
+	command := []string{
+		"natnetwork", "modify",
+		"--netname", s.NATNetwork,
+		"--port-forward-6", fmt.Sprintf("%s:tcp:[::1]:%d:[%s]:%d", rule, s.l.Port, guestIP, guestPort),
+	}
+	if err := driver.VBoxManage(command...); err != nil {
+		if !strings.Contains(err.Error(), "already exists") {
+			return 0, err
+		}
+		log.Printf("A packer NAT Network rule already exists. Trying to delete ...")
+		if err := s.deleteNATNetworkRule(driver, rule); err != nil {
+			return 0, err
+		}
+		if err := driver.VBoxManage(command...); err != nil {
+			return 0, err
+		}
+	}
+	s.rule = rule
+	state.Put("commNATNetworkRule", rule)
+
+	return s.l.Port, nil
+}
+
+func (s *StepPortForwarding) deleteNATNetworkRule(driver Driver, rule string) error {
+	return driver.VBoxManage("natnetwork", "modify", "--netname", s.NATNetwork, "--port-forward-6", "delete", rule)
+}
+
+// savedHostPort returns the host port of the communicator forwarding rule of
+// the VM, once it holds the port.
+func (s *StepPortForwarding) savedHostPort(ctx context.Context, driver Driver, vmName string) (int, error) {
+	output, err := driver.VBoxManageWithOutput("showvminfo", vmName, "--machinereadable")
+	if err != nil {
+		return 0, err
+	}
+	port := 0
+	for key, rule := range ParseVMInfo(output) {
+		// Forwarding(0)="packercomm,tcp,127.0.0.1,2222,,22"
+		fields := strings.Split(rule, ",")
+		if strings.HasPrefix(key, "Forwarding(") && len(fields) == 6 && fields[0] == "packercomm" {
+			port, err = strconv.Atoi(fields[3])
+			if err != nil {
+				return 0, fmt.Errorf("invalid host port %q", fields[3])
+			}
+		}
+	}
+	if port == 0 {
+		return 0, fmt.Errorf("the VM has no packercomm rule")
+	}
+
+	s.l, err = net.ListenRangeConfig{
+		Addr:    LoopbackAddress(s.IPFamily),
+		Min:     port,
+		Max:     port,
+		Network: "tcp",
+	}.Listen(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("host port %d is not available: %s", port, err)
+	}
+	s.l.Listener.Close() // free port, but don't unlock lock file
+	return port, nil
+}
+
 func (s *StepPortForwarding) Cleanup(state multistep.StateBag) {
+	if s.rule != "" {
+		driver := state.Get("driver").(Driver)
+		if err := s.deleteNATNetworkRule(driver, s.rule); err != nil {
+			log.Printf("failed to delete the port forwarding rule of NAT Network %q: %v", s.NATNetwork, err)
+		}
+	}
 	if s.l != nil {
 		err := s.l.Close()
 		if err != nil {
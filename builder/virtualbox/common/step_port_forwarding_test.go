@@ -4,8 +4,14 @@
 package common
 
 import (
+	"context"
+	"fmt"
+	"net"
 	"reflect"
 	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
 )
 
 func TestVirtualboxVersionIsAnInValidSemver(t *testing.T) {
@@ -77,3 +83,83 @@ func TestVirtualboxVersionDoesNotNeedLocalhostAccessFlag(t *testing.T) {
 		t.Fatalf("Expected VBoxManage not to be called, but it was called %v times!", len(driver.VBoxManageCalls))
 	}
 }
+
+func TestStepPortForwarding_saved(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	state := testState(t)
+	state.Put("vmName", "foo")
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"showvminfo foo --machinereadable": fmt.Sprintf(`nic1="nat"
+Forwarding(0)="ssh,tcp,,2200,,22"
+Forwarding(1)="packercomm,tcp,127.0.0.1,%d,,22"`, port),
+	}
+	step := &StepPortForwarding{
+		CommConfig:  &communicator.Config{Type: "ssh"},
+		HostPortMin: 2222,
+		HostPortMax: 4444,
+		Saved:       true,
+	}
+	defer step.Cleanup(state)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+	if hostPort := state.Get("commHostPort"); hostPort != port {
+		t.Fatalf("bad: %#v", hostPort)
+	}
+	// The saved VM isn't modified
+	for _, call := range driver.VBoxManageCalls {
+		if call[0] != "showvminfo" {
+			t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+		}
+	}
+}
+
+func TestStepPortForwarding_natNetwork(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"natnetwork list":                  testNATNetworkList,
+		"showvminfo foo --machinereadable": "nic1=\"natnetwork\"\nmacaddress1=\"080027AB12CD\"\n",
+	}
+	step := &StepPortForwarding{
+		CommConfig:  &communicator.Config{Type: "ssh", SSH: communicator.SSH{SSHPort: 22}},
+		HostPortMin: 2222,
+		HostPortMax: 4444,
+		IPFamily:    IPFamilyIPv6,
+		NATNetwork:  "packer6",
+	}
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+	port := state.Get("commHostPort").(int)
+	expected := [][]string{
+		{"natnetwork", "list"},
+		{"modifyvm", "foo", "--nic1", "natnetwork", "--nat-network1", "packer6"},
+		{"showvminfo", "foo", "--machinereadable"},
+		{"natnetwork", "modify", "--netname", "packer6", "--port-forward-6",
+			fmt.Sprintf("packercomm-foo:tcp:[::1]:%d:[fd17:625c:f037:3:a00:27ff:feab:12cd]:22", port)},
+	}
+	if !reflect.DeepEqual(driver.VBoxManageCalls, expected) {
+		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+	}
+	if rule := state.Get("commNATNetworkRule"); rule != "packercomm-foo" {
+		t.Fatalf("bad: %#v", rule)
+	}
+
+	// The rule of the network is deleted
+	step.Cleanup(state)
+	last := driver.VBoxManageCalls[len(driver.VBoxManageCalls)-1]
+	if !reflect.DeepEqual(last, []string{"natnetwork", "modify", "--netname", "packer6", "--port-forward-6", "delete", "packercomm-foo"}) {
+		t.Fatalf("bad: %#v", last)
+	}
+}
@@ -15,11 +15,13 @@ import (
 )
 
 // This step removes any devices (floppy disks, ISOs, etc.) from the
-// machine that we may have added.
+// machine that we may have added. A machine in saved state cannot have its
+// hardware changed, so its disks are only ejected and the drives are kept.
 //
 // Uses:
 //
 //	driver Driver
+//	saved_state bool
 //	ui packersdk.Ui
 //	vmName string
 //
@@ -32,9 +34,28 @@ func (s *StepRemoveDevices) Run(ctx context.Context, state multistep.StateBag) m
 	driver := state.Get("driver").(Driver)
 	ui := state.Get("ui").(packersdk.Ui)
 	vmName := state.Get("vmName").(string)
+	_, saved := state.GetOk("saved_state")
+
+	// Eject the attached floppy disk of a saved machine, if it exists
+	if _, ok := state.GetOk("floppy_path"); ok && saved {
+		ui.Message("Ejecting floppy disk...")
+		command := []string{
+			"storageattach", vmName,
+			"--storagectl", "Floppy",
+			"--port", "0",
+			"--device", "0",
+			"--medium", "emptydrive",
+		}
+		if err := driver.VBoxManage(command...); err != nil {
+			err := fmt.Errorf("Error ejecting floppy: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
 
 	// Remove the attached floppy disk, if it exists
-	if _, ok := state.GetOk("floppy_path"); ok {
+	if _, ok := state.GetOk("floppy_path"); ok && !saved {
 		ui.Message("Removing floppy drive...")
 		command := []string{
 			"storageattach", vmName,
@@ -90,6 +111,9 @@ func (s *StepRemoveDevices) Run(ctx context.Context, state multistep.StateBag) m
 			// skip the unmount if user wants to bundle the iso
 			continue
 		}
+		if saved {
+			unmountCommand = ejectCommand(unmountCommand)
+		}
 
 		if err := driver.VBoxManage(unmountCommand...); err != nil {
 			err := fmt.Errorf("Error detaching ISO: %s", err)
@@ -108,3 +132,15 @@ func (s *StepRemoveDevices) Run(ctx context.Context, state multistep.StateBag) m
 
 func (s *StepRemoveDevices) Cleanup(state multistep.StateBag) {
 }
+
+// ejectCommand turns a command removing a drive into one leaving it empty.
+func ejectCommand(command []string) []string {
+	result := make([]string, len(command))
+	copy(result, command)
+	for i := 0; i < len(result)-1; i++ {
+		if result[i] == "--medium" && result[i+1] == "none" {
+			result[i+1] = "emptydrive"
+		}
+	}
+	return result
+}
@@ -136,3 +136,46 @@ func TestStepRemoveDevices_floppyPath(t *testing.T) {
 		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
 	}
 }
+
+func TestStepRemoveDevices_savedState(t *testing.T) {
+	state := testState(t)
+	step := new(StepRemoveDevices)
+
+	diskUnmountCommands := map[string][]string{
+		"boot_iso": []string{
+			"storageattach", "foo",
+			"--storagectl", "IDE",
+			"--port", "0",
+			"--device", "1",
+			"--type", "dvddrive",
+			"--medium", "none",
+		},
+	}
+	state.Put("disk_unmount_commands", diskUnmountCommands)
+	state.Put("floppy_path", "foo")
+	state.Put("saved_state", true)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// Test that the disks were ejected and the drives kept
+	if len(driver.VBoxManageCalls) != 2 {
+		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+	}
+	for _, command := range driver.VBoxManageCalls {
+		if command[0] != "storageattach" || command[len(command)-1] != "emptydrive" {
+			t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+		}
+	}
+	if diskUnmountCommands["boot_iso"][11] != "none" {
+		t.Fatal("should not change the unmount commands")
+	}
+}
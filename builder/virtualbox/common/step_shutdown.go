@@ -15,7 +15,8 @@ import (
 )
 
 // This step shuts down the machine. It first attempts to do so gracefully,
-// but ultimately forcefully shuts it down if that fails.
+// but ultimately forcefully shuts it down if that fails. With SaveState, it
+// saves the state of the machine instead.
 //
 // Uses:
 //
@@ -26,13 +27,14 @@ import (
 //
 // Produces:
 //
-//	<nothing>
+//	saved_state bool - Set when the machine was left in saved state.
 type StepShutdown struct {
 	Command         string
 	Timeout         time.Duration
 	Delay           time.Duration
 	DisableShutdown bool
 	ACPIShutdown    bool
+	SaveState       bool
 }
 
 func (s *StepShutdown) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
@@ -41,7 +43,16 @@ func (s *StepShutdown) Run(ctx context.Context, state multistep.StateBag) multis
 	ui := state.Get("ui").(packersdk.Ui)
 	vmName := state.Get("vmName").(string)
 
-	if s.ACPIShutdown {
+	if s.SaveState {
+		ui.Say("Saving the state of the virtual machine...")
+		if err := driver.VBoxManage("controlvm", vmName, "savestate"); err != nil {
+			err := fmt.Errorf("Error saving the state of VM: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		state.Put("saved_state", true)
+	} else if s.ACPIShutdown {
 		ui.Say("Shutting down the virtual machine via ACPI power button...")
 		if err := driver.StopViaACPI(vmName); err != nil {
 			err := fmt.Errorf("Error stopping VM: %s", err)
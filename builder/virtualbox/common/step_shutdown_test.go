@@ -5,6 +5,7 @@ package common
 
 import (
 	"context"
+	"reflect"
 	"testing"
 	"time"
 
@@ -173,3 +174,35 @@ func TestStepShutdown_ACPIShutdown(t *testing.T) {
 		t.Fatal("comm start should not be called")
 	}
 }
+
+func TestStepShutdown_SaveState(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.SaveState = true
+	step.Timeout = 2 * time.Second
+
+	comm := new(packersdk.MockCommunicator)
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+	if _, ok := state.GetOk("saved_state"); !ok {
+		t.Fatal("should set saved_state")
+	}
+
+	// Test that the state was saved rather than the VM stopped
+	if len(driver.VBoxManageCalls) != 1 || !reflect.DeepEqual(driver.VBoxManageCalls[0], []string{"controlvm", "foo", "savestate"}) {
+		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+	}
+	if driver.StopName != "" || comm.StartCalled {
+		t.Fatal("should not stop the VM")
+	}
+}
@@ -32,6 +32,13 @@ type bootCommandTemplateData struct {
 
 	// SSHPublicKey is the SSH public key in OpenSSH authorized_keys format.
 	SSHPublicKey string
+
+	// ProxyURL is the proxy the guest should use, if any.
+	ProxyURL string
+
+	// NoProxy is the comma separated list of hosts the guest should reach
+	// without the proxy.
+	NoProxy string
 }
 
 type StepTypeBootCommand struct {
@@ -67,12 +74,19 @@ func (s *StepTypeBootCommand) Run(ctx context.Context, state multistep.StateBag)
 	}
 
 	hostIP := state.Get("http_ip").(string)
-	s.Ctx.Data = &bootCommandTemplateData{
+	data := &bootCommandTemplateData{
 		HTTPIP:       hostIP,
 		HTTPPort:     httpPort,
 		Name:         s.VMName,
 		SSHPublicKey: string(s.Comm.SSHPublicKey),
 	}
+	if proxyURL, ok := state.GetOk("proxy_url"); ok {
+		data.ProxyURL = proxyURL.(string)
+	}
+	if noProxy, ok := state.GetOk("no_proxy"); ok {
+		data.NoProxy = noProxy.(string)
+	}
+	s.Ctx.Data = data
 
 	sendCodes := func(codes []string) error {
 		args := []string{"controlvm", vmName, "keyboardputscancode"}
@@ -0,0 +1,188 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common/ovfmodel"
+)
+
+const vagrantfileTemplate = `Vagrant.configure("2") do |config|
+  config.vm.base_mac = "%s"
+end
+`
+
+// This step writes a Vagrant box for the virtualbox provider next to the
+// OVF export, and packs it into a .box file if asked to.
+//
+// Uses:
+//
+//	exportOutputs []ExportOutput - The exports, if any. The box is added
+//	  to them as the "vagrant" format.
+//	exportVMName string - The VM exported instead of vmName, if set.
+type StepVagrantBox struct {
+	Config VagrantBoxConfig
+}
+
+func (s *StepVagrantBox) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	outputs, ok := state.GetOk("exportOutputs")
+	if !ok || !s.Config.VagrantBox {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+	if name, ok := state.GetOk("exportVMName"); ok {
+		vmName = name.(string)
+	}
+
+	exportOutputs := outputs.([]ExportOutput)
+	for _, output := range exportOutputs {
+		if output.Format != "ovf" {
+			continue
+		}
+
+		ui.Say("Writing Vagrant box...")
+		box, err := s.writeBox(driver, vmName, output)
+		if err == nil && s.Config.VagrantBoxArchive != "" {
+			ui.Message(fmt.Sprintf("Packing Vagrant box to %s", s.Config.VagrantBoxArchive))
+			var checksum string
+			checksum, err = packVagrantBox(s.Config.VagrantBoxArchive, box.Files)
+			box.Path = s.Config.VagrantBoxArchive
+			box.Files = append(box.Files, s.Config.VagrantBoxArchive)
+			box.checksums[filepath.Base(s.Config.VagrantBoxArchive)] = checksum
+		}
+		if err != nil {
+			err := fmt.Errorf("Error writing the Vagrant box: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		state.Put("exportOutputs", append(exportOutputs, box))
+		break
+	}
+
+	return multistep.ActionContinue
+}
+
+// writeBox writes the files of the Vagrant box next to the OVF export, and
+// returns them along with the disks of the export, with their checksums.
+func (s *StepVagrantBox) writeBox(driver Driver, vmName string, ovf ExportOutput) (ExportOutput, error) {
+	output, err := driver.VBoxManageWithOutput("showvminfo", vmName, "--machinereadable")
+	if err != nil {
+		return ExportOutput{}, err
+	}
+	mac := ParseVMInfo(output)["macaddress1"]
+	if mac == "" {
+		return ExportOutput{}, fmt.Errorf("the first network adapter of the VM has no MAC address")
+	}
+	version, err := driver.Version()
+	if err != nil {
+		return ExportOutput{}, fmt.Errorf("error getting VirtualBox version: %s", err)
+	}
+
+	data, err := os.ReadFile(ovf.Path)
+	if err != nil {
+		return ExportOutput{}, err
+	}
+	descriptor, err := ovfmodel.Parse(data)
+	if err != nil {
+		return ExportOutput{}, err
+	}
+
+	// box.ovf is the exported descriptor, referencing the same disks
+	dir := filepath.Dir(ovf.Path)
+	box := ExportOutput{
+		Format:    "vagrant",
+		Path:      filepath.Join(dir, "box.ovf"),
+		checksums: make(map[string]string),
+	}
+	for name, content := range map[string][]byte{
+		"box.ovf":       data,
+		"metadata.json": []byte(`{"provider": "virtualbox"}` + "\n"),
+		"Vagrantfile":   []byte(fmt.Sprintf(vagrantfileTemplate, mac)),
+		".vbox_version": []byte(version),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return ExportOutput{}, err
+		}
+		checksum := sha256.Sum256(content)
+		box.checksums[name] = hex.EncodeToString(checksum[:])
+	}
+	for _, name := range []string{"box.ovf", "metadata.json", "Vagrantfile", ".vbox_version"} {
+		box.Files = append(box.Files, filepath.Join(dir, name))
+	}
+	for _, name := range descriptor.References() {
+		box.Files = append(box.Files, filepath.Join(dir, name))
+		if checksum, ok := ovf.checksums[name]; ok {
+			box.checksums[name] = checksum
+		}
+	}
+	return box, nil
+}
+
+func (s *StepVagrantBox) Cleanup(state multistep.StateBag) {}
+
+// packVagrantBox writes files to a gzipped tar at path, flat, and returns
+// its sha256 checksum, computed as it is written.
+func packVagrantBox(path string, files []string) (string, error) {
+	out, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	checksum := sha256.New()
+	gw := gzip.NewWriter(io.MultiWriter(out, checksum))
+	tw := tar.NewWriter(gw)
+	for _, file := range files {
+		if err := addTarFile(tw, file); err != nil {
+			os.Remove(path)
+			return "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	if err := gw.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return hex.EncodeToString(checksum.Sum(nil)), out.Close()
+}
+
+func addTarFile(tw *tar.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
@@ -0,0 +1,121 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepVagrantBox_impl(t *testing.T) {
+	var _ multistep.Step = new(StepVagrantBox)
+}
+
+func TestStepVagrantBox(t *testing.T) {
+	state := testState(t)
+	dir := t.TempDir()
+	archive := filepath.Join(t.TempDir(), "foo.box")
+	step := &StepVagrantBox{Config: VagrantBoxConfig{VagrantBox: true, VagrantBoxArchive: archive}}
+	state.Put("vmName", "foo")
+	state.Put("exportVMName", "foo-export")
+
+	ovfPath := filepath.Join(dir, "foo.ovf")
+	vmdkPath := filepath.Join(dir, "foo-disk001.vmdk")
+	descriptor := `<Envelope><References><File ovf:href="foo-disk001.vmdk"/></References></Envelope>`
+	assert.NoError(t, os.WriteFile(ovfPath, []byte(descriptor), 0644))
+	assert.NoError(t, os.WriteFile(vmdkPath, []byte("disk"), 0644))
+	ovf := ExportOutput{Format: "ovf", Path: ovfPath, Files: []string{vmdkPath, ovfPath},
+		checksums: map[string]string{"foo-disk001.vmdk": sha256Hex("disk"), "foo.ovf": sha256Hex(descriptor)}}
+	state.Put("exportOutputs", []ExportOutput{ovf})
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VersionResult = "7.0.14"
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"showvminfo foo-export --machinereadable": "name=\"foo-export\"\nnic1=\"nat\"\nmacaddress1=\"080027D14C66\"\n",
+	}
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	files := map[string]string{
+		"box.ovf":          descriptor,
+		"metadata.json":    "{\"provider\": \"virtualbox\"}\n",
+		"Vagrantfile":      "Vagrant.configure(\"2\") do |config|\n  config.vm.base_mac = \"080027D14C66\"\nend\n",
+		".vbox_version":    "7.0.14",
+		"foo-disk001.vmdk": "disk",
+	}
+	for name, content := range files {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(data), name)
+	}
+
+	// The files of the box are checksummed as they are written, the disks
+	// by the OVF export
+	box, err := os.ReadFile(archive)
+	assert.NoError(t, err)
+	checksums := make(map[string]string)
+	for name, content := range files {
+		checksums[name] = sha256Hex(content)
+	}
+	checksums["foo.box"] = sha256Hex(string(box))
+
+	outputs := state.Get("exportOutputs").([]ExportOutput)
+	assert.Equal(t, []ExportOutput{ovf, {
+		Format: "vagrant",
+		Path:   archive,
+		Files: []string{
+			filepath.Join(dir, "box.ovf"),
+			filepath.Join(dir, "metadata.json"),
+			filepath.Join(dir, "Vagrantfile"),
+			filepath.Join(dir, ".vbox_version"),
+			vmdkPath,
+			archive,
+		},
+		checksums: checksums,
+	}}, outputs)
+
+	// The box holds the same files, flat
+	f, err := os.Open(archive)
+	assert.NoError(t, err)
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	assert.NoError(t, err)
+	tr := tar.NewReader(gr)
+	packed := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+		packed[hdr.Name] = string(data)
+	}
+	assert.Equal(t, files, packed)
+}
+
+func TestStepVagrantBox_noMAC(t *testing.T) {
+	state := testState(t)
+	dir := t.TempDir()
+	step := &StepVagrantBox{Config: VagrantBoxConfig{VagrantBox: true}}
+	state.Put("vmName", "foo")
+	ovfPath := filepath.Join(dir, "foo.ovf")
+	assert.NoError(t, os.WriteFile(ovfPath, []byte("<Envelope/>"), 0644))
+	state.Put("exportOutputs", []ExportOutput{{Format: "ovf", Path: ovfPath}})
+
+	assert.Equal(t, multistep.ActionHalt, step.Run(context.Background(), state))
+	assert.EqualError(t, state.Get("error").(error),
+		"Error writing the Vagrant box: the first network adapter of the VM has no MAC address")
+}
@@ -0,0 +1,186 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// This step inspects the network configuration of the VM right before it is
+// started and fails the build if anything would let the guest reach or be
+// reached from outside the host.
+//
+// Uses:
+//
+//	driver Driver
+//	ui packersdk.Ui
+//	vmName string
+//
+// Produces:
+type StepVerifyNetworkIsolation struct {
+	NetworkIsolation bool
+	AllowedHostPorts []int
+}
+
+func (s *StepVerifyNetworkIsolation) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.NetworkIsolation {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+	vmName := state.Get("vmName").(string)
+
+	ui.Say("Verifying network isolation...")
+	output, err := driver.VBoxManageWithOutput("showvminfo", vmName, "--machinereadable")
+	if err != nil {
+		err := fmt.Errorf("Error reading virtual machine configuration: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	// showvminfo doesn't print the settings of the NAT engine, they are
+	// read from the settings file of the VM.
+	info := ParseVMInfo(output)
+	nat, err := readNATSettings(info["CfgFile"], 1)
+	if err != nil {
+		err := fmt.Errorf("Error reading NAT settings of the virtual machine: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	leaks := networkIsolationLeaks(info, nat, s.AllowedHostPorts)
+	if len(leaks) > 0 {
+		for _, leak := range leaks {
+			ui.Error(leak)
+		}
+		err := fmt.Errorf("The network configuration of VM %s leaks external connectivity", vmName)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepVerifyNetworkIsolation) Cleanup(state multistep.StateBag) {}
+
+// networkIsolationLeaks returns a description of every setting in the given
+// VM info that breaks the isolation configured by
+// StepConfigureNetworkIsolation.
+func networkIsolationLeaks(info map[string]string, nat natSettings, allowedHostPorts []int) []string {
+	var leaks []string
+
+	if nic := info["nic1"]; nic != "nat" {
+		leaks = append(leaks, fmt.Sprintf("Network adapter 1 is attached to %q instead of NAT", nic))
+	}
+	if ip := net.ParseIP(nat.BindIP); ip == nil || !ip.IsLoopback() {
+		leaks = append(leaks, fmt.Sprintf("The NAT engine of network adapter 1 binds to %q instead of a loopback address", nat.BindIP))
+	}
+	if nat.DNSProxy {
+		leaks = append(leaks, "The NAT DNS proxy of network adapter 1 is enabled")
+	}
+	if nat.DNSHostResolver {
+		leaks = append(leaks, "The NAT host resolver of network adapter 1 is enabled")
+	}
+	for i := 2; i <= maxNetworkAdapters; i++ {
+		nic, ok := info[fmt.Sprintf("nic%d", i)]
+		if ok && nic != "none" && nic != "null" {
+			leaks = append(leaks, fmt.Sprintf("Network adapter %d is attached to %q", i, nic))
+		}
+	}
+
+	for i := 0; ; i++ {
+		rule, ok := info[fmt.Sprintf("Forwarding(%d)", i)]
+		if !ok {
+			break
+		}
+
+		// name,protocol,hostip,hostport,guestip,guestport
+		parts := strings.Split(rule, ",")
+		if len(parts) != 6 {
+			leaks = append(leaks, fmt.Sprintf("Unable to parse port forwarding rule %q", rule))
+			continue
+		}
+
+		hostIP := net.ParseIP(parts[2])
+		if hostIP == nil || !hostIP.IsLoopback() {
+			leaks = append(leaks, fmt.Sprintf("Port forwarding rule %q does not bind to a loopback address", parts[0]))
+		}
+
+		if parts[0] == "packercomm" {
+			continue
+		}
+		hostPort, _ := strconv.Atoi(parts[3])
+		allowed := false
+		for _, port := range allowedHostPorts {
+			if port == hostPort {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			leaks = append(leaks, fmt.Sprintf("Port forwarding rule %q uses host port %s which is not allowed", parts[0], parts[3]))
+		}
+	}
+
+	return leaks
+}
+
+// natSettings are the settings of the NAT engine of a network adapter.
+type natSettings struct {
+	BindIP          string
+	DNSProxy        bool
+	DNSHostResolver bool
+}
+
+// readNATSettings reads the settings of the NAT engine of the given network
+// adapter, counting from 1, from the settings file of a VM.
+func readNATSettings(cfgFile string, nic int) (natSettings, error) {
+	var settings struct {
+		Adapters []struct {
+			Slot int `xml:"slot,attr"`
+			NAT  struct {
+				HostIP string `xml:"hostip,attr"`
+				DNS    struct {
+					UseProxy        bool `xml:"use-proxy,attr"`
+					UseHostResolver bool `xml:"use-host-resolver,attr"`
+				} `xml:"DNS"`
+			} `xml:"NAT"`
+		} `xml:"Machine>Hardware>Network>Adapter"`
+	}
+
+	if cfgFile == "" {
+		return natSettings{}, fmt.Errorf("the VM has no settings file")
+	}
+	content, err := os.ReadFile(cfgFile)
+	if err != nil {
+		return natSettings{}, err
+	}
+	if err := xml.Unmarshal(content, &settings); err != nil {
+		return natSettings{}, fmt.Errorf("error parsing %s: %s", cfgFile, err)
+	}
+	for _, adapter := range settings.Adapters {
+		if adapter.Slot == nic-1 {
+			return natSettings{
+				BindIP:          adapter.NAT.HostIP,
+				DNSProxy:        adapter.NAT.DNS.UseProxy,
+				DNSHostResolver: adapter.NAT.DNS.UseHostResolver,
+			}, nil
+		}
+	}
+	// Adapters left with their default settings aren't saved
+	return natSettings{}, nil
+}
@@ -0,0 +1,129 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepVerifyNetworkIsolation_impl(t *testing.T) {
+	var _ multistep.Step = new(StepVerifyNetworkIsolation)
+}
+
+// testVMSettings writes a VM settings file with the given NAT engine
+// settings of the first network adapter.
+func testVMSettings(t *testing.T, nat string) string {
+	path := filepath.Join(t.TempDir(), "foo.vbox")
+	content := `<?xml version="1.0"?>
+<VirtualBox xmlns="http://www.virtualbox.org/" version="1.19-linux">
+  <Machine uuid="{00000000-0000-0000-0000-000000000001}" name="foo">
+    <Hardware>
+      <Network>
+        <Adapter slot="0" enabled="true" MACAddress="080027000001" type="82540EM">
+          ` + nat + `
+        </Adapter>
+      </Network>
+    </Hardware>
+  </Machine>
+</VirtualBox>`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return path
+}
+
+func TestStepVerifyNetworkIsolation(t *testing.T) {
+	state := testState(t)
+	step := &StepVerifyNetworkIsolation{NetworkIsolation: true}
+
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResult = `CfgFile="` + testVMSettings(t, `<NAT hostip="127.0.0.1"/>`) + `"
+nic1="nat"
+nic2="none"
+Forwarding(0)="packercomm,tcp,127.0.0.1,2222,,22"`
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+}
+
+func TestStepVerifyNetworkIsolation_Leaks(t *testing.T) {
+	state := testState(t)
+	step := &StepVerifyNetworkIsolation{NetworkIsolation: true}
+
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VBoxManageWithOutputResult = `CfgFile="` + testVMSettings(t, `<NAT hostip="127.0.0.1"/>`) + `"
+nic1="nat"
+nic2="bridged"`
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+}
+
+func TestNetworkIsolationLeaks(t *testing.T) {
+	info := map[string]string{
+		"nic1":          "nat",
+		"nic2":          "null",
+		"nic3":          "hostonly",
+		"Forwarding(0)": "packercomm,tcp,127.0.0.1,2222,,22",
+		"Forwarding(1)": "web,tcp,127.0.0.1,8080,,80",
+		"Forwarding(2)": "db,tcp,127.0.0.1,5432,,5432",
+		"Forwarding(3)": "rdp,tcp,,3389,,3389",
+	}
+
+	nat := natSettings{BindIP: "127.0.0.1"}
+	leaks := networkIsolationLeaks(info, nat, []int{8080, 3389})
+	assert.Equal(t, []string{
+		`Network adapter 3 is attached to "hostonly"`,
+		`Port forwarding rule "db" uses host port 5432 which is not allowed`,
+		`Port forwarding rule "rdp" does not bind to a loopback address`,
+	}, leaks)
+
+	info["nic1"] = "bridged"
+	leaks = networkIsolationLeaks(info, nat, []int{8080, 3389, 5432})
+	assert.Contains(t, leaks, `Network adapter 1 is attached to "bridged" instead of NAT`)
+
+	info["nic1"] = "nat"
+	leaks = networkIsolationLeaks(info, natSettings{DNSProxy: true, DNSHostResolver: true}, []int{8080, 3389, 5432})
+	assert.Equal(t, []string{
+		`The NAT engine of network adapter 1 binds to "" instead of a loopback address`,
+		"The NAT DNS proxy of network adapter 1 is enabled",
+		"The NAT host resolver of network adapter 1 is enabled",
+		`Network adapter 3 is attached to "hostonly"`,
+		`Port forwarding rule "rdp" does not bind to a loopback address`,
+	}, leaks)
+}
+
+func TestReadNATSettings(t *testing.T) {
+	path := testVMSettings(t, `<NAT hostip="0.0.0.0" localhost-reachable="true">
+            <DNS use-proxy="true"/>
+          </NAT>`)
+	nat, err := readNATSettings(path, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, natSettings{BindIP: "0.0.0.0", DNSProxy: true}, nat)
+
+	// An adapter with the default settings isn't saved
+	nat, err = readNATSettings(path, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, natSettings{}, nat)
+}
@@ -0,0 +1,42 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+
+package common
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// VagrantBoxConfig configures the Vagrant box written from the OVF export,
+// instead of chaining the build to the `vagrant` post-processor.
+type VagrantBoxConfig struct {
+	// Write a Vagrant box for the `virtualbox` provider to the output
+	// directory: `box.ovf`, the disks it references, `metadata.json`, a
+	// `Vagrantfile` setting the base MAC address to the one of the first
+	// network adapter of the exported VM, and `.vbox_version` holding the
+	// VirtualBox version. Requires the `ovf` format. Defaults to `false`.
+	VagrantBox bool `mapstructure:"vagrant_box" required:"false"`
+	// Also pack the Vagrant box into this `.box` file, a gzipped tar that
+	// `vagrant box add` takes. Requires `vagrant_box`.
+	VagrantBoxArchive string `mapstructure:"vagrant_box_archive" required:"false"`
+}
+
+func (c *VagrantBoxConfig) Prepare(export *ExportConfig) []error {
+	var errs []error
+	if c.VagrantBox && !contains(export.formats(), "ovf") {
+		errs = append(errs, fmt.Errorf("vagrant_box requires the ovf format"))
+	}
+	if c.VagrantBoxArchive != "" {
+		if !c.VagrantBox {
+			errs = append(errs, fmt.Errorf("vagrant_box_archive requires vagrant_box"))
+		}
+		if !strings.EqualFold(filepath.Ext(c.VagrantBoxArchive), ".box") {
+			errs = append(errs, fmt.Errorf("vagrant_box_archive must be a .box file"))
+		}
+	}
+	return errs
+}
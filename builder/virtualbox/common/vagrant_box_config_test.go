@@ -0,0 +1,30 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVagrantBoxConfigPrepare(t *testing.T) {
+	ovf := &ExportConfig{Formats: []string{"ovf"}}
+	ova := &ExportConfig{Formats: []string{"ova"}}
+
+	c := &VagrantBoxConfig{VagrantBox: true, VagrantBoxArchive: "ubuntu.box"}
+	assert.Empty(t, c.Prepare(ovf))
+
+	for _, tc := range []struct {
+		Config VagrantBoxConfig
+		Export *ExportConfig
+	}{
+		{VagrantBoxConfig{VagrantBox: true}, ova},
+		{VagrantBoxConfig{VagrantBox: true}, &ExportConfig{ExportDiskOnly: true}},
+		{VagrantBoxConfig{VagrantBoxArchive: "ubuntu.box"}, ovf},
+		{VagrantBoxConfig{VagrantBox: true, VagrantBoxArchive: "ubuntu.tar.gz"}, ovf},
+	} {
+		assert.NotEmpty(t, tc.Config.Prepare(tc.Export), "%#v", tc.Config)
+	}
+}
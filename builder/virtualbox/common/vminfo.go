@@ -0,0 +1,43 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"strings"
+)
+
+// ParseVMInfo parses the output of `VBoxManage showvminfo --machinereadable`
+// into a map of keys to unquoted values.
+func ParseVMInfo(output string) map[string]string {
+	info := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		// Need to trim off CR character when running in windows
+		line = strings.TrimRight(line, " \r")
+
+		var key, value string
+		if strings.HasPrefix(line, `"`) {
+			// Keys containing spaces, such as storage attachments, are quoted
+			end := strings.Index(line, `"=`)
+			if end < 0 {
+				continue
+			}
+			key = line[1:end]
+			value = line[end+2:]
+		} else {
+			idx := strings.Index(line, "=")
+			if idx < 0 {
+				continue
+			}
+			key = line[:idx]
+			value = line[idx+1:]
+		}
+
+		if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+			value = value[1 : len(value)-1]
+		}
+		info[key] = value
+	}
+
+	return info
+}
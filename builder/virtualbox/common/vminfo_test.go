@@ -0,0 +1,29 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVMInfo(t *testing.T) {
+	info := ParseVMInfo(`name="packer-foo"
+memory=1024
+"SATA Controller-0-0"="/vms/packer-foo/disk.vdi"
+nic1="nat"
+Forwarding(0)="packercomm,tcp,127.0.0.1,2222,,22"
+description="a=b"
+garbage
+`)
+
+	assert.Equal(t, "packer-foo", info["name"])
+	assert.Equal(t, "1024", info["memory"])
+	assert.Equal(t, "/vms/packer-foo/disk.vdi", info["SATA Controller-0-0"])
+	assert.Equal(t, "nat", info["nic1"])
+	assert.Equal(t, "packercomm,tcp,127.0.0.1,2222,,22", info["Forwarding(0)"])
+	assert.Equal(t, "a=b", info["description"])
+	assert.Len(t, info, 6)
+}
@@ -32,22 +32,31 @@ type Builder struct {
 }
 
 type Config struct {
-	common.PackerConfig             `mapstructure:",squash"`
-	commonsteps.HTTPConfig          `mapstructure:",squash"`
-	commonsteps.ISOConfig           `mapstructure:",squash"`
-	commonsteps.FloppyConfig        `mapstructure:",squash"`
-	commonsteps.CDConfig            `mapstructure:",squash"`
-	bootcommand.BootConfig          `mapstructure:",squash"`
-	vboxcommon.ExportConfig         `mapstructure:",squash"`
-	vboxcommon.OutputConfig         `mapstructure:",squash"`
-	vboxcommon.RunConfig            `mapstructure:",squash"`
-	vboxcommon.ShutdownConfig       `mapstructure:",squash"`
-	vboxcommon.CommConfig           `mapstructure:",squash"`
-	vboxcommon.HWConfig             `mapstructure:",squash"`
-	vboxcommon.VBoxManageConfig     `mapstructure:",squash"`
-	vboxcommon.VBoxVersionConfig    `mapstructure:",squash"`
-	vboxcommon.VBoxBundleConfig     `mapstructure:",squash"`
-	vboxcommon.GuestAdditionsConfig `mapstructure:",squash"`
+	common.PackerConfig               `mapstructure:",squash"`
+	commonsteps.HTTPConfig            `mapstructure:",squash"`
+	commonsteps.ISOConfig             `mapstructure:",squash"`
+	commonsteps.FloppyConfig          `mapstructure:",squash"`
+	commonsteps.CDConfig              `mapstructure:",squash"`
+	bootcommand.BootConfig            `mapstructure:",squash"`
+	vboxcommon.ExportConfig           `mapstructure:",squash"`
+	vboxcommon.OutputConfig           `mapstructure:",squash"`
+	vboxcommon.RunConfig              `mapstructure:",squash"`
+	vboxcommon.ShutdownConfig         `mapstructure:",squash"`
+	vboxcommon.CommConfig             `mapstructure:",squash"`
+	vboxcommon.HWConfig               `mapstructure:",squash"`
+	vboxcommon.VBoxManageConfig       `mapstructure:",squash"`
+	vboxcommon.VBoxVersionConfig      `mapstructure:",squash"`
+	vboxcommon.VBoxBundleConfig       `mapstructure:",squash"`
+	vboxcommon.GuestAdditionsConfig   `mapstructure:",squash"`
+	vboxcommon.NetworkIsolationConfig `mapstructure:",squash"`
+	vboxcommon.MACAddressConfig       `mapstructure:",squash"`
+	vboxcommon.NetworkTraceConfig     `mapstructure:",squash"`
+	vboxcommon.GuestProxyConfig       `mapstructure:",squash"`
+	vboxcommon.LiveSnapshotConfig     `mapstructure:",squash"`
+	vboxcommon.GCConfig               `mapstructure:",squash"`
+	vboxcommon.ExportProductConfig    `mapstructure:",squash"`
+	vboxcommon.ExportManifestConfig   `mapstructure:",squash"`
+	vboxcommon.VagrantBoxConfig       `mapstructure:",squash"`
 	// The chipset to be used: PIIX3 or ICH9.
 	// When set to piix3, the firmare is PIIX3. This is the default.
 	// When set to ich9, the firmare is ICH9.
@@ -184,6 +193,11 @@ type Config struct {
 	// Set this to true if you would like to keep the VM registered with
 	// virtualbox. Defaults to false.
 	KeepRegistered bool `mapstructure:"keep_registered" required:"false"`
+	// Also delete the VM kept registered with `keep_registered` when the
+	// artifact is destroyed, for example by a post-processor that does not
+	// keep its input artifact. The VM is not deleted while it is running.
+	// Defaults to `false`.
+	DeleteWithArtifact bool `mapstructure:"delete_with_artifact" required:"false"`
 	// Defaults to false. When enabled, Packer will not export the VM. Useful
 	// if the build output is not the resultant image, but created inside the
 	// VM.
@@ -217,6 +231,12 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 		return nil, nil, err
 	}
 
+	// The HTTP server has to listen on IPv6 for the guest to reach it through
+	// the host loopback address of the NAT Network.
+	if b.config.IPFamily == vboxcommon.IPFamilyIPv6 && b.config.HTTPAddress == "" && b.config.HTTPInterface == "" {
+		b.config.HTTPAddress = "::"
+	}
+
 	// Accumulate any errors and warnings
 	var errs *packersdk.MultiError
 	warnings := make([]string, 0)
@@ -225,7 +245,6 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 	warnings = append(warnings, isoWarnings...)
 	errs = packersdk.MultiErrorAppend(errs, isoErrs...)
 
-	errs = packersdk.MultiErrorAppend(errs, b.config.ExportConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.ExportConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.FloppyConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.CDConfig.Prepare(&b.config.ctx)...)
@@ -241,6 +260,15 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 	errs = packersdk.MultiErrorAppend(errs, b.config.VBoxVersionConfig.Prepare(b.config.CommConfig.Comm.Type)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.BootConfig.Prepare(&b.config.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, b.config.GuestAdditionsConfig.Prepare(b.config.CommConfig.Comm.Type)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.VagrantBoxConfig.Prepare(&b.config.ExportConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.ExportManifestConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.ExportProductConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.GCConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.LiveSnapshotConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.GuestProxyConfig.Prepare(&b.config.ctx, &b.config.NetworkIsolationConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.NetworkTraceConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.MACAddressConfig.Prepare(&b.config.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, b.config.NetworkIsolationConfig.Prepare(&b.config.ctx, &b.config.CommConfig)...)
 
 	if b.config.Chipset == "" {
 		b.config.Chipset = "piix3"
@@ -412,8 +440,13 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 			errs, errors.New("iso_interface can only be ide, sata or virtio"))
 	}
 
+	if b.config.SaveState && (!b.config.KeepRegistered || !b.config.SkipExport) {
+		errs = packersdk.MultiErrorAppend(
+			errs, errors.New("save_state requires keep_registered and skip_export"))
+	}
+
 	// Warnings
-	if b.config.ShutdownCommand == "" {
+	if b.config.ShutdownCommand == "" && !b.config.SaveState {
 		warnings = append(warnings,
 			"A shutdown_command was not specified. Without a shutdown command, Packer\n"+
 				"will forcibly halt the virtual machine, which may result in data loss.")
@@ -423,7 +456,11 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 		return nil, warnings, errs
 	}
 
-	return nil, warnings, nil
+	var generatedData []string
+	generatedData = append(generatedData, vboxcommon.GuestProxyGeneratedData...)
+	generatedData = append(generatedData, vboxcommon.LiveSnapshotGeneratedData...)
+	generatedData = append(generatedData, vboxcommon.CloudExportGeneratedData...)
+	return generatedData, warnings, nil
 }
 
 func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook) (packersdk.Artifact, error) {
@@ -464,7 +501,10 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Content: b.config.CDConfig.CDContent,
 			Label:   b.config.CDConfig.CDLabel,
 		},
-		new(vboxcommon.StepHTTPIPDiscover),
+		&vboxcommon.StepHTTPIPDiscover{
+			IPFamily:   b.config.IPFamily,
+			NATNetwork: b.config.NATNetwork,
+		},
 		commonsteps.HTTPServerFromHTTPConfig(&b.config.HTTPConfig),
 		&vboxcommon.StepSshKeyPair{
 			Debug:        b.config.PackerDebug,
@@ -472,6 +512,11 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Comm:         &b.config.Comm,
 		},
 		new(vboxcommon.StepSuppressMessages),
+		&vboxcommon.StepGarbageCollect{
+			Enabled:   b.config.GCOrphans,
+			OlderThan: b.config.GCOrphansOlderThan,
+			DryRun:    b.config.GCOrphansDryRun,
+		},
 		new(stepCreateVM),
 		new(stepCreateDisk),
 		&vboxcommon.StepAttachISOs{
@@ -486,16 +531,43 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			VRDPPortMax:     b.config.VRDPPortMax,
 		},
 		new(vboxcommon.StepAttachFloppy),
+		&vboxcommon.StepConfigureMACAddresses{
+			MACAddressPolicy: b.config.MACAddressPolicy,
+			MACAddresses:     b.config.MACAddresses,
+		},
 		&vboxcommon.StepPortForwarding{
 			CommConfig:     &b.config.CommConfig.Comm,
 			HostPortMin:    b.config.HostPortMin,
 			HostPortMax:    b.config.HostPortMax,
 			SkipNatMapping: b.config.SkipNatMapping,
+			IPFamily:       b.config.IPFamily,
+			NATNetwork:     b.config.NATNetwork,
+		},
+		&vboxcommon.StepConfigureNetworkIsolation{
+			NetworkIsolation: b.config.NetworkIsolation,
+		},
+		&vboxcommon.StepNetworkTrace{
+			Adapters:      b.config.NetworkTrace,
+			OutputDir:     b.config.OutputDir,
+			InArtifact:    b.config.NetworkTraceInArtifact,
+			KeepOnFailure: b.config.KeepNetworkTraceOnFailure,
+		},
+		&vboxcommon.StepGuestProxy{
+			URL:      b.config.GuestProxyURL,
+			NoProxy:  b.config.GuestNoProxy,
+			Local:    b.config.GuestProxyLocal,
+			PortMin:  b.config.GuestProxyPortMin,
+			PortMax:  b.config.GuestProxyPortMax,
+			IPFamily: b.config.IPFamily,
 		},
 		&vboxcommon.StepVBoxManage{
 			Commands: b.config.VBoxManage,
 			Ctx:      b.config.ctx,
 		},
+		&vboxcommon.StepVerifyNetworkIsolation{
+			NetworkIsolation: b.config.NetworkIsolation,
+			AllowedHostPorts: b.config.NetworkIsolationAllowedHostPorts,
+		},
 		&vboxcommon.StepRun{
 			Headless: b.config.Headless,
 		},
@@ -522,6 +594,11 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			GuestAdditionsPath: b.config.GuestAdditionsPath,
 			Ctx:                b.config.ctx,
 		},
+		&vboxcommon.StepLiveSnapshots{
+			Milestones: b.config.LiveSnapshotMilestones,
+			Prefix:     b.config.LiveSnapshotPrefix,
+			Keep:       b.config.KeepLiveSnapshots,
+		},
 		new(commonsteps.StepProvision),
 		&commonsteps.StepCleanupTempKeys{
 			Comm: &b.config.CommConfig.Comm,
@@ -532,6 +609,7 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Delay:           b.config.PostShutdownDelay,
 			DisableShutdown: b.config.DisableShutdown,
 			ACPIShutdown:    b.config.ACPIShutdown,
+			SaveState:       b.config.SaveState,
 		},
 		&vboxcommon.StepRemoveDevices{
 			Bundling: b.config.VBoxBundleConfig,
@@ -541,13 +619,30 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Ctx:      b.config.ctx,
 		},
 		&vboxcommon.StepExport{
-			Format:         b.config.Format,
+			Format:           b.config.Format,
+			Formats:          b.config.Formats,
+			OutputDir:        b.config.OutputDir,
+			OutputFilename:   b.config.OutputFilename,
+			ExportOpts:       b.config.ExportConfig.ExportOpts,
+			Product:          b.config.ExportProductConfig,
+			Transform:        b.config.OVFTransform,
+			Manifest:         b.config.ExportManifestConfig,
+			Cloud:            b.config.CloudExport,
+			DiskOnly:         b.config.ExportDiskOnly,
+			DiskFormat:       b.config.ExportDiskFormat,
+			DiskCompression:  b.config.ExportDiskCompression,
+			Atomic:           b.config.ExportAtomic,
+			Bundling:         b.config.VBoxBundleConfig,
+			SkipNatMapping:   b.config.SkipNatMapping,
+			SkipExport:       b.config.SkipExport,
+			MACAddressPolicy: b.config.ExportMACAddressPolicy,
+		},
+		&vboxcommon.StepVagrantBox{
+			Config: b.config.VagrantBoxConfig,
+		},
+		&vboxcommon.StepArtifactMetadata{
 			OutputDir:      b.config.OutputDir,
-			OutputFilename: b.config.OutputFilename,
-			ExportOpts:     b.config.ExportConfig.ExportOpts,
-			Bundling:       b.config.VBoxBundleConfig,
-			SkipNatMapping: b.config.SkipNatMapping,
-			SkipExport:     b.config.SkipExport,
+			KeepRegistered: b.config.KeepRegistered,
 		},
 	}
 
@@ -578,5 +673,14 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	}
 
 	generatedData := map[string]interface{}{"generated_data": state.Get("generated_data")}
-	return vboxcommon.NewArtifact(b.config.OutputDir, generatedData)
+	metadata, _ := state.Get("artifactMetadata").(*vboxcommon.ArtifactMetadata)
+	// Without a driver, destroying the artifact leaves the VM alone
+	var artifactDriver vboxcommon.Driver
+	if b.config.DeleteWithArtifact {
+		artifactDriver = driver
+	}
+	if b.config.ExportDiskOnly && metadata != nil && len(metadata.Exports) == 1 {
+		return vboxcommon.NewDiskArtifact(b.config.OutputDir, artifactDriver, metadata, generatedData)
+	}
+	return vboxcommon.NewArtifact(b.config.OutputDir, artifactDriver, metadata, generatedData)
 }
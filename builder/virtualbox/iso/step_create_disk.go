@@ -6,6 +6,7 @@ package iso
 import (
 	"context"
 	"fmt"
+	"log"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
@@ -63,6 +64,10 @@ func (s *stepCreateDisk) Run(ctx context.Context, state multistep.StateBag) mult
 			ui.Error(err.Error())
 			return multistep.ActionHalt
 		}
+		// For gc_orphans to delete it if the build dies before attaching it
+		if err := vboxcommon.RecordMedium(diskFullPaths[i]); err != nil {
+			log.Printf("Error recording hard drive %s: %s", diskFullPaths[i], err)
+		}
 	}
 
 	// Add the IDE controller so we can later attach the disk.
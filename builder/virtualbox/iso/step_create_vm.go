@@ -32,6 +32,19 @@ func (s *stepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 
 	name := config.VMName
 
+	if config.PackerForce {
+		replaced, err := vboxcommon.ReplaceKeptVM(driver, name)
+		if err != nil {
+			err := fmt.Errorf("Error replacing VM %s: %s", name, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		if replaced {
+			ui.Say(fmt.Sprintf("Deleted VM %s kept by an earlier build (-force)", name))
+		}
+	}
+
 	commands := [][]string{}
 	commands = append(commands, []string{
 		"createvm", "--name", name,
@@ -160,6 +173,10 @@ func (s *stepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 		}
 	}
 
+	if err := vboxcommon.TagVM(driver, s.vmName); err != nil {
+		log.Printf("Error tagging VM %s: %s", s.vmName, err)
+	}
+
 	// Set the final name in the state bag so others can use it
 	state.Put("vmName", s.vmName)
 
@@ -179,6 +196,9 @@ func (s *stepCreateVM) Cleanup(state multistep.StateBag) {
 	_, halted := state.GetOk(multistep.StateHalted)
 	if (config.KeepRegistered) && (!cancelled && !halted) {
 		ui.Say("Keeping virtual machine registered with VirtualBox host (keep_registered = true)")
+		if err := vboxcommon.UntagVM(driver, s.vmName); err != nil {
+			log.Printf("Error untagging VM %s: %s", s.vmName, err)
+		}
 		return
 	}
 
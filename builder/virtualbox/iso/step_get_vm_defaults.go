@@ -42,6 +42,10 @@ func (s *stepGetVMDefaults) Run(ctx context.Context, state multistep.StateBag) m
 		return multistep.ActionContinue
 	}
 
+	if err := vboxcommon.TagVM(driver, vmName); err != nil {
+		log.Printf("Error tagging temp VM: %s", err)
+	}
+
 	defer func() {
 		// Delete the temp VM
 		command = []string{"unregistervm", vmName, "--delete"}
@@ -31,7 +31,11 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 		return nil, warnings, errs
 	}
 
-	return nil, warnings, nil
+	var generatedData []string
+	generatedData = append(generatedData, vboxcommon.GuestProxyGeneratedData...)
+	generatedData = append(generatedData, vboxcommon.LiveSnapshotGeneratedData...)
+	generatedData = append(generatedData, vboxcommon.CloudExportGeneratedData...)
+	return generatedData, warnings, nil
 }
 
 // Run executes a Packer build and returns a packersdk.Artifact representing
@@ -58,6 +62,11 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Path:  b.config.OutputDir,
 		},
 		new(vboxcommon.StepSuppressMessages),
+		&vboxcommon.StepGarbageCollect{
+			Enabled:   b.config.GCOrphans,
+			OlderThan: b.config.GCOrphansOlderThan,
+			DryRun:    b.config.GCOrphansDryRun,
+		},
 		&commonsteps.StepCreateFloppy{
 			Files:       b.config.FloppyConfig.FloppyFiles,
 			Directories: b.config.FloppyConfig.FloppyDirectories,
@@ -69,7 +78,10 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Content: b.config.CDConfig.CDContent,
 			Label:   b.config.CDConfig.CDLabel,
 		},
-		new(vboxcommon.StepHTTPIPDiscover),
+		&vboxcommon.StepHTTPIPDiscover{
+			IPFamily:   b.config.IPFamily,
+			NATNetwork: b.config.NATNetwork,
+		},
 		commonsteps.HTTPServerFromHTTPConfig(&b.config.HTTPConfig),
 		&vboxcommon.StepSshKeyPair{
 			Debug:        b.config.PackerDebug,
@@ -94,6 +106,9 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Name:           b.config.VMName,
 			ImportFlags:    b.config.ImportFlags,
 			KeepRegistered: b.config.KeepRegistered,
+			Force:          b.config.PackerForce,
+			SharedBase:     b.config.SharedBaseImport,
+			BaseName:       b.config.SharedBaseName,
 		},
 		&vboxcommon.StepAttachISOs{
 			AttachBootISO:           false,
@@ -107,16 +122,43 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			VRDPPortMax:     b.config.VRDPPortMax,
 		},
 		new(vboxcommon.StepAttachFloppy),
+		&vboxcommon.StepConfigureMACAddresses{
+			MACAddressPolicy: b.config.MACAddressPolicy,
+			MACAddresses:     b.config.MACAddresses,
+		},
 		&vboxcommon.StepPortForwarding{
 			CommConfig:     &b.config.CommConfig.Comm,
 			HostPortMin:    b.config.HostPortMin,
 			HostPortMax:    b.config.HostPortMax,
 			SkipNatMapping: b.config.SkipNatMapping,
+			IPFamily:       b.config.IPFamily,
+			NATNetwork:     b.config.NATNetwork,
+		},
+		&vboxcommon.StepConfigureNetworkIsolation{
+			NetworkIsolation: b.config.NetworkIsolation,
+		},
+		&vboxcommon.StepNetworkTrace{
+			Adapters:      b.config.NetworkTrace,
+			OutputDir:     b.config.OutputDir,
+			InArtifact:    b.config.NetworkTraceInArtifact,
+			KeepOnFailure: b.config.KeepNetworkTraceOnFailure,
+		},
+		&vboxcommon.StepGuestProxy{
+			URL:      b.config.GuestProxyURL,
+			NoProxy:  b.config.GuestNoProxy,
+			Local:    b.config.GuestProxyLocal,
+			PortMin:  b.config.GuestProxyPortMin,
+			PortMax:  b.config.GuestProxyPortMax,
+			IPFamily: b.config.IPFamily,
 		},
 		&vboxcommon.StepVBoxManage{
 			Commands: b.config.VBoxManage,
 			Ctx:      b.config.ctx,
 		},
+		&vboxcommon.StepVerifyNetworkIsolation{
+			NetworkIsolation: b.config.NetworkIsolation,
+			AllowedHostPorts: b.config.NetworkIsolationAllowedHostPorts,
+		},
 		&vboxcommon.StepRun{
 			Headless: b.config.Headless,
 		},
@@ -143,6 +185,11 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			GuestAdditionsPath: b.config.GuestAdditionsPath,
 			Ctx:                b.config.ctx,
 		},
+		&vboxcommon.StepLiveSnapshots{
+			Milestones: b.config.LiveSnapshotMilestones,
+			Prefix:     b.config.LiveSnapshotPrefix,
+			Keep:       b.config.KeepLiveSnapshots,
+		},
 		new(commonsteps.StepProvision),
 		&commonsteps.StepCleanupTempKeys{
 			Comm: &b.config.CommConfig.Comm,
@@ -153,6 +200,7 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Delay:           b.config.PostShutdownDelay,
 			DisableShutdown: b.config.DisableShutdown,
 			ACPIShutdown:    b.config.ACPIShutdown,
+			SaveState:       b.config.SaveState,
 		},
 		&vboxcommon.StepRemoveDevices{},
 		&vboxcommon.StepVBoxManage{
@@ -160,12 +208,29 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Ctx:      b.config.ctx,
 		},
 		&vboxcommon.StepExport{
-			Format:         b.config.Format,
+			Format:           b.config.Format,
+			Formats:          b.config.Formats,
+			OutputDir:        b.config.OutputDir,
+			OutputFilename:   b.config.OutputFilename,
+			ExportOpts:       b.config.ExportConfig.ExportOpts,
+			Product:          b.config.ExportProductConfig,
+			Transform:        b.config.OVFTransform,
+			Manifest:         b.config.ExportManifestConfig,
+			Cloud:            b.config.CloudExport,
+			DiskOnly:         b.config.ExportDiskOnly,
+			DiskFormat:       b.config.ExportDiskFormat,
+			DiskCompression:  b.config.ExportDiskCompression,
+			Atomic:           b.config.ExportAtomic,
+			SkipNatMapping:   b.config.SkipNatMapping,
+			SkipExport:       b.config.SkipExport,
+			MACAddressPolicy: b.config.ExportMACAddressPolicy,
+		},
+		&vboxcommon.StepVagrantBox{
+			Config: b.config.VagrantBoxConfig,
+		},
+		&vboxcommon.StepArtifactMetadata{
 			OutputDir:      b.config.OutputDir,
-			OutputFilename: b.config.OutputFilename,
-			ExportOpts:     b.config.ExportConfig.ExportOpts,
-			SkipNatMapping: b.config.SkipNatMapping,
-			SkipExport:     b.config.SkipExport,
+			KeepRegistered: b.config.KeepRegistered,
 		},
 	}
 
@@ -188,7 +253,16 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	}
 
 	generatedData := map[string]interface{}{"generated_data": state.Get("generated_data")}
-	return vboxcommon.NewArtifact(b.config.OutputDir, generatedData)
+	metadata, _ := state.Get("artifactMetadata").(*vboxcommon.ArtifactMetadata)
+	// Without a driver, destroying the artifact leaves the VM alone
+	var artifactDriver vboxcommon.Driver
+	if b.config.DeleteWithArtifact {
+		artifactDriver = driver
+	}
+	if b.config.ExportDiskOnly && metadata != nil && len(metadata.Exports) == 1 {
+		return vboxcommon.NewDiskArtifact(b.config.OutputDir, artifactDriver, metadata, generatedData)
+	}
+	return vboxcommon.NewArtifact(b.config.OutputDir, artifactDriver, metadata, generatedData)
 }
 
 // Cancel.
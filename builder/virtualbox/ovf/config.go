@@ -20,19 +20,28 @@ import (
 
 // Config is the configuration structure for the builder.
 type Config struct {
-	common.PackerConfig             `mapstructure:",squash"`
-	commonsteps.HTTPConfig          `mapstructure:",squash"`
-	commonsteps.FloppyConfig        `mapstructure:",squash"`
-	commonsteps.CDConfig            `mapstructure:",squash"`
-	bootcommand.BootConfig          `mapstructure:",squash"`
-	vboxcommon.ExportConfig         `mapstructure:",squash"`
-	vboxcommon.OutputConfig         `mapstructure:",squash"`
-	vboxcommon.RunConfig            `mapstructure:",squash"`
-	vboxcommon.CommConfig           `mapstructure:",squash"`
-	vboxcommon.ShutdownConfig       `mapstructure:",squash"`
-	vboxcommon.VBoxManageConfig     `mapstructure:",squash"`
-	vboxcommon.VBoxVersionConfig    `mapstructure:",squash"`
-	vboxcommon.GuestAdditionsConfig `mapstructure:",squash"`
+	common.PackerConfig               `mapstructure:",squash"`
+	commonsteps.HTTPConfig            `mapstructure:",squash"`
+	commonsteps.FloppyConfig          `mapstructure:",squash"`
+	commonsteps.CDConfig              `mapstructure:",squash"`
+	bootcommand.BootConfig            `mapstructure:",squash"`
+	vboxcommon.ExportConfig           `mapstructure:",squash"`
+	vboxcommon.OutputConfig           `mapstructure:",squash"`
+	vboxcommon.RunConfig              `mapstructure:",squash"`
+	vboxcommon.CommConfig             `mapstructure:",squash"`
+	vboxcommon.ShutdownConfig         `mapstructure:",squash"`
+	vboxcommon.VBoxManageConfig       `mapstructure:",squash"`
+	vboxcommon.VBoxVersionConfig      `mapstructure:",squash"`
+	vboxcommon.GuestAdditionsConfig   `mapstructure:",squash"`
+	vboxcommon.NetworkIsolationConfig `mapstructure:",squash"`
+	vboxcommon.MACAddressConfig       `mapstructure:",squash"`
+	vboxcommon.NetworkTraceConfig     `mapstructure:",squash"`
+	vboxcommon.GuestProxyConfig       `mapstructure:",squash"`
+	vboxcommon.LiveSnapshotConfig     `mapstructure:",squash"`
+	vboxcommon.GCConfig               `mapstructure:",squash"`
+	vboxcommon.ExportProductConfig    `mapstructure:",squash"`
+	vboxcommon.ExportManifestConfig   `mapstructure:",squash"`
+	vboxcommon.VagrantBoxConfig       `mapstructure:",squash"`
 	// The checksum for the source_path file. The type of the checksum is
 	// specified within the checksum field as a prefix, ex: "md5:{$checksum}".
 	// The type of the checksum can also be omitted and Packer will try to
@@ -76,10 +85,35 @@ type Config struct {
 	// Set this to true if you would like to keep
 	// the VM registered with virtualbox. Defaults to false.
 	KeepRegistered bool `mapstructure:"keep_registered" required:"false"`
+	// Also delete the VM kept registered with `keep_registered` when the
+	// artifact is destroyed, for example by a post-processor that does not
+	// keep its input artifact. The VM is not deleted while it is running.
+	// Defaults to `false`.
+	DeleteWithArtifact bool `mapstructure:"delete_with_artifact" required:"false"`
 	// Defaults to false. When enabled, Packer will
 	// not export the VM. Useful if the build output is not the resultant image,
 	// but created inside the VM.
 	SkipExport bool `mapstructure:"skip_export" required:"false"`
+	// Import the OVF once into a base VM shared by all builds of the same
+	// appliance and `import_flags`, and build each VM as a
+	// linked clone of a snapshot of it. Builds running in parallel, such as
+	// the variants of an image defined as several sources, wait for the
+	// first one to import the base and then run independently, each with its
+	// own communicator, forwarded ports, VRDP port and `output_directory`.
+	// The base VM is kept registered for the next builds. It is tagged with
+	// the `Packer/Created` extra data, refreshed by every build using it, so
+	// that `gc_orphans` deletes it once no build used it for
+	// `gc_orphans_older_than` and no VM is a linked clone of it anymore,
+	// such as those kept with `keep_registered`. A base left behind when the
+	// appliance changes goes the same way. Without `gc_orphans`, delete it
+	// with `VBoxManage unregistervm --delete`. Defaults to `false`.
+	SharedBaseImport bool `mapstructure:"shared_base_import" required:"false"`
+	// The name of the base VM used by `shared_base_import`. By default it is
+	// `packer-base-` followed by a hash of the path, size and modification
+	// time of the downloaded appliance and of the disk images an OVF
+	// references, and of `import_flags`: a new base is imported when the
+	// appliance changes.
+	SharedBaseName string `mapstructure:"shared_base_name" required:"false"`
 
 	ctx interpolate.Context
 }
@@ -109,10 +143,15 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 			"packer-%s-%d", c.PackerBuildName, interpolate.InitTime.Unix())
 	}
 
+	// The HTTP server has to listen on IPv6 for the guest to reach it through
+	// the host loopback address of the NAT Network.
+	if c.IPFamily == vboxcommon.IPFamilyIPv6 && c.HTTPAddress == "" && c.HTTPInterface == "" {
+		c.HTTPAddress = "::"
+	}
+
 	// Prepare the errors
 	var errs *packersdk.MultiError
 	errs = packersdk.MultiErrorAppend(errs, c.ExportConfig.Prepare(&c.ctx)...)
-	errs = packersdk.MultiErrorAppend(errs, c.ExportConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.FloppyConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.CDConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.HTTPConfig.Prepare(&c.ctx)...)
@@ -124,6 +163,15 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 	errs = packersdk.MultiErrorAppend(errs, c.VBoxVersionConfig.Prepare(c.CommConfig.Comm.Type)...)
 	errs = packersdk.MultiErrorAppend(errs, c.BootConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.GuestAdditionsConfig.Prepare(c.CommConfig.Comm.Type)...)
+	errs = packersdk.MultiErrorAppend(errs, c.VagrantBoxConfig.Prepare(&c.ExportConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.ExportManifestConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.ExportProductConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.GCConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.LiveSnapshotConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.GuestProxyConfig.Prepare(&c.ctx, &c.NetworkIsolationConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.NetworkTraceConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.MACAddressConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.NetworkIsolationConfig.Prepare(&c.ctx, &c.CommConfig)...)
 
 	if c.SourcePath == "" {
 		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("source_path is required"))
@@ -133,9 +181,13 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		c.GuestAdditionsInterface = "ide"
 	}
 
+	if c.SaveState && (!c.KeepRegistered || !c.SkipExport) {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("save_state requires keep_registered and skip_export"))
+	}
+
 	// Warnings
 	var warnings []string
-	if c.ShutdownCommand == "" {
+	if c.ShutdownCommand == "" && !c.SaveState {
 		warnings = append(warnings,
 			"A shutdown_command was not specified. Without a shutdown command, Packer\n"+
 				"will forcibly halt the virtual machine, which may result in data loss.")
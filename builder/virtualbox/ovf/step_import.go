@@ -5,18 +5,35 @@ package ovf
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/packer-plugin-sdk/filelock"
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+	"github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common/ovfmodel"
 )
 
-// This step imports an OVF VM into VirtualBox.
+// The snapshot of the shared base VM the builds are cloned from.
+const sharedBaseSnapshot = "packer-base"
+
+// This step imports an OVF VM into VirtualBox. With SharedBase, the OVF is
+// imported once into the BaseName VM, shared by parallel builds, and the VM
+// built is a linked clone of it.
 type StepImport struct {
 	Name           string
 	ImportFlags    []string
 	KeepRegistered bool
+	// Replace the VM kept by an earlier build
+	Force      bool
+	SharedBase bool
+	BaseName   string
 
 	vmName string
 }
@@ -26,19 +43,158 @@ func (s *StepImport) Run(ctx context.Context, state multistep.StateBag) multiste
 	ui := state.Get("ui").(packersdk.Ui)
 	vmPath := state.Get("vm_path").(string)
 
-	ui.Say(fmt.Sprintf("Importing VM: %s", vmPath))
-	if err := driver.Import(s.Name, vmPath, s.ImportFlags); err != nil {
-		err := fmt.Errorf("Error importing VM: %s", err)
-		state.Put("error", err)
-		ui.Error(err.Error())
-		return multistep.ActionHalt
+	if s.Force {
+		replaced, err := vboxcommon.ReplaceKeptVM(driver, s.Name)
+		if err != nil {
+			err := fmt.Errorf("Error replacing VM %s: %s", s.Name, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		if replaced {
+			ui.Say(fmt.Sprintf("Deleted VM %s kept by an earlier build (-force)", s.Name))
+		}
+	}
+
+	if s.SharedBase {
+		if s.BaseName == "" {
+			name, err := sharedBaseName(vmPath, s.ImportFlags)
+			if err != nil {
+				err := fmt.Errorf("Error naming the shared base VM: %s", err)
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+			s.BaseName = name
+		}
+		if err := s.cloneSharedBase(ctx, driver, ui, vmPath); err != nil {
+			err := fmt.Errorf("Error creating VM from shared base %s: %s", s.BaseName, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	} else {
+		ui.Say(fmt.Sprintf("Importing VM: %s", vmPath))
+		if err := driver.Import(s.Name, vmPath, s.ImportFlags); err != nil {
+			err := fmt.Errorf("Error importing VM: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
 	}
 
 	s.vmName = s.Name
+	if err := vboxcommon.TagVM(driver, s.Name); err != nil {
+		log.Printf("Error tagging VM %s: %s", s.Name, err)
+	}
 	state.Put("vmName", s.Name)
 	return multistep.ActionContinue
 }
 
+// sharedBaseName returns the default name of the shared base VM, a hash of
+// the import flags and of the path, size and modification time of the
+// appliance files, for a new base to be imported when they change.
+func sharedBaseName(vmPath string, importFlags []string) (string, error) {
+	files := []string{vmPath}
+	if !strings.EqualFold(filepath.Ext(vmPath), ".ova") {
+		data, err := ovfmodel.ReadDescriptor(vmPath)
+		if err != nil {
+			return "", err
+		}
+		descriptor, err := ovfmodel.Parse(data)
+		if err != nil {
+			return "", err
+		}
+		for _, name := range descriptor.References() {
+			files = append(files, filepath.Join(filepath.Dir(vmPath), name))
+		}
+	}
+
+	h := sha256.New()
+	for _, flag := range importFlags {
+		fmt.Fprintf(h, "%s\x00", flag)
+	}
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\x00", abs, info.Size(), info.ModTime().UnixNano())
+	}
+	return fmt.Sprintf("packer-base-%x", h.Sum(nil)[:6]), nil
+}
+
+// cloneSharedBase imports the base VM unless an earlier or a parallel build
+// did, and creates a linked clone of its base snapshot.
+func (s *StepImport) cloneSharedBase(ctx context.Context, driver vboxcommon.Driver, ui packersdk.Ui, vmPath string) error {
+	// Builds running in parallel wait for the one importing the base, and
+	// take turns cloning it as VirtualBox locks the machine cloned.
+	lockPath, err := vboxcommon.VMLockPath(s.BaseName)
+	if err != nil {
+		return err
+	}
+	lock := filelock.New(lockPath)
+	for {
+		locked, err := lock.TryLock()
+		if err != nil {
+			return fmt.Errorf("error locking shared base: %s", err)
+		}
+		if locked {
+			break
+		}
+		log.Printf("Waiting for the lock on shared base %s", s.BaseName)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			log.Printf("Error unlocking shared base %s: %s", s.BaseName, err)
+		}
+	}()
+
+	if _, err := driver.VBoxManageWithOutput("showvminfo", s.BaseName, "--machinereadable"); err != nil {
+		ui.Say(fmt.Sprintf("Importing shared base VM %s: %s", s.BaseName, vmPath))
+		if err := driver.Import(s.BaseName, vmPath, s.ImportFlags); err != nil {
+			return fmt.Errorf("error importing VM: %s", err)
+		}
+	} else {
+		ui.Say(fmt.Sprintf("Using shared base VM %s", s.BaseName))
+	}
+	// gc_orphans deletes the base once no build used it for
+	// gc_orphans_older_than and it has no linked clones left
+	if err := vboxcommon.TagVM(driver, s.BaseName); err != nil {
+		log.Printf("Error tagging shared base VM %s: %s", s.BaseName, err)
+	}
+
+	snapshotTree, err := driver.LoadSnapshots(s.BaseName)
+	if err != nil {
+		return fmt.Errorf("error loading snapshots: %s", err)
+	}
+	if snapshotTree == nil || len(snapshotTree.GetSnapshotsByName(sharedBaseSnapshot)) == 0 {
+		description := fmt.Sprintf("Shared base of Packer builds, imported from %s", vmPath)
+		if err := driver.CreateSnapshot(s.BaseName, sharedBaseSnapshot, description); err != nil {
+			return fmt.Errorf("error creating snapshot %s: %s", sharedBaseSnapshot, err)
+		}
+	}
+
+	ui.Say(fmt.Sprintf("Creating linked clone %s", s.Name))
+	command := []string{
+		"clonevm", s.BaseName,
+		"--snapshot", sharedBaseSnapshot,
+		"--options", "Link",
+		"--name", s.Name,
+		"--register",
+	}
+	return driver.VBoxManage(command...)
+}
+
 func (s *StepImport) Cleanup(state multistep.StateBag) {
 	if s.vmName == "" {
 		return
@@ -51,6 +207,9 @@ func (s *StepImport) Cleanup(state multistep.StateBag) {
 	_, halted := state.GetOk(multistep.StateHalted)
 	if (s.KeepRegistered) && (!cancelled && !halted) {
 		ui.Say("Keeping virtual machine registered with VirtualBox host (keep_registered = true)")
+		if err := vboxcommon.UntagVM(driver, s.vmName); err != nil {
+			log.Printf("Error untagging VM %s: %s", s.vmName, err)
+		}
 		return
 	}
 
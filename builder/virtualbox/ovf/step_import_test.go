@@ -5,7 +5,12 @@ package ovf
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
@@ -72,3 +77,156 @@ func TestStepImport_Cleanup(t *testing.T) {
 		t.Fatalf("bad: %#v", driver.DeleteName)
 	}
 }
+
+func TestStepImport_SharedBase(t *testing.T) {
+	t.Setenv("PACKER_CACHE_DIR", t.TempDir())
+	state := testState(t)
+	state.Put("vm_path", "foo.ova")
+
+	step := &StepImport{
+		Name:       "bar",
+		SharedBase: true,
+		BaseName:   "packer-base-test",
+	}
+
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	// The base VM doesn't exist yet
+	driver.VBoxManageErrs = []error{errors.New("not found")}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	// The base is imported and snapshotted, the VM is a linked clone of it
+	if driver.ImportName != "packer-base-test" {
+		t.Fatalf("bad: %#v", driver.ImportName)
+	}
+	if !reflect.DeepEqual(driver.CreateSnapshotCalled, []string{sharedBaseSnapshot}) {
+		t.Fatalf("bad: %#v", driver.CreateSnapshotCalled)
+	}
+	expected := []string{"clonevm", "packer-base-test", "--snapshot", sharedBaseSnapshot, "--options", "Link", "--name", "bar", "--register"}
+	if !reflect.DeepEqual(driver.VBoxManageCalls[2], expected) {
+		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+	}
+	if name := state.Get("vmName"); name != "bar" {
+		t.Fatalf("bad: %#v", name)
+	}
+
+	// The base is tagged for gc_orphans, the clone is collected as well if
+	// the build crashes
+	if call := driver.VBoxManageCalls[1]; call[0] != "setextradata" || call[1] != "packer-base-test" {
+		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+	}
+	if call := driver.VBoxManageCalls[3]; call[0] != "setextradata" || call[1] != "bar" {
+		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+	}
+}
+
+func TestSharedBaseName(t *testing.T) {
+	dir := t.TempDir()
+	ovfPath := filepath.Join(dir, "foo.ovf")
+	diskPath := filepath.Join(dir, "foo-disk001.vmdk")
+	descriptor := `<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1">
+  <References>
+    <File ovf:id="file1" ovf:href="foo-disk001.vmdk"/>
+  </References>
+</Envelope>`
+	if err := os.WriteFile(ovfPath, []byte(descriptor), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := os.WriteFile(diskPath, []byte("disk"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	name, err := sharedBaseName(ovfPath, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if again, _ := sharedBaseName(ovfPath, nil); again != name {
+		t.Fatalf("the name should be stable: %s != %s", again, name)
+	}
+	if other, _ := sharedBaseName(ovfPath, []string{"--eula", "accept"}); other == name {
+		t.Fatal("the import flags should change the name")
+	}
+
+	// A disk image replaced with the same path and size
+	mtime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(diskPath, mtime, mtime); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if other, _ := sharedBaseName(ovfPath, nil); other == name {
+		t.Fatal("the modification time of the disk should change the name")
+	}
+
+	if _, err := sharedBaseName(filepath.Join(dir, "missing.ova"), nil); err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestStepImport_SharedBaseExists(t *testing.T) {
+	t.Setenv("PACKER_CACHE_DIR", t.TempDir())
+	state := testState(t)
+	state.Put("vm_path", "foo.ova")
+
+	step := &StepImport{
+		Name:       "bar",
+		SharedBase: true,
+		BaseName:   "packer-base-test",
+	}
+
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	snapshotTree, err := vboxcommon.ParseSnapshotData(`SnapshotName="packer-base"
+SnapshotUUID="00000000-0000-0000-0000-000000000000"
+CurrentSnapshotUUID="00000000-0000-0000-0000-000000000000"`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	driver.LoadSnapshotsResult = snapshotTree
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	// Neither imported nor snapshotted again
+	if driver.ImportCalled {
+		t.Fatal("import should not be called")
+	}
+	if len(driver.CreateSnapshotCalled) != 0 {
+		t.Fatal("snapshot should not be taken")
+	}
+	if len(driver.VBoxManageCalls) != 4 || driver.VBoxManageCalls[2][0] != "clonevm" {
+		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+	}
+
+	// Every build refreshes the tag of the base it uses
+	if call := driver.VBoxManageCalls[1]; call[0] != "setextradata" || call[1] != "packer-base-test" {
+		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+	}
+}
+
+func TestStepImport_Force(t *testing.T) {
+	state := testState(t)
+	state.Put("vm_path", "foo")
+	step := &StepImport{Name: "bar", Force: true}
+
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	driver.VBoxManageWithOutputResults = map[string]string{
+		"list vms": `"bar" {00000000-0000-0000-0000-000000000001}`,
+		"getextradata 00000000-0000-0000-0000-000000000001 Packer/Kept": "Value: 2024-01-01T00:00:00Z",
+	}
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+	// The kept VM is deleted before the import
+	if driver.DeleteName != "00000000-0000-0000-0000-000000000001" {
+		t.Fatalf("bad: %#v", driver.DeleteName)
+	}
+	if !driver.ImportCalled {
+		t.Fatal("import should be called")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"fmt"
+	"strings"
+
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+)
+
+// The ways to pick one of several snapshots matching attach_snapshot.
+const (
+	attachSnapshotStrategyLatest        = "latest"
+	attachSnapshotStrategyDeepest       = "deepest"
+	attachSnapshotStrategyCurrentBranch = "current-branch"
+)
+
+var attachSnapshotStrategies = []string{
+	attachSnapshotStrategyLatest,
+	attachSnapshotStrategyDeepest,
+	attachSnapshotStrategyCurrentBranch,
+}
+
+// findSnapshots returns the snapshots matching a UUID, a slash separated
+// path of names from the root, or a name.
+func findSnapshots(snapshotTree *vboxcommon.VBoxSnapshot, spec string) []*vboxcommon.VBoxSnapshot {
+	if sn := snapshotTree.GetSnapshotByUUID(strings.Trim(spec, "{}")); sn != nil {
+		return []*vboxcommon.VBoxSnapshot{sn}
+	}
+	if strings.Contains(spec, "/") {
+		return snapshotTree.GetSnapshotsByPath(spec)
+	}
+	return snapshotTree.GetSnapshotsByName(spec)
+}
+
+// resolveSnapshot finds the single snapshot spec refers to, using strategy to
+// choose between several matches. The "latest" strategy needs the time
+// stamps loaded with LoadSnapshotTimeStamps.
+func resolveSnapshot(snapshotTree *vboxcommon.VBoxSnapshot, spec string, strategy string) (*vboxcommon.VBoxSnapshot, error) {
+	candidates := findSnapshots(snapshotTree, spec)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("Snapshot %s does not exist", spec)
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	var chosen []*vboxcommon.VBoxSnapshot
+	switch strategy {
+	case attachSnapshotStrategyLatest:
+		for _, sn := range candidates {
+			if len(chosen) == 0 || sn.TimeStamp.After(chosen[0].TimeStamp) {
+				chosen = []*vboxcommon.VBoxSnapshot{sn}
+			} else if sn.TimeStamp.Equal(chosen[0].TimeStamp) {
+				chosen = append(chosen, sn)
+			}
+		}
+	case attachSnapshotStrategyDeepest:
+		depth := -1
+		for _, sn := range candidates {
+			d := 0
+			for parent := sn.Parent; parent != nil; parent = parent.Parent {
+				d++
+			}
+			if d > depth {
+				depth = d
+				chosen = []*vboxcommon.VBoxSnapshot{sn}
+			} else if d == depth {
+				chosen = append(chosen, sn)
+			}
+		}
+	case attachSnapshotStrategyCurrentBranch:
+		// The candidate closest to the current snapshot on its branch.
+		for sn := snapshotTree.GetCurrentSnapshot(); sn != nil && len(chosen) == 0; sn = sn.Parent {
+			for _, candidate := range candidates {
+				if candidate == sn {
+					chosen = append(chosen, sn)
+				}
+			}
+		}
+		if len(chosen) == 0 {
+			return nil, fmt.Errorf("None of the snapshots matching %s is on the branch of the current snapshot, candidates are:\n%s",
+				spec, describeSnapshots(candidates))
+		}
+	default:
+		chosen = candidates
+	}
+
+	if len(chosen) > 1 {
+		return nil, fmt.Errorf("Snapshot %s is ambiguous, set attach_snapshot to a UUID or a path, or set attach_snapshot_strategy. Candidates are:\n%s",
+			spec, describeSnapshots(chosen))
+	}
+	return chosen[0], nil
+}
+
+// describeSnapshots lists snapshots with their UUID and the path of their
+// parent, one per line.
+func describeSnapshots(snapshots []*vboxcommon.VBoxSnapshot) string {
+	var lines []string
+	for _, sn := range snapshots {
+		parent := "/"
+		if sn.Parent != nil {
+			parent = sn.Parent.Path()
+		}
+		lines = append(lines, fmt.Sprintf("  %s (%s) under %s", sn.Name, sn.UUID, parent))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,109 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// A base VM with a "clean" snapshot at several levels of the tree.
+func testCleanSnapshots(t *testing.T) *vboxcommon.VBoxSnapshot {
+	tree, err := vboxcommon.ParseSnapshotData(`SnapshotName="base"
+SnapshotUUID="00000000-0000-0000-0000-000000000000"
+SnapshotName-1="clean"
+SnapshotUUID-1="00000000-0000-0000-0000-000000000001"
+SnapshotName-1-1="patched"
+SnapshotUUID-1-1="00000000-0000-0000-0000-000000000011"
+SnapshotName-1-1-1="clean"
+SnapshotUUID-1-1-1="00000000-0000-0000-0000-000000000111"
+SnapshotName-2="other"
+SnapshotUUID-2="00000000-0000-0000-0000-000000000002"
+SnapshotName-2-1="clean"
+SnapshotUUID-2-1="00000000-0000-0000-0000-000000000021"
+CurrentSnapshotUUID="00000000-0000-0000-0000-000000000011"`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return tree
+}
+
+func TestResolveSnapshot(t *testing.T) {
+	tree := testCleanSnapshots(t)
+
+	sn, err := resolveSnapshot(tree, "{00000000-0000-0000-0000-000000000021}", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "base/other/clean", sn.Path())
+
+	sn, err = resolveSnapshot(tree, "base/clean/patched/clean", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000111", sn.UUID)
+
+	_, err = resolveSnapshot(tree, "missing", "")
+	assert.Error(t, err)
+
+	_, err = resolveSnapshot(tree, "clean", "")
+	assert.EqualError(t, err, `Snapshot clean is ambiguous, set attach_snapshot to a UUID or a path, or set attach_snapshot_strategy. Candidates are:
+  clean (00000000-0000-0000-0000-000000000111) under base/clean/patched
+  clean (00000000-0000-0000-0000-000000000001) under base
+  clean (00000000-0000-0000-0000-000000000021) under base/other`)
+}
+
+func TestResolveSnapshot_strategies(t *testing.T) {
+	tree := testCleanSnapshots(t)
+
+	sn, err := resolveSnapshot(tree, "clean", attachSnapshotStrategyDeepest)
+	assert.NoError(t, err)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000111", sn.UUID)
+
+	sn, err = resolveSnapshot(tree, "clean", attachSnapshotStrategyCurrentBranch)
+	assert.NoError(t, err)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000001", sn.UUID)
+
+	now := time.Now()
+	for i, uuid := range []string{
+		"00000000-0000-0000-0000-000000000001",
+		"00000000-0000-0000-0000-000000000111",
+		"00000000-0000-0000-0000-000000000021",
+	} {
+		tree.GetSnapshotByUUID(uuid).TimeStamp = now.Add(time.Duration(i) * time.Hour)
+	}
+	sn, err = resolveSnapshot(tree, "clean", attachSnapshotStrategyLatest)
+	assert.NoError(t, err)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000021", sn.UUID)
+
+	// A single match needs no strategy
+	_, err = resolveSnapshot(tree, "other", attachSnapshotStrategyCurrentBranch)
+	assert.NoError(t, err)
+
+	// None of the matches is on the current branch
+	tree.GetSnapshotByUUID("00000000-0000-0000-0000-000000000001").IsCurrent = false
+	tree.GetSnapshotByUUID("00000000-0000-0000-0000-000000000011").IsCurrent = false
+	tree.IsCurrent = true
+	_, err = resolveSnapshot(tree, "clean", attachSnapshotStrategyCurrentBranch)
+	assert.Error(t, err)
+}
+
+func TestStepSetSnapshot_ambiguous(t *testing.T) {
+	state := testState(t)
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	driver.LoadSnapshotsResult = testCleanSnapshots(t)
+
+	step := &StepSetSnapshot{Name: "foo", AttachSnapshot: "clean"}
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	assert.Empty(t, driver.SetSnapshotCalled)
+
+	step = &StepSetSnapshot{Name: "foo", AttachSnapshot: "clean", Strategy: attachSnapshotStrategyDeepest}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	assert.Equal(t, "00000000-0000-0000-0000-000000000111", driver.SetSnapshotCalled[0].UUID)
+}
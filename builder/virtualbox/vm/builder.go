@@ -31,7 +31,11 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 		return nil, warnings, errs
 	}
 
-	return nil, warnings, nil
+	generatedData := []string{"TargetSnapshot", "TargetSnapshotUUID"}
+	generatedData = append(generatedData, vboxcommon.GuestProxyGeneratedData...)
+	generatedData = append(generatedData, vboxcommon.LiveSnapshotGeneratedData...)
+	generatedData = append(generatedData, vboxcommon.CloudExportGeneratedData...)
+	return generatedData, warnings, nil
 }
 
 // Run executes a Packer build and returns a packersdk.Artifact representing
@@ -50,9 +54,69 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 	state.Put("hook", hook)
 	state.Put("ui", ui)
 
-	// Build the steps.
+	steps := b.steps()
+
+	// Run the steps.
+	b.runner = commonsteps.NewRunnerWithPauseFn(steps, b.config.PackerConfig, ui, state)
+	b.runner.Run(ctx, state)
+
+	// Report any errors.
+	if rawErr, ok := state.GetOk("error"); ok {
+		return nil, rawErr.(error)
+	}
+
+	// If we were interrupted or cancelled, then just exit.
+	if _, ok := state.GetOk(multistep.StateCancelled); ok {
+		return nil, errors.New("build was cancelled")
+	}
+
+	if _, ok := state.GetOk(multistep.StateHalted); ok {
+		return nil, errors.New("build was halted")
+	}
+
+	if b.config.SkipExport {
+		return nil, nil
+	}
+
+	generatedData := map[string]interface{}{"generated_data": state.Get("generated_data")}
+	metadata, _ := state.Get("artifactMetadata").(*vboxcommon.ArtifactMetadata)
+	// Without a driver, destroying the artifact leaves the VM alone
+	var artifactDriver vboxcommon.Driver
+	if b.config.DeleteWithArtifact {
+		artifactDriver = driver
+	}
+	if b.config.ExportDiskOnly && metadata != nil && len(metadata.Exports) == 1 {
+		return vboxcommon.NewDiskArtifact(b.config.OutputDir, artifactDriver, metadata, generatedData)
+	}
+	return vboxcommon.NewArtifact(b.config.OutputDir, artifactDriver, metadata, generatedData)
+}
+
+// steps returns the steps of the build.
+func (b *Builder) steps() []multistep.Step {
+	// A resumed VM boots from disks that are already installed.
+	bootWait, bootCommand := b.config.BootWait, b.config.FlatBootCommand()
+	var resumedMilestone string
+	if b.config.ResumeFromSnapshot != "" {
+		bootWait, bootCommand = 0, ""
+		resumedMilestone = b.config.completedMilestones[len(b.config.completedMilestones)-1]
+	}
+	// The settings of a VM restored from a saved state can't change, it
+	// keeps those of the build that saved it.
+	saved := b.config.resumeSavedState()
+	liveSnapshots := &vboxcommon.StepLiveSnapshots{
+		Milestones: b.config.LiveSnapshotMilestones,
+		Prefix:     b.config.LiveSnapshotPrefix,
+		Keep:       b.config.KeepLiveSnapshots,
+		Completed:  b.config.completedMilestones,
+	}
+
 	steps := []multistep.Step{
 		new(vboxcommon.StepSuppressMessages),
+		&vboxcommon.StepGarbageCollect{
+			Enabled:   b.config.GCOrphans,
+			OlderThan: b.config.GCOrphansOlderThan,
+			DryRun:    b.config.GCOrphansDryRun,
+		},
 		&commonsteps.StepCreateFloppy{
 			Files:       b.config.FloppyConfig.FloppyFiles,
 			Directories: b.config.FloppyConfig.FloppyDirectories,
@@ -67,9 +131,20 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		&StepSetSnapshot{
 			Name:           b.config.VMName,
 			AttachSnapshot: b.config.AttachSnapshot,
+			Strategy:       b.config.AttachSnapshotStrategy,
 			KeepRegistered: b.config.KeepRegistered,
+			DiscardState:   b.config.ResumeDiscardState,
+		},
+		// Before anything changes the VM, for a rollback to restore it as
+		// it was
+		&StepSafetySnapshot{
+			Name: b.config.VMName,
+			Keep: b.config.KeepSafetySnapshot,
+		},
+		&vboxcommon.StepHTTPIPDiscover{
+			IPFamily:   b.config.IPFamily,
+			NATNetwork: b.config.NATNetwork,
 		},
-		new(vboxcommon.StepHTTPIPDiscover),
 		commonsteps.HTTPServerFromHTTPConfig(&b.config.HTTPConfig),
 		&vboxcommon.StepDownloadGuestAdditions{
 			GuestAdditionsMode:   b.config.GuestAdditionsMode,
@@ -80,34 +155,77 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		&StepImport{
 			Name: b.config.VMName,
 		},
-		&vboxcommon.StepAttachISOs{
-			AttachBootISO:           false,
-			ISOInterface:            b.config.GuestAdditionsInterface,
-			GuestAdditionsMode:      b.config.GuestAdditionsMode,
-			GuestAdditionsInterface: b.config.GuestAdditionsInterface,
-		},
-		&vboxcommon.StepConfigureVRDP{
-			VRDPBindAddress: b.config.VRDPBindAddress,
-			VRDPPortMin:     b.config.VRDPPortMin,
-			VRDPPortMax:     b.config.VRDPPortMax,
-		},
-		new(vboxcommon.StepAttachFloppy),
+	}
+	if !saved {
+		steps = append(steps,
+			&vboxcommon.StepAttachISOs{
+				AttachBootISO:           false,
+				ISOInterface:            b.config.GuestAdditionsInterface,
+				GuestAdditionsMode:      b.config.GuestAdditionsMode,
+				GuestAdditionsInterface: b.config.GuestAdditionsInterface,
+			},
+			&vboxcommon.StepConfigureVRDP{
+				VRDPBindAddress: b.config.VRDPBindAddress,
+				VRDPPortMin:     b.config.VRDPPortMin,
+				VRDPPortMax:     b.config.VRDPPortMax,
+			},
+			new(vboxcommon.StepAttachFloppy),
+			&vboxcommon.StepConfigureMACAddresses{
+				MACAddressPolicy: b.config.MACAddressPolicy,
+				MACAddresses:     b.config.MACAddresses,
+				Restore:          true,
+			},
+		)
+	}
+	steps = append(steps,
 		&vboxcommon.StepPortForwarding{
 			CommConfig:     &b.config.CommConfig.Comm,
 			HostPortMin:    b.config.HostPortMin,
 			HostPortMax:    b.config.HostPortMax,
 			SkipNatMapping: b.config.SkipNatMapping,
+			IPFamily:       b.config.IPFamily,
+			NATNetwork:     b.config.NATNetwork,
+			Saved:          saved,
+		},
+	)
+	if !saved {
+		steps = append(steps,
+			&vboxcommon.StepConfigureNetworkIsolation{
+				NetworkIsolation: b.config.NetworkIsolation,
+				Restore:          true,
+			},
+			&vboxcommon.StepNetworkTrace{
+				Adapters:      b.config.NetworkTrace,
+				OutputDir:     b.config.OutputDir,
+				InArtifact:    b.config.NetworkTraceInArtifact,
+				KeepOnFailure: b.config.KeepNetworkTraceOnFailure,
+			},
+		)
+	}
+	steps = append(steps,
+		&vboxcommon.StepGuestProxy{
+			URL:      b.config.GuestProxyURL,
+			NoProxy:  b.config.GuestNoProxy,
+			Local:    b.config.GuestProxyLocal,
+			PortMin:  b.config.GuestProxyPortMin,
+			PortMax:  b.config.GuestProxyPortMax,
+			IPFamily: b.config.IPFamily,
+			Saved:    saved,
 		},
 		&vboxcommon.StepVBoxManage{
 			Commands: b.config.VBoxManage,
 			Ctx:      b.config.ctx,
 		},
+		&vboxcommon.StepVerifyNetworkIsolation{
+			NetworkIsolation: b.config.NetworkIsolation,
+			AllowedHostPorts: b.config.NetworkIsolationAllowedHostPorts,
+		},
 		&vboxcommon.StepRun{
 			Headless: b.config.Headless,
 		},
 		&vboxcommon.StepTypeBootCommand{
-			BootWait:      b.config.BootWait,
-			BootCommand:   b.config.FlatBootCommand(),
+			BootWait:      bootWait,
+			BootCommand:   bootCommand,
 			VMName:        b.config.VMName,
 			Ctx:           b.config.ctx,
 			GroupInterval: b.config.BootConfig.BootGroupInterval,
@@ -128,7 +246,11 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			GuestAdditionsPath: b.config.GuestAdditionsPath,
 			Ctx:                b.config.ctx,
 		},
-		new(commonsteps.StepProvision),
+		liveSnapshots,
+		&StepResumeProvision{
+			Milestone:     resumedMilestone,
+			LiveSnapshots: liveSnapshots,
+		},
 		&commonsteps.StepCleanupTempKeys{
 			Comm: &b.config.CommConfig.Comm,
 		},
@@ -138,6 +260,7 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Delay:           b.config.PostShutdownDelay,
 			DisableShutdown: b.config.DisableShutdown,
 			ACPIShutdown:    b.config.ACPIShutdown,
+			SaveState:       b.config.SaveState,
 		},
 		&vboxcommon.StepRemoveDevices{},
 		&vboxcommon.StepVBoxManage{
@@ -147,16 +270,50 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 		&StepCreateSnapshot{
 			Name:           b.config.VMName,
 			TargetSnapshot: b.config.TargetSnapshot,
+			Description:    b.config.TargetSnapshotDescription,
+			Provisioners:   b.config.TargetSnapshotProvisioners,
+			BuildName:      b.config.PackerBuildName,
+			PackerVersion:  b.config.PackerCoreVersion,
+			Ctx:            b.config.ctx,
+		},
+		&StepSnapshotRetention{
+			Name:           b.config.VMName,
+			AttachSnapshot: b.config.AttachSnapshot,
+			ExportSnapshot: b.config.ExportSnapshot,
+			Prefix:         b.config.SnapshotRetentionPrefix,
+			Count:          b.config.SnapshotRetentionCount,
+			MaxAge:         b.config.SnapshotRetentionMaxAge,
+		},
+		&StepExportSnapshot{
+			Name:       b.config.VMName,
+			Snapshot:   b.config.ExportSnapshot,
+			SkipExport: b.config.SkipExport,
 		},
 		&vboxcommon.StepExport{
-			Format:         b.config.Format,
-			OutputDir:      b.config.OutputDir,
-			OutputFilename: b.config.OutputFilename,
-			ExportOpts:     b.config.ExportOpts,
-			SkipNatMapping: b.config.SkipNatMapping,
-			SkipExport:     b.config.SkipExport,
+			Format:           b.config.Format,
+			Formats:          b.config.Formats,
+			OutputDir:        b.config.OutputDir,
+			OutputFilename:   b.config.OutputFilename,
+			ExportOpts:       b.config.ExportOpts,
+			Product:          b.config.ExportProductConfig,
+			Transform:        b.config.OVFTransform,
+			Manifest:         b.config.ExportManifestConfig,
+			Cloud:            b.config.CloudExport,
+			DiskOnly:         b.config.ExportDiskOnly,
+			DiskFormat:       b.config.ExportDiskFormat,
+			DiskCompression:  b.config.ExportDiskCompression,
+			Atomic:           b.config.ExportAtomic,
+			SkipNatMapping:   b.config.SkipNatMapping,
+			SkipExport:       b.config.SkipExport,
+			MACAddressPolicy: b.config.ExportMACAddressPolicy,
 		},
-	}
+		&vboxcommon.StepVagrantBox{
+			Config: b.config.VagrantBoxConfig,
+		},
+		&vboxcommon.StepArtifactMetadata{
+			OutputDir: b.config.OutputDir,
+		},
+	)
 
 	if !b.config.SkipExport {
 		steps = append(steps, nil)
@@ -166,28 +323,5 @@ func (b *Builder) Run(ctx context.Context, ui packersdk.Ui, hook packersdk.Hook)
 			Path:  b.config.OutputDir,
 		}
 	}
-	// Run the steps.
-	b.runner = commonsteps.NewRunnerWithPauseFn(steps, b.config.PackerConfig, ui, state)
-	b.runner.Run(ctx, state)
-
-	// Report any errors.
-	if rawErr, ok := state.GetOk("error"); ok {
-		return nil, rawErr.(error)
-	}
-
-	// If we were interrupted or cancelled, then just exit.
-	if _, ok := state.GetOk(multistep.StateCancelled); ok {
-		return nil, errors.New("build was cancelled")
-	}
-
-	if _, ok := state.GetOk(multistep.StateHalted); ok {
-		return nil, errors.New("build was halted")
-	}
-
-	if b.config.SkipExport {
-		return nil, nil
-	}
-
-	generatedData := map[string]interface{}{"generated_data": state.Get("generated_data")}
-	return vboxcommon.NewArtifact(b.config.OutputDir, generatedData)
+	return steps
 }
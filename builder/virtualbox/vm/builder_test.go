@@ -0,0 +1,76 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+)
+
+func TestBuilder_stepsSafetySnapshot(t *testing.T) {
+	versionFile := ".vbox_version"
+	b := new(Builder)
+	b.config.VBoxVersionFile = &versionFile
+	steps := b.steps()
+
+	index := func(step multistep.Step) int {
+		for i, s := range steps {
+			if reflect.TypeOf(s) == reflect.TypeOf(step) {
+				return i
+			}
+		}
+		t.Fatalf("no %T step", step)
+		return -1
+	}
+
+	safety := index(new(StepSafetySnapshot))
+	if set := index(new(StepSetSnapshot)); safety != set+1 {
+		t.Fatalf("the safety snapshot should directly follow StepSetSnapshot: %d, %d", safety, set)
+	}
+	// None of the steps changing the VM may run before it
+	for _, step := range []multistep.Step{
+		new(vboxcommon.StepAttachISOs),
+		new(vboxcommon.StepConfigureVRDP),
+		new(vboxcommon.StepAttachFloppy),
+		new(vboxcommon.StepPortForwarding),
+		new(vboxcommon.StepConfigureNetworkIsolation),
+		new(vboxcommon.StepConfigureMACAddresses),
+		new(vboxcommon.StepNetworkTrace),
+		new(vboxcommon.StepVBoxManage),
+		new(vboxcommon.StepRun),
+	} {
+		if i := index(step); i < safety {
+			t.Fatalf("%T runs before the safety snapshot", step)
+		}
+	}
+}
+
+func TestBuilder_stepsResumeSavedState(t *testing.T) {
+	versionFile := ".vbox_version"
+	b := new(Builder)
+	b.config.VBoxVersionFile = &versionFile
+	b.config.ResumeFromSnapshot = "packer-milestone-updates"
+	b.config.completedMilestones = []string{"updates"}
+
+	// A VM restored from a saved state can't be modified
+	for _, step := range b.steps() {
+		switch step.(type) {
+		case *vboxcommon.StepAttachISOs, *vboxcommon.StepConfigureVRDP, *vboxcommon.StepAttachFloppy,
+			*vboxcommon.StepConfigureNetworkIsolation, *vboxcommon.StepConfigureMACAddresses,
+			*vboxcommon.StepNetworkTrace:
+			t.Fatalf("%T changes the saved VM", step)
+		case *vboxcommon.StepPortForwarding:
+			if !step.(*vboxcommon.StepPortForwarding).Saved {
+				t.Fatal("the port forwarding rule of the saved VM should be reused")
+			}
+		case *StepResumeProvision:
+			if milestone := step.(*StepResumeProvision).Milestone; milestone != "updates" {
+				t.Fatalf("bad: %#v", milestone)
+			}
+		}
+	}
+}
@@ -9,6 +9,7 @@ package vm
 import (
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/bootcommand"
@@ -22,27 +23,44 @@ import (
 
 // Config is the configuration structure for the builder.
 type Config struct {
-	common.PackerConfig             `mapstructure:",squash"`
-	commonsteps.HTTPConfig          `mapstructure:",squash"`
-	commonsteps.FloppyConfig        `mapstructure:",squash"`
-	commonsteps.CDConfig            `mapstructure:",squash"`
-	bootcommand.BootConfig          `mapstructure:",squash"`
-	vboxcommon.ExportConfig         `mapstructure:",squash"`
-	vboxcommon.OutputConfig         `mapstructure:",squash"`
-	vboxcommon.RunConfig            `mapstructure:",squash"`
-	vboxcommon.CommConfig           `mapstructure:",squash"`
-	vboxcommon.ShutdownConfig       `mapstructure:",squash"`
-	vboxcommon.VBoxManageConfig     `mapstructure:",squash"`
-	vboxcommon.VBoxVersionConfig    `mapstructure:",squash"`
-	vboxcommon.GuestAdditionsConfig `mapstructure:",squash"`
+	common.PackerConfig               `mapstructure:",squash"`
+	commonsteps.HTTPConfig            `mapstructure:",squash"`
+	commonsteps.FloppyConfig          `mapstructure:",squash"`
+	commonsteps.CDConfig              `mapstructure:",squash"`
+	bootcommand.BootConfig            `mapstructure:",squash"`
+	vboxcommon.ExportConfig           `mapstructure:",squash"`
+	vboxcommon.OutputConfig           `mapstructure:",squash"`
+	vboxcommon.RunConfig              `mapstructure:",squash"`
+	vboxcommon.CommConfig             `mapstructure:",squash"`
+	vboxcommon.ShutdownConfig         `mapstructure:",squash"`
+	vboxcommon.VBoxManageConfig       `mapstructure:",squash"`
+	vboxcommon.VBoxVersionConfig      `mapstructure:",squash"`
+	vboxcommon.GuestAdditionsConfig   `mapstructure:",squash"`
+	vboxcommon.NetworkIsolationConfig `mapstructure:",squash"`
+	vboxcommon.MACAddressConfig       `mapstructure:",squash"`
+	vboxcommon.NetworkTraceConfig     `mapstructure:",squash"`
+	vboxcommon.GuestProxyConfig       `mapstructure:",squash"`
+	vboxcommon.LiveSnapshotConfig     `mapstructure:",squash"`
+	vboxcommon.GCConfig               `mapstructure:",squash"`
+	vboxcommon.ExportProductConfig    `mapstructure:",squash"`
+	vboxcommon.ExportManifestConfig   `mapstructure:",squash"`
+	vboxcommon.VagrantBoxConfig       `mapstructure:",squash"`
 	// This is the name of the virtual machine to which the
 	//  builder shall attach.
 	VMName string `mapstructure:"vm_name" required:"true"`
 	// Default to `null/empty`. The name of an
 	//  **existing** snapshot to which the builder shall attach the VM before
 	//  starting it. If no snapshot is specified the builder will simply start the
-	//  VM from it's current state i.e. snapshot.
+	//  VM from it's current state i.e. snapshot. Besides a name, this can be the
+	//  UUID of the snapshot, or its path of names from the root snapshot
+	//  separated by slashes, such as `base/clean`.
 	AttachSnapshot string `mapstructure:"attach_snapshot" required:"false"`
+	// How to choose between several snapshots matching `attach_snapshot`.
+	//  `latest` picks the one taken last, `deepest` the one furthest from the
+	//  root snapshot, and `current-branch` the one closest to the current
+	//  snapshot among its ancestors. By default, an ambiguous `attach_snapshot`
+	//  is an error listing the candidates.
+	AttachSnapshotStrategy string `mapstructure:"attach_snapshot_strategy" required:"false"`
 	// Default to `null/empty`. The name of the
 	//   snapshot which shall be created after all provisioners has been run by the
 	//   builder. If no target snapshot is specified and `keep_registered` is set to
@@ -50,22 +68,107 @@ type Config struct {
 	//   before the builder has been executed, which will revert all changes applied
 	//   by the provisioners. This is handy if only an export shall be created and no
 	//   further snapshot is required.
+	//
+	//   The name is a template, which can use functions such as `{{timestamp}}`
+	//   and `{{isotime "2006-01-02"}}` as well as the variables `{{ .BuildName }}`,
+	//   `{{ .VMName }}`, and `{{ .GitCommit }}`, `{{ .GitBranch }}` and
+	//   `{{ .GitDescribe }}`, which describe the git checkout Packer runs from and
+	//   are empty outside of one.
 	TargetSnapshot string `mapstructure:"target_snapshot" required:"false"`
+	// The description of the target snapshot. This is a template which can use
+	//   `{{ .BuildName }}`, `{{ .VMName }}`, `{{ .SnapshotName }}`,
+	//   `{{ .PackerVersion }}`, `{{ .PluginVersion }}`, `{{ .SourceSnapshotName }}`
+	//   and `{{ .SourceSnapshotUUID }}`, the last two describing the snapshot the
+	//   VM was started from, and `{{ .Provisioners }}`, the
+	//   `target_snapshot_provisioners`. By default it records the Packer and
+	//   plugin versions, the build name, the source snapshot and the
+	//   provisioners.
+	TargetSnapshotDescription string `mapstructure:"target_snapshot_description" required:"false"`
+	// The provisioners of the build, in the order they run, such as
+	//   `["shell.setup", "ansible"]`, which `{{ .Provisioners }}` lists in the
+	//   `target_snapshot_description`. Packer doesn't tell the builder which
+	//   provisioners it runs, so they are listed here, as the provisioner
+	//   blocks of the build name them.
+	TargetSnapshotProvisioners []string `mapstructure:"target_snapshot_provisioners" required:"false"`
 	// Defaults to `false`. If set to `true`,
 	//   overwrite an existing `target_snapshot`. Otherwise the builder will yield an
 	//   error if the specified target snapshot already exists.
 	DeleteTargetSnapshot bool `mapstructure:"force_delete_snapshot" required:"false"`
+	// Also delete the `target_snapshot` taken by the build when the artifact
+	// is destroyed, for example by a post-processor that does not keep its
+	// input artifact. The VM itself is never deleted, and the snapshot is
+	// not deleted while the VM is running. Defaults to `false`.
+	DeleteWithArtifact bool `mapstructure:"delete_with_artifact" required:"false"`
+	// The name prefix of the snapshots managed by the retention options
+	//   below, for example `nightly-`. Snapshots whose name does not start with
+	//   this prefix are never deleted. Required when `snapshot_retention_count`
+	//   or `snapshot_retention_max_age` is set.
+	SnapshotRetentionPrefix string `mapstructure:"snapshot_retention_prefix" required:"false"`
+	// Keep only the newest snapshots matching `snapshot_retention_prefix`,
+	//   including the one just created, and delete the others once the target
+	//   snapshot has been taken. The current snapshot, the snapshots given by
+	//   `attach_snapshot` and `export_snapshot` and their ancestors are never
+	//   deleted. Defaults to `0`,
+	//   which keeps all of them.
+	SnapshotRetentionCount int `mapstructure:"snapshot_retention_count" required:"false"`
+	// Delete snapshots matching `snapshot_retention_prefix` that were taken
+	//   longer ago than this duration, for example `720h`. The same snapshots as
+	//   for `snapshot_retention_count` are never deleted. Defaults to `0`, which
+	//   keeps all of them.
+	SnapshotRetentionMaxAge time.Duration `mapstructure:"snapshot_retention_max_age" required:"false"`
+	// The name of a live snapshot taken at one of the
+	//   `live_snapshot_milestones` of an earlier build, such as
+	//   `packer-milestone-updates`, to resume that build from. The snapshot is
+	//   attached like `attach_snapshot` and the VM is restored running, with
+	//   the memory it was saved with, without typing the `boot_command`.
+	//   All the provisioners run again, and must skip what was done before
+	//   the milestone themselves: `{{ build.CompletedMilestones }}` lists the
+	//   resumed milestone and the ones before it, separated by commas, for
+	//   example for a `shell` provisioner to exit early with
+	//   `case ",${build.CompletedMilestones}," in *,updates,*) exit 0;; esac`.
+	//   The build fails if a provisioner reports one of those milestones
+	//   again, or if provisioning finishes without reaching the others. The
+	//   settings the VM was saved with cannot change, so the steps changing
+	//   them are skipped and the communicator keeps the host port of the
+	//   earlier build: `vboxmanage`, `network_trace`, the floppy and CD options
+	//   cannot be used, and the media the snapshot has attached must still
+	//   exist. Cannot be used with `attach_snapshot`.
+	ResumeFromSnapshot string `mapstructure:"resume_from_snapshot" required:"false"`
+	// Discard the memory state of the `resume_from_snapshot` snapshot, and
+	//   boot the VM from its disks as they were at the milestone instead,
+	//   which are only crash consistent. The VM can then be configured like
+	//   any other. Defaults to `false`.
+	ResumeDiscardState bool `mapstructure:"resume_discard_state" required:"false"`
 	// Set this to `true` if you would like to keep
 	//   the VM attached to the snapshot specified by `attach_snapshot`. Otherwise
 	//   the builder will reset the VM to the snapshot to which the VM was attached
 	//   before the builder started. Defaults to `false`.
 	KeepRegistered bool `mapstructure:"keep_registered" required:"false"`
+	// Packer takes a safety snapshot of the VM before it changes its
+	//   settings or starts it, and rolls the VM back to it and deletes it if
+	//   the build fails or is cancelled, so that the VM is never left half
+	//   configured or half provisioned. Set this to
+	//   `true` to keep the safety snapshot after a successful build, otherwise
+	//   it is deleted. Cannot be used with `target_snapshot`, which the kept
+	//   snapshot would separate from `attach_snapshot`. When a failed build
+	//   took snapshots, such as the live snapshots kept to resume it, the VM
+	//   is rolled back and the safety snapshot they were taken on is kept.
+	//   Defaults to `false`.
+	KeepSafetySnapshot bool `mapstructure:"keep_safety_snapshot" required:"false"`
 	// Defaults to `false`. When enabled, Packer will
 	//   not export the VM. Useful if the builder should be applied again on the created
 	//   target snapshot.
 	SkipExport bool `mapstructure:"skip_export" required:"false"`
+	// Export the state of the VM at this snapshot rather than its current
+	//   state. The snapshot is given like `attach_snapshot`, by name, UUID or
+	//   path, and may be the `target_snapshot` of the build. It is cloned to a
+	//   temporary VM with `VBoxManage clonevm --snapshot`, which is exported and
+	//   then deleted, so the VM itself stays attached to its current snapshot.
+	//   Cannot be used with `skip_export`.
+	ExportSnapshot string `mapstructure:"export_snapshot" required:"false"`
 
-	ctx interpolate.Context
+	ctx                 interpolate.Context
+	completedMilestones []string
 }
 
 func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
@@ -78,6 +181,8 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 				"boot_command",
 				"guest_additions_path",
 				"guest_additions_url",
+				"target_snapshot",
+				"target_snapshot_description",
 				"vboxmanage",
 				"vboxmanage_post",
 			},
@@ -92,8 +197,30 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		c.PostShutdownDelay = 2 * time.Second
 	}
 
+	// The HTTP server has to listen on IPv6 for the guest to reach it through
+	// the host loopback address of the NAT Network.
+	if c.IPFamily == vboxcommon.IPFamilyIPv6 && c.HTTPAddress == "" && c.HTTPInterface == "" {
+		c.HTTPAddress = "::"
+	}
+
 	// Prepare the errors
 	var errs *packersdk.MultiError
+	if c.TargetSnapshotDescription == "" {
+		c.TargetSnapshotDescription = defaultTargetSnapshotDescription
+	}
+	if err := interpolate.Validate(c.TargetSnapshotDescription, &c.ctx); err != nil {
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("Error parsing target_snapshot_description template: %s", err))
+	}
+	if c.TargetSnapshot != "" {
+		ctx := c.ctx
+		ctx.Data = newTargetSnapshotTemplateData(c.PackerBuildName, c.VMName, c.TargetSnapshot)
+		c.TargetSnapshot, err = interpolate.Render(c.TargetSnapshot, &ctx)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs,
+				fmt.Errorf("Error rendering target_snapshot template: %s", err))
+		}
+	}
 	errs = packersdk.MultiErrorAppend(errs, c.ExportConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.FloppyConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.CDConfig.Prepare(&c.ctx)...)
@@ -106,6 +233,15 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 	errs = packersdk.MultiErrorAppend(errs, c.VBoxVersionConfig.Prepare(c.CommConfig.Comm.Type)...)
 	errs = packersdk.MultiErrorAppend(errs, c.BootConfig.Prepare(&c.ctx)...)
 	errs = packersdk.MultiErrorAppend(errs, c.GuestAdditionsConfig.Prepare(c.CommConfig.Comm.Type)...)
+	errs = packersdk.MultiErrorAppend(errs, c.VagrantBoxConfig.Prepare(&c.ExportConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.ExportManifestConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.ExportProductConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.GCConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.LiveSnapshotConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.GuestProxyConfig.Prepare(&c.ctx, &c.NetworkIsolationConfig)...)
+	errs = packersdk.MultiErrorAppend(errs, c.NetworkTraceConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.MACAddressConfig.Prepare(&c.ctx)...)
+	errs = packersdk.MultiErrorAppend(errs, c.NetworkIsolationConfig.Prepare(&c.ctx, &c.CommConfig)...)
 	if c.GuestAdditionsInterface == "" {
 		c.GuestAdditionsInterface = "ide"
 	}
@@ -117,6 +253,86 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 			fmt.Errorf("vm_name is required"))
 	}
 
+	if c.AttachSnapshotStrategy != "" {
+		valid := false
+		for _, strategy := range attachSnapshotStrategies {
+			valid = valid || c.AttachSnapshotStrategy == strategy
+		}
+		if !valid {
+			errs = packersdk.MultiErrorAppend(errs,
+				fmt.Errorf("attach_snapshot_strategy must be one of %s", strings.Join(attachSnapshotStrategies, ", ")))
+		}
+	}
+
+	if c.KeepSafetySnapshot && c.TargetSnapshot != "" {
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("keep_safety_snapshot cannot be used with target_snapshot, the next build would not find the target snapshot under attach_snapshot"))
+	}
+
+	if c.ResumeFromSnapshot != "" {
+		if c.AttachSnapshot != "" {
+			errs = packersdk.MultiErrorAppend(errs,
+				fmt.Errorf("resume_from_snapshot and attach_snapshot cannot both be set"))
+		}
+		if i, ok := c.LiveSnapshotConfig.Milestone(c.ResumeFromSnapshot); ok {
+			c.completedMilestones = c.LiveSnapshotMilestones[:i+1]
+		} else {
+			errs = packersdk.MultiErrorAppend(errs,
+				fmt.Errorf("resume_from_snapshot %s is not the live snapshot of one of live_snapshot_milestones", c.ResumeFromSnapshot))
+		}
+		// Resuming attaches the live snapshot, which is checked below.
+		c.AttachSnapshot = c.ResumeFromSnapshot
+
+		if c.resumeSavedState() {
+			saved := []struct {
+				option string
+				set    bool
+			}{
+				{"vboxmanage", len(c.VBoxManage) > 0},
+				{"network_trace", len(c.NetworkTrace) > 0},
+				{"floppy_files", len(c.FloppyFiles) > 0},
+				{"floppy_dirs", len(c.FloppyDirectories) > 0},
+				{"floppy_content", len(c.FloppyContent) > 0},
+				{"cd_files", len(c.CDFiles) > 0},
+				{"cd_content", len(c.CDContent) > 0},
+			}
+			for _, o := range saved {
+				if o.set {
+					errs = packersdk.MultiErrorAppend(errs,
+						fmt.Errorf("%s cannot change a VM resumed with its saved state, set resume_discard_state", o.option))
+				}
+			}
+		}
+	} else if c.ResumeDiscardState {
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("resume_discard_state requires resume_from_snapshot"))
+	}
+
+	if c.SnapshotRetentionCount < 0 {
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("snapshot_retention_count must not be negative"))
+	}
+	if c.SnapshotRetentionMaxAge < 0 {
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("snapshot_retention_max_age must not be negative"))
+	}
+	retention := c.SnapshotRetentionCount > 0 || c.SnapshotRetentionMaxAge > 0
+	if retention && c.SnapshotRetentionPrefix == "" {
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("snapshot_retention_prefix is required by snapshot_retention_count and snapshot_retention_max_age"))
+	} else if !retention && c.SnapshotRetentionPrefix != "" {
+		errs = packersdk.MultiErrorAppend(errs,
+			fmt.Errorf("snapshot_retention_prefix requires snapshot_retention_count or snapshot_retention_max_age"))
+	}
+
+	if c.ExportSnapshot != "" && c.SkipExport {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("export_snapshot cannot be used with skip_export"))
+	}
+
+	if c.SaveState && (!c.SkipExport || (c.TargetSnapshot == "" && !c.KeepRegistered)) {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("save_state requires skip_export and either target_snapshot or keep_registered"))
+	}
+
 	// Warnings
 	var warnings []string
 	if c.TargetSnapshot == "" && c.SkipExport {
@@ -125,7 +341,7 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 				"You might lose all changes applied by this run, the next time you execute packer.")
 	}
 
-	if c.ShutdownCommand == "" {
+	if c.ShutdownCommand == "" && !c.SaveState {
 		warnings = append(warnings,
 			"A shutdown_command was not specified. Without a shutdown command, Packer\n"+
 				"will forcibly halt the virtual machine, which may result in data loss.")
@@ -155,13 +371,17 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 				if nil == snapshotTree {
 					errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("No snapshots defined on VM %s. Unable to attach to %s", c.VMName, c.AttachSnapshot))
 				} else {
-					snapshots := snapshotTree.GetSnapshotsByName(c.AttachSnapshot)
-					if 0 >= len(snapshots) {
-						errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("Snapshot %s does not exist on VM %s", c.AttachSnapshot, c.VMName))
-					} else if 1 < len(snapshots) {
-						errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("Multiple Snapshots with name %s exist on VM %s", c.AttachSnapshot, c.VMName))
+					var snapshot *vboxcommon.VBoxSnapshot
+					if c.AttachSnapshotStrategy == attachSnapshotStrategyLatest {
+						err = driver.LoadSnapshotTimeStamps(c.VMName, snapshotTree)
+					}
+					if err == nil {
+						snapshot, err = resolveSnapshot(snapshotTree, c.AttachSnapshot, c.AttachSnapshotStrategy)
+					}
+					if err != nil {
+						errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("Unable to attach snapshot on VM %s: %s", c.VMName, err))
 					} else {
-						attachSnapshot = snapshots[0]
+						attachSnapshot = snapshot
 					}
 				}
 			}
@@ -197,6 +417,12 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 					}
 				}
 			}
+			if c.ExportSnapshot != "" && !c.exportsTargetSnapshot() {
+				log.Printf("Checking configuration export_snapshot [%s]", c.ExportSnapshot)
+				if nil == snapshotTree || len(findSnapshots(snapshotTree, c.ExportSnapshot)) == 0 {
+					errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("Snapshot %s to export does not exist on VM %s", c.ExportSnapshot, c.VMName))
+				}
+			}
 		}
 	}
 	// Check for any errors.
@@ -206,3 +432,16 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 
 	return warnings, nil
 }
+
+// exportsTargetSnapshot reports whether export_snapshot may refer to the
+// target snapshot, which only exists once the build has run.
+func (c *Config) exportsTargetSnapshot() bool {
+	return c.TargetSnapshot != "" &&
+		(c.ExportSnapshot == c.TargetSnapshot || strings.HasSuffix(c.ExportSnapshot, "/"+c.TargetSnapshot))
+}
+
+// resumeSavedState tells whether the build resumes a live snapshot with the
+// memory it was saved with.
+func (c *Config) resumeSavedState() bool {
+	return c.ResumeFromSnapshot != "" && !c.ResumeDiscardState
+}
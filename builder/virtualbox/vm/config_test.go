@@ -0,0 +1,23 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigPrepare_keepSafetySnapshot(t *testing.T) {
+	var c Config
+	_, err := c.Prepare(map[string]interface{}{
+		"vm_name":              "foo",
+		"communicator":         "none",
+		"shutdown_command":     "poweroff",
+		"keep_safety_snapshot": true,
+		"target_snapshot":      "nightly",
+	})
+	if err == nil || !strings.Contains(err.Error(), "keep_safety_snapshot cannot be used with target_snapshot") {
+		t.Fatalf("should reject keep_safety_snapshot with target_snapshot: %v", err)
+	}
+}
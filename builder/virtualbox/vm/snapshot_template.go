@@ -0,0 +1,65 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"log"
+	"os/exec"
+	"strings"
+)
+
+const defaultTargetSnapshotDescription = `Built by Packer {{ .PackerVersion }} with packer-plugin-virtualbox {{ .PluginVersion }}
+Build: {{ .BuildName }}
+Source snapshot: {{ .SourceSnapshotName }} {{ .SourceSnapshotUUID }}
+Provisioners:{{ range .Provisioners }}
+- {{ . }}{{ else }} none{{ end }}`
+
+// targetSnapshotTemplateData is the data available to the target_snapshot
+// template.
+type targetSnapshotTemplateData struct {
+	BuildName   string
+	VMName      string
+	GitCommit   string
+	GitBranch   string
+	GitDescribe string
+}
+
+func newTargetSnapshotTemplateData(buildName, vmName, tpl string) *targetSnapshotTemplateData {
+	data := &targetSnapshotTemplateData{
+		BuildName: buildName,
+		VMName:    vmName,
+	}
+	// Only look for a git checkout when the template asks for it.
+	if strings.Contains(tpl, ".Git") {
+		data.GitCommit = git("rev-parse", "--short", "HEAD")
+		data.GitBranch = git("rev-parse", "--abbrev-ref", "HEAD")
+		data.GitDescribe = git("describe", "--tags", "--always", "--dirty")
+	}
+	return data
+}
+
+// snapshotDescriptionTemplateData is the data available to the
+// target_snapshot_description template.
+type snapshotDescriptionTemplateData struct {
+	BuildName          string
+	VMName             string
+	SnapshotName       string
+	PackerVersion      string
+	PluginVersion      string
+	SourceSnapshotName string
+	SourceSnapshotUUID string
+	// The provisioners of the build, such as "shell.setup".
+	Provisioners []string
+}
+
+// git runs git in the current directory and returns its trimmed output, or
+// an empty string if it fails.
+func git(args ...string) string {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		log.Printf("git %s: %s", strings.Join(args, " "), err)
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
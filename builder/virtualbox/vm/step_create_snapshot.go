@@ -10,17 +10,26 @@ import (
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
 	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+	"github.com/hashicorp/packer-plugin-virtualbox/version"
 )
 
 type StepCreateSnapshot struct {
 	Name           string
 	TargetSnapshot string
+	Description    string
+	Provisioners   []string
+	BuildName      string
+	PackerVersion  string
+	Ctx            interpolate.Context
 }
 
 func (s *StepCreateSnapshot) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
 	driver := state.Get("driver").(vboxcommon.Driver)
 	ui := state.Get("ui").(packersdk.Ui)
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
 	if s.TargetSnapshot != "" {
 		running, err := driver.IsRunning(s.Name)
 		if err != nil {
@@ -43,10 +52,28 @@ func (s *StepCreateSnapshot) Run(_ context.Context, state multistep.StateBag) mu
 			return multistep.ActionHalt
 		}
 
+		description := &snapshotDescriptionTemplateData{
+			BuildName:     s.BuildName,
+			VMName:        s.Name,
+			SnapshotName:  s.TargetSnapshot,
+			PackerVersion: s.PackerVersion,
+			PluginVersion: version.PluginVersion.FormattedVersion(),
+			Provisioners:  s.Provisioners,
+		}
+
 		// Remove any snapshot with the target's name, if present.
 		if snapshotTree != nil {
-			currentSnapshot := snapshotTree.GetCurrentSnapshot()
-			targetSnapshot := currentSnapshot.GetChildWithName(s.TargetSnapshot)
+			// The snapshot the build started from, rather than the safety
+			// snapshot taken on top of it
+			sourceSnapshot := snapshotTree.GetCurrentSnapshot()
+			if uuid, ok := state.GetOk("sourceSnapshotUUID"); ok {
+				if snapshot := snapshotTree.GetSnapshotByUUID(uuid.(string)); snapshot != nil {
+					sourceSnapshot = snapshot
+				}
+			}
+			description.SourceSnapshotName = sourceSnapshot.Name
+			description.SourceSnapshotUUID = sourceSnapshot.UUID
+			targetSnapshot := sourceSnapshot.GetChildWithName(s.TargetSnapshot)
 			if nil != targetSnapshot {
 				log.Printf("Deleting existing target snapshot %s", s.TargetSnapshot)
 				err = driver.DeleteSnapshot(s.Name, targetSnapshot)
@@ -59,15 +86,44 @@ func (s *StepCreateSnapshot) Run(_ context.Context, state multistep.StateBag) mu
 			}
 		}
 
-		err = driver.CreateSnapshot(s.Name, s.TargetSnapshot)
+		s.Ctx.Data = description
+		snapshotDescription, err := interpolate.Render(s.Description, &s.Ctx)
+		if err != nil {
+			err := fmt.Errorf("Error rendering target snapshot description: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+
+		err = driver.CreateSnapshot(s.Name, s.TargetSnapshot, snapshotDescription)
 		if err != nil {
 			err := fmt.Errorf("Error creating snaphot VM: %s", err)
 			state.Put("error", err)
 			ui.Error(err.Error())
 			return multistep.ActionHalt
 		}
+
+		// The snapshot just taken becomes the current one.
+		snapshotTree, err = driver.LoadSnapshots(s.Name)
+		if err != nil {
+			err = fmt.Errorf("Failed to load snapshots for VM %s: %s", s.Name, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		var uuid string
+		if snapshotTree != nil {
+			if current := snapshotTree.GetCurrentSnapshot(); current != nil {
+				uuid = current.UUID
+			}
+		}
+		log.Printf("Created snapshot %s/%s", s.TargetSnapshot, uuid)
+		generatedData.Put("TargetSnapshot", s.TargetSnapshot)
+		generatedData.Put("TargetSnapshotUUID", uuid)
 	} else {
 		ui.Say("No target snapshot defined...")
+		generatedData.Put("TargetSnapshot", "")
+		generatedData.Put("TargetSnapshotUUID", "")
 	}
 
 	return multistep.ActionContinue
@@ -0,0 +1,111 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepCreateSnapshot_impl(t *testing.T) {
+	var _ multistep.Step = new(StepCreateSnapshot)
+}
+
+func TestStepCreateSnapshot(t *testing.T) {
+	state := testState(t)
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	tree, err := vboxcommon.ParseSnapshotData(`SnapshotName="base"
+SnapshotUUID="7e5b4165-91ec-4091-a74c-a5709d584530"
+CurrentSnapshotUUID="7e5b4165-91ec-4091-a74c-a5709d584530"`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	driver.LoadSnapshotsResult = tree
+
+	step := &StepCreateSnapshot{
+		Name:           "foo",
+		TargetSnapshot: "nightly",
+		Description:    defaultTargetSnapshotDescription,
+		Provisioners:   []string{"shell.setup", "ansible"},
+		BuildName:      "vm.nightly",
+		PackerVersion:  "1.11.0",
+		Ctx:            *interpolate.NewContext(),
+	}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	assert.Equal(t, []string{"nightly"}, driver.CreateSnapshotCalled)
+	assert.Contains(t, driver.CreateSnapshotDescription[0], "Built by Packer 1.11.0 with packer-plugin-virtualbox ")
+	assert.Contains(t, driver.CreateSnapshotDescription[0], "Build: vm.nightly")
+	assert.Contains(t, driver.CreateSnapshotDescription[0], "Source snapshot: base 7e5b4165-91ec-4091-a74c-a5709d584530")
+	assert.Contains(t, driver.CreateSnapshotDescription[0], "Provisioners:\n- shell.setup\n- ansible")
+
+	generatedData := state.Get("generated_data").(map[string]interface{})
+	assert.Equal(t, "nightly", generatedData["TargetSnapshot"])
+	assert.NotEmpty(t, generatedData["TargetSnapshotUUID"])
+}
+
+func TestStepCreateSnapshot_safetySnapshot(t *testing.T) {
+	state := testState(t)
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	parse := func(data string) *vboxcommon.VBoxSnapshot {
+		tree, err := vboxcommon.ParseSnapshotData(data)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return tree
+	}
+	// The target of an earlier build is a child of the snapshot the build
+	// starts from, the safety snapshot is taken next to it
+	driver.LoadSnapshotsResults = []*vboxcommon.VBoxSnapshot{
+		parse(`SnapshotName="base"
+SnapshotUUID="00000000-0000-0000-0000-000000000000"
+SnapshotName-1="nightly"
+SnapshotUUID-1="00000000-0000-0000-0000-000000000001"
+CurrentSnapshotUUID="00000000-0000-0000-0000-000000000000"`),
+	}
+	driver.LoadSnapshotsResult = parse(`SnapshotName="base"
+SnapshotUUID="00000000-0000-0000-0000-000000000000"
+SnapshotName-1="nightly"
+SnapshotUUID-1="00000000-0000-0000-0000-000000000001"
+SnapshotName-2="packer-safety-1"
+SnapshotUUID-2="00000000-0000-0000-0000-000000000002"
+CurrentSnapshotUUID="00000000-0000-0000-0000-000000000002"`)
+
+	safety := &StepSafetySnapshot{Name: "foo"}
+	if action := safety.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+	step := &StepCreateSnapshot{
+		Name:           "foo",
+		TargetSnapshot: "nightly",
+		Description:    defaultTargetSnapshotDescription,
+		Ctx:            *interpolate.NewContext(),
+	}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	// force_delete_snapshot replaces the earlier target, and the source is
+	// the snapshot the build started from
+	if assert.Len(t, driver.DeleteSnapshotCalled, 1) {
+		assert.Equal(t, "00000000-0000-0000-0000-000000000001", driver.DeleteSnapshotCalled[0].UUID)
+	}
+	assert.Contains(t, driver.CreateSnapshotDescription[1], "Source snapshot: base 00000000-0000-0000-0000-000000000000")
+}
+
+func TestTargetSnapshotTemplateData(t *testing.T) {
+	ctx := interpolate.NewContext()
+	ctx.Data = newTargetSnapshotTemplateData("vm.nightly", "foo", "")
+
+	name, err := interpolate.Render("{{ .BuildName }}-{{ .VMName }}{{ .GitCommit }}", ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "vm.nightly-foo", name)
+}
@@ -0,0 +1,149 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+)
+
+// This step clones a snapshot of the VM to a temporary VM, which StepExport
+// exports instead of the VM itself.
+//
+// Produces:
+//
+//	exportVMName string - The name of the VM to export.
+type StepExportSnapshot struct {
+	Name       string
+	Snapshot   string
+	SkipExport bool
+
+	cloneName string
+}
+
+func (s *StepExportSnapshot) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Snapshot == "" || s.SkipExport {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	snapshotTree, err := driver.LoadSnapshots(s.Name)
+	if err != nil {
+		err := fmt.Errorf("Failed to load snapshots for VM %s: %s", s.Name, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	var candidates []*vboxcommon.VBoxSnapshot
+	if snapshotTree != nil {
+		candidates = findSnapshots(snapshotTree, s.Snapshot)
+	}
+	if len(candidates) != 1 {
+		err := fmt.Errorf("Snapshot %s to export does not exist on VM %s", s.Snapshot, s.Name)
+		if len(candidates) > 1 {
+			err = fmt.Errorf("Snapshot %s to export is ambiguous, set export_snapshot to a UUID or a path. Candidates are:\n%s",
+				s.Snapshot, describeSnapshots(candidates))
+		}
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	snapshot := candidates[0]
+
+	cloneName := fmt.Sprintf("packer-export-%s-%d", s.Name, time.Now().Unix())
+	ui.Say(fmt.Sprintf("Cloning snapshot %s of virtual machine %s to %s for export", snapshot.Path(), s.Name, cloneName))
+	// A linked clone shares the disks of the snapshot, which is much faster
+	// than copying them. The MAC addresses are kept as they were at the
+	// snapshot, export_mac_address_policy applies to the clone.
+	command := []string{
+		"clonevm", s.Name,
+		"--snapshot", snapshot.UUID,
+		"--options", "Link,KeepAllMACs",
+		"--name", cloneName,
+		"--register",
+	}
+	if err := driver.VBoxManage(command...); err != nil {
+		err := fmt.Errorf("Error cloning snapshot %s of VM %s: %s", snapshot.Name, s.Name, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	s.cloneName = cloneName
+	if err := vboxcommon.TagVM(driver, cloneName); err != nil {
+		log.Printf("Error tagging VM %s: %s", cloneName, err)
+	}
+
+	if err := prepareExportClone(driver, cloneName); err != nil {
+		err := fmt.Errorf("Error preparing clone %s for export: %s", cloneName, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	state.Put("exportVMName", cloneName)
+	return multistep.ActionContinue
+}
+
+func (s *StepExportSnapshot) Cleanup(state multistep.StateBag) {
+	if s.cloneName == "" {
+		return
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	ui.Say(fmt.Sprintf("Deleting export clone %s", s.cloneName))
+	if err := driver.Delete(s.cloneName); err != nil {
+		ui.Error(fmt.Sprintf("Error deleting export clone %s: %s", s.cloneName, err))
+	}
+}
+
+// prepareExportClone removes what an earlier build left behind in the
+// snapshot and should not be exported: the saved state of a live snapshot,
+// the communicator port forwarding rule and the network traffic capture.
+func prepareExportClone(driver vboxcommon.Driver, name string) error {
+	if err := discardSavedState(driver, name); err != nil {
+		return err
+	}
+
+	output, err := driver.VBoxManageWithOutput("showvminfo", name, "--machinereadable")
+	if err != nil {
+		return err
+	}
+	info := vboxcommon.ParseVMInfo(output)
+	keys := make([]string, 0, len(info))
+	for key := range info {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	command := []string{"modifyvm", name}
+	forwarded := false
+	for _, key := range keys {
+		value := info[key]
+		if strings.HasPrefix(key, "nictrace") && value == "on" {
+			command = append(command, "--"+key, "off")
+		}
+		if strings.HasPrefix(key, "Forwarding(") && strings.HasPrefix(value, "packercomm,") {
+			forwarded = true
+		}
+	}
+	if forwarded {
+		command = append(command, "--natpf1", "delete", "packercomm")
+	}
+	if len(command) == 2 {
+		return nil
+	}
+	return driver.VBoxManage(command...)
+}
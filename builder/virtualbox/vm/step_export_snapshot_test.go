@@ -0,0 +1,68 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepExportSnapshot_impl(t *testing.T) {
+	var _ multistep.Step = new(StepExportSnapshot)
+}
+
+func TestStepExportSnapshot(t *testing.T) {
+	state := testState(t)
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	driver.LoadSnapshotsResult = testCleanSnapshots(t)
+	driver.VBoxManageWithOutputResult = `VMState="poweroff"
+nictrace1="on"
+nictracefile1="/tmp/trace.pcap"
+Forwarding(0)="packercomm,tcp,127.0.0.1,2222,,22"`
+	step := &StepExportSnapshot{Name: "foo", Snapshot: "base/other/clean"}
+
+	assert.Equal(t, multistep.ActionContinue, step.Run(context.Background(), state))
+
+	clone := state.Get("exportVMName").(string)
+	assert.True(t, strings.HasPrefix(clone, "packer-export-foo-"))
+	assert.Equal(t, []string{"clonevm", "foo", "--snapshot", "00000000-0000-0000-0000-000000000021",
+		"--options", "Link,KeepAllMACs", "--name", clone, "--register"}, driver.VBoxManageCalls[0])
+	assert.Contains(t, driver.VBoxManageCalls,
+		[]string{"modifyvm", clone, "--nictrace1", "off", "--natpf1", "delete", "packercomm"})
+
+	// The clone is deleted once exported
+	step.Cleanup(state)
+	assert.True(t, driver.DeleteCalled)
+	assert.Equal(t, clone, driver.DeleteName)
+}
+
+func TestStepExportSnapshot_ambiguous(t *testing.T) {
+	state := testState(t)
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	driver.LoadSnapshotsResult = testCleanSnapshots(t)
+	step := &StepExportSnapshot{Name: "foo", Snapshot: "clean"}
+
+	assert.Equal(t, multistep.ActionHalt, step.Run(context.Background(), state))
+	assert.Contains(t, state.Get("error").(error).Error(), "set export_snapshot to a UUID or a path")
+	assert.Empty(t, driver.VBoxManageCalls)
+
+	step.Cleanup(state)
+	assert.False(t, driver.DeleteCalled)
+}
+
+func TestStepExportSnapshot_disabled(t *testing.T) {
+	state := testState(t)
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	step := &StepExportSnapshot{Name: "foo", Snapshot: "clean", SkipExport: true}
+
+	assert.Equal(t, multistep.ActionContinue, step.Run(context.Background(), state))
+	assert.Empty(t, driver.LoadSnapshotsCalled)
+	_, ok := state.GetOk("exportVMName")
+	assert.False(t, ok)
+}
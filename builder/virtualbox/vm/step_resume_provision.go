@@ -0,0 +1,60 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+)
+
+// This step runs the provisioners, which in a build resumed from the live
+// snapshot of a milestone skip what was done before it, with the milestones
+// passed listed by build.CompletedMilestones. The resumed build then fails
+// if the provisioners reported one of those milestones again, or didn't
+// reach the others.
+//
+// Uses:
+//
+//	communicator packersdk.Communicator
+//	driver Driver
+//	hook packersdk.Hook
+//	ui packersdk.Ui
+//	vmName string
+type StepResumeProvision struct {
+	// The milestone the build is resumed from, if any.
+	Milestone string
+	// The step watching for the milestones.
+	LiveSnapshots *vboxcommon.StepLiveSnapshots
+
+	provision commonsteps.StepProvision
+}
+
+func (s *StepResumeProvision) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packersdk.Ui)
+
+	if s.Milestone == "" {
+		return s.provision.Run(ctx, state)
+	}
+
+	ui.Say(fmt.Sprintf("Resuming provisioning after milestone %s", s.Milestone))
+	action := s.provision.Run(ctx, state)
+	if action != multistep.ActionContinue {
+		return action
+	}
+	if err := s.LiveSnapshots.Check(state); err != nil {
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	return action
+}
+
+func (s *StepResumeProvision) Cleanup(state multistep.StateBag) {
+	s.provision.Cleanup(state)
+}
@@ -0,0 +1,84 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+)
+
+func TestStepResumeProvision_impl(t *testing.T) {
+	var _ multistep.Step = new(StepResumeProvision)
+}
+
+// testResumeProvision runs a build resumed from the updates milestone, in
+// which the guest reports the given milestone.
+func testResumeProvision(t *testing.T, reported string) (multistep.StateBag, *packersdk.MockCommunicator, multistep.StepAction) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	if reported != "" {
+		driver.VBoxManageWithOutputResult = "Value: " + reported + "\n"
+	}
+	comm := new(packersdk.MockCommunicator)
+	state.Put("communicator", comm)
+	hook := new(packersdk.MockHook)
+	hook.RunFunc = func(ctx context.Context) error {
+		cmd := &packersdk.RemoteCmd{Command: "/tmp/script_1.sh"}
+		return cmd.RunWithUi(ctx, hook.RunComm, hook.RunUi)
+	}
+	state.Put("hook", hook)
+
+	liveSnapshots := &vboxcommon.StepLiveSnapshots{
+		Milestones: []string{"updates", "drivers"},
+		Prefix:     "packer-milestone-",
+		Completed:  []string{"updates"},
+	}
+	if action := liveSnapshots.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	defer liveSnapshots.Cleanup(state)
+
+	step := &StepResumeProvision{
+		Milestone:     "updates",
+		LiveSnapshots: liveSnapshots,
+	}
+	action := step.Run(context.Background(), state)
+	return state, comm, action
+}
+
+func TestStepResumeProvision(t *testing.T) {
+	state, comm, action := testResumeProvision(t, "drivers")
+	if action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+	if comm.StartCmd == nil || comm.StartCmd.Command != "/tmp/script_1.sh" {
+		t.Fatalf("the provisioners should run: %#v", comm.StartCmd)
+	}
+}
+
+func TestStepResumeProvision_reportedAgain(t *testing.T) {
+	state, _, action := testResumeProvision(t, "updates")
+	if action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if err := state.Get("error").(error); !strings.Contains(err.Error(), "updates again") {
+		t.Fatalf("bad error: %s", err)
+	}
+}
+
+func TestStepResumeProvision_notReached(t *testing.T) {
+	state, _, action := testResumeProvision(t, "")
+	if action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if err := state.Get("error").(error); !strings.Contains(err.Error(), "without reaching milestones drivers") {
+		t.Fatalf("bad error: %s", err)
+	}
+}
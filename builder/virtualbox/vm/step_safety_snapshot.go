@@ -0,0 +1,128 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+)
+
+// StepSafetySnapshot snapshots the VM before the build changes its settings
+// or starts it, so that a failed or cancelled build leaves it as it was.
+//
+// Produces:
+//
+//	sourceSnapshotUUID string - The snapshot that was current before the
+//	safety snapshot was taken, if any.
+type StepSafetySnapshot struct {
+	Name string
+	// Keep the snapshot once the build succeeded.
+	Keep bool
+
+	uuid string
+}
+
+func (s *StepSafetySnapshot) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	// The safety snapshot becomes the current one: the steps looking for
+	// the snapshot the build started from use this one instead.
+	snapshotTree, err := driver.LoadSnapshots(s.Name)
+	if err != nil {
+		err := fmt.Errorf("Failed to load snapshots for VM %s: %s", s.Name, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if snapshotTree != nil {
+		if current := snapshotTree.GetCurrentSnapshot(); current != nil {
+			state.Put("sourceSnapshotUUID", current.UUID)
+		}
+	}
+
+	name := fmt.Sprintf("packer-safety-%d", time.Now().Unix())
+	ui.Say(fmt.Sprintf("Creating safety snapshot %s on virtual machine %s", name, s.Name))
+	description := "Taken by Packer before the build, to roll back to if it fails"
+	err = driver.CreateSnapshot(s.Name, name, description)
+	if err == nil {
+		snapshotTree, err = driver.LoadSnapshots(s.Name)
+	}
+	if err == nil && snapshotTree == nil {
+		err = fmt.Errorf("snapshot %s not found", name)
+	}
+	if err != nil {
+		err := fmt.Errorf("Error creating safety snapshot on VM %s: %s", s.Name, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	// The snapshot just taken is the current one
+	s.uuid = snapshotTree.GetCurrentSnapshot().UUID
+	return multistep.ActionContinue
+}
+
+func (s *StepSafetySnapshot) Cleanup(state multistep.StateBag) {
+	if s.uuid == "" {
+		return
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	failed := cancelled || halted
+	if !failed && s.Keep {
+		ui.Say("Keeping safety snapshot (keep_safety_snapshot = true)")
+		return
+	}
+
+	snapshotTree, err := driver.LoadSnapshots(s.Name)
+	if err == nil && snapshotTree == nil {
+		err = fmt.Errorf("no snapshots found")
+	}
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error loading snapshots of VM %s: %s", s.Name, err))
+		return
+	}
+	snapshot := snapshotTree.GetSnapshotByUUID(s.uuid)
+	if snapshot == nil {
+		ui.Error(fmt.Sprintf("Safety snapshot %s not found on VM %s", s.uuid, s.Name))
+		return
+	}
+
+	if failed {
+		ui.Say(fmt.Sprintf("Rolling back virtual machine %s to safety snapshot %s", s.Name, snapshot.Name))
+		if err := driver.SetSnapshot(s.Name, snapshot); err != nil {
+			// Keep the snapshot to roll back by hand
+			ui.Error(fmt.Sprintf("Error rolling back to safety snapshot %s, it is kept: %s", snapshot.Name, err))
+			return
+		}
+		// The snapshots taken by the build, such as the live snapshots kept
+		// to resume it, branch off the safety snapshot besides the current
+		// state now: VirtualBox can't delete it.
+		if len(snapshot.Children) > 0 {
+			var names []string
+			for _, child := range snapshot.Children {
+				names = append(names, child.Name)
+			}
+			ui.Say(fmt.Sprintf("Keeping safety snapshot %s, snapshots %s were taken on top of it",
+				snapshot.Name, strings.Join(names, ", ")))
+			return
+		}
+	}
+
+	ui.Say(fmt.Sprintf("Deleting safety snapshot %s", snapshot.Name))
+	if err := driver.DeleteSnapshot(s.Name, snapshot); err != nil {
+		ui.Error(fmt.Sprintf("Error deleting safety snapshot %s: %s", snapshot.Name, err))
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepSafetySnapshot_impl(t *testing.T) {
+	var _ multistep.Step = new(StepSafetySnapshot)
+}
+
+func testSafetySnapshotState(t *testing.T) multistep.StateBag {
+	state := testState(t)
+	tree, err := vboxcommon.ParseSnapshotData(`SnapshotName="base"
+SnapshotUUID="00000000-0000-0000-0000-000000000000"
+SnapshotName-1="packer-safety-1"
+SnapshotUUID-1="00000000-0000-0000-0000-000000000001"
+CurrentSnapshotUUID="00000000-0000-0000-0000-000000000001"`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	state.Get("driver").(*vboxcommon.DriverMock).LoadSnapshotsResult = tree
+	return state
+}
+
+func TestStepSafetySnapshot(t *testing.T) {
+	state := testSafetySnapshotState(t)
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	step := &StepSafetySnapshot{Name: "foo"}
+
+	before, err := vboxcommon.ParseSnapshotData(`SnapshotName="base"
+SnapshotUUID="00000000-0000-0000-0000-000000000000"
+CurrentSnapshotUUID="00000000-0000-0000-0000-000000000000"`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	driver.LoadSnapshotsResults = []*vboxcommon.VBoxSnapshot{before}
+
+	assert.Equal(t, multistep.ActionContinue, step.Run(context.Background(), state))
+	assert.Len(t, driver.CreateSnapshotCalled, 1)
+	// The snapshot the build started from
+	assert.Equal(t, "00000000-0000-0000-0000-000000000000", state.Get("sourceSnapshotUUID"))
+
+	// A successful build deletes the snapshot without rolling back
+	step.Cleanup(state)
+	assert.Empty(t, driver.SetSnapshotCalled)
+	assert.Equal(t, []string{"00000000-0000-0000-0000-000000000001"}, uuids(driver.DeleteSnapshotCalled))
+}
+
+func TestStepSafetySnapshot_keep(t *testing.T) {
+	state := testSafetySnapshotState(t)
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	step := &StepSafetySnapshot{Name: "foo", Keep: true}
+
+	assert.Equal(t, multistep.ActionContinue, step.Run(context.Background(), state))
+	step.Cleanup(state)
+	assert.Empty(t, driver.SetSnapshotCalled)
+	assert.Empty(t, driver.DeleteSnapshotCalled)
+}
+
+func TestStepSafetySnapshot_failed(t *testing.T) {
+	state := testSafetySnapshotState(t)
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	step := &StepSafetySnapshot{Name: "foo", Keep: true}
+
+	assert.Equal(t, multistep.ActionContinue, step.Run(context.Background(), state))
+
+	// A failed build rolls back and deletes the snapshot, even when kept
+	state.Put(multistep.StateHalted, true)
+	step.Cleanup(state)
+	assert.Equal(t, []string{"00000000-0000-0000-0000-000000000001"}, uuids(driver.SetSnapshotCalled))
+	assert.Equal(t, []string{"00000000-0000-0000-0000-000000000001"}, uuids(driver.DeleteSnapshotCalled))
+}
+
+func uuids(snapshots []*vboxcommon.VBoxSnapshot) []string {
+	var result []string
+	for _, sn := range snapshots {
+		result = append(result, sn.UUID)
+	}
+	return result
+}
+
+func TestStepSafetySnapshot_failedWithSnapshots(t *testing.T) {
+	state := testState(t)
+	driver := state.Get("driver").(*vboxcommon.DriverMock)
+	// A live snapshot was taken on top of the safety snapshot
+	tree, err := vboxcommon.ParseSnapshotData(`SnapshotName="base"
+SnapshotUUID="00000000-0000-0000-0000-000000000000"
+SnapshotName-1="packer-safety-1"
+SnapshotUUID-1="00000000-0000-0000-0000-000000000001"
+SnapshotName-1-1="packer-milestone-updates"
+SnapshotUUID-1-1="00000000-0000-0000-0000-000000000002"
+CurrentSnapshotUUID="00000000-0000-0000-0000-000000000002"`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	driver.LoadSnapshotsResult = tree
+	step := &StepSafetySnapshot{Name: "foo", uuid: "00000000-0000-0000-0000-000000000001"}
+
+	// The VM is rolled back, and the snapshot the live one depends on kept
+	state.Put(multistep.StateHalted, true)
+	step.Cleanup(state)
+	assert.Equal(t, []string{"00000000-0000-0000-0000-000000000001"}, uuids(driver.SetSnapshotCalled))
+	assert.Empty(t, driver.DeleteSnapshotCalled)
+}
@@ -15,7 +15,9 @@ import (
 type StepSetSnapshot struct {
 	Name             string
 	AttachSnapshot   string
+	Strategy         string
 	KeepRegistered   bool
+	DiscardState     bool
 	revertToSnapshot string
 }
 
@@ -40,21 +42,29 @@ func (s *StepSetSnapshot) Run(_ context.Context, state multistep.StateBag) multi
 		currentSnapshot := snapshotTree.GetCurrentSnapshot()
 		s.revertToSnapshot = currentSnapshot.UUID
 		ui.Say(fmt.Sprintf("Attaching snapshot %s on virtual machine %s", s.AttachSnapshot, s.Name))
-		candidateSnapshots := snapshotTree.GetSnapshotsByName(s.AttachSnapshot)
-		if 0 >= len(candidateSnapshots) {
-			err := fmt.Errorf("Snapshot %s not found on VM %s", s.AttachSnapshot, s.Name)
+		if s.Strategy == attachSnapshotStrategyLatest {
+			err = driver.LoadSnapshotTimeStamps(s.Name, snapshotTree)
+		}
+		var snapshot *vboxcommon.VBoxSnapshot
+		if err == nil {
+			snapshot, err = resolveSnapshot(snapshotTree, s.AttachSnapshot, s.Strategy)
+		}
+		if err != nil {
+			err := fmt.Errorf("Unable to attach snapshot on VM %s: %s", s.Name, err)
 			state.Put("error", err)
 			ui.Error(err.Error())
 			return multistep.ActionHalt
-		} else if 1 > len(candidateSnapshots) {
-			err := fmt.Errorf("More than one Snapshot %s found on VM %s", s.AttachSnapshot, s.Name)
+		}
+		err = driver.SetSnapshot(s.Name, snapshot)
+		if err != nil {
+			err := fmt.Errorf("Unable to set snapshot for VM: %s", err)
 			state.Put("error", err)
 			ui.Error(err.Error())
 			return multistep.ActionHalt
-		} else {
-			err = driver.SetSnapshot(s.Name, candidateSnapshots[0])
-			if err != nil {
-				err := fmt.Errorf("Unable to set snapshot for VM: %s", err)
+		}
+		if s.DiscardState {
+			if err := discardSavedState(driver, s.Name); err != nil {
+				err := fmt.Errorf("Unable to discard the saved state of VM %s: %s", s.Name, err)
 				state.Put("error", err)
 				ui.Error(err.Error())
 				return multistep.ActionHalt
@@ -97,3 +107,16 @@ func (s *StepSetSnapshot) Cleanup(state multistep.StateBag) {
 		}
 	}
 }
+
+// discardSavedState drops the memory state restored from a live snapshot, if
+// any, so that the VM can be modified and booted again.
+func discardSavedState(driver vboxcommon.Driver, name string) error {
+	output, err := driver.VBoxManageWithOutput("showvminfo", name, "--machinereadable")
+	if err != nil {
+		return err
+	}
+	if vboxcommon.ParseVMInfo(output)["VMState"] != "saved" {
+		return nil
+	}
+	return driver.VBoxManage("discardstate", name)
+}
@@ -0,0 +1,115 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+)
+
+// StepSnapshotRetention deletes the snapshots matching Prefix that are
+// beyond the newest Count or older than MaxAge.
+type StepSnapshotRetention struct {
+	Name           string
+	AttachSnapshot string
+	ExportSnapshot string
+	Prefix         string
+	Count          int
+	MaxAge         time.Duration
+}
+
+func (s *StepSnapshotRetention) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Count == 0 && s.MaxAge == 0 {
+		return multistep.ActionContinue
+	}
+
+	driver := state.Get("driver").(vboxcommon.Driver)
+	ui := state.Get("ui").(packersdk.Ui)
+
+	snapshotTree, err := driver.LoadSnapshots(s.Name)
+	if err == nil {
+		err = driver.LoadSnapshotTimeStamps(s.Name, snapshotTree)
+	}
+	if err != nil {
+		err = fmt.Errorf("Failed to load snapshots for VM %s: %s", s.Name, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if snapshotTree == nil {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Applying retention to snapshots %s* of virtual machine %s", s.Prefix, s.Name))
+	for _, snapshot := range s.expired(snapshotTree, time.Now()) {
+		if len(snapshot.Children) > 1 {
+			ui.Say(fmt.Sprintf("Keeping snapshot %s, it has more than one child", snapshot.Path()))
+			continue
+		}
+		ui.Say(fmt.Sprintf("Deleting snapshot %s", snapshot.Path()))
+		if err := driver.DeleteSnapshot(s.Name, snapshot); err != nil {
+			err = fmt.Errorf("Unable to delete snapshot %s from VM %s: %s", snapshot.Name, s.Name, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepSnapshotRetention) Cleanup(state multistep.StateBag) {}
+
+// expired returns the snapshots the retention policy deletes, oldest first.
+// The current, attached and exported snapshots and their ancestors are kept.
+func (s *StepSnapshotRetention) expired(snapshotTree *vboxcommon.VBoxSnapshot, now time.Time) []*vboxcommon.VBoxSnapshot {
+	protected := make(map[string]bool)
+	protect := func(sn *vboxcommon.VBoxSnapshot) {
+		for ; sn != nil; sn = sn.Parent {
+			protected[sn.UUID] = true
+		}
+	}
+	protect(snapshotTree.GetCurrentSnapshot())
+	for _, spec := range []string{s.AttachSnapshot, s.ExportSnapshot} {
+		if spec == "" {
+			continue
+		}
+		for _, sn := range findSnapshots(snapshotTree, spec) {
+			protect(sn)
+		}
+	}
+
+	var matching []*vboxcommon.VBoxSnapshot
+	for _, sn := range snapshotTree.GetSnapshots() {
+		if strings.HasPrefix(sn.Name, s.Prefix) {
+			matching = append(matching, sn)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].TimeStamp.After(matching[j].TimeStamp)
+	})
+
+	var result []*vboxcommon.VBoxSnapshot
+	for i, sn := range matching {
+		tooMany := s.Count > 0 && i >= s.Count
+		tooOld := s.MaxAge > 0 && !sn.TimeStamp.IsZero() && now.Sub(sn.TimeStamp) > s.MaxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if protected[sn.UUID] {
+			log.Printf("Keeping snapshot %s/%s, it is in use", sn.Name, sn.UUID)
+			continue
+		}
+		result = append([]*vboxcommon.VBoxSnapshot{sn}, result...)
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+// Copyright IBM Corp. 2013, 2026
+// SPDX-License-Identifier: MPL-2.0
+
+package vm
+
+import (
+	"testing"
+	"time"
+
+	vboxcommon "github.com/hashicorp/packer-plugin-virtualbox/builder/virtualbox/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSnapshotTree(t *testing.T, now time.Time) *vboxcommon.VBoxSnapshot {
+	tree, err := vboxcommon.ParseSnapshotData(`SnapshotName="base"
+SnapshotUUID="00000000-0000-0000-0000-000000000000"
+SnapshotName-1="nightly-1"
+SnapshotUUID-1="00000000-0000-0000-0000-000000000001"
+SnapshotName-1-1="nightly-1-patched"
+SnapshotUUID-1-1="00000000-0000-0000-0000-000000000011"
+SnapshotName-2="nightly-2"
+SnapshotUUID-2="00000000-0000-0000-0000-000000000002"
+SnapshotName-3="nightly-3"
+SnapshotUUID-3="00000000-0000-0000-0000-000000000003"
+SnapshotName-4="nightly-4"
+SnapshotUUID-4="00000000-0000-0000-0000-000000000004"
+CurrentSnapshotUUID="00000000-0000-0000-0000-000000000004"`)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// One snapshot a day, the newest taken an hour ago
+	for i, name := range []string{"nightly-4", "nightly-3", "nightly-2", "nightly-1-patched", "nightly-1", "base"} {
+		tree.GetSnapshotsByName(name)[0].TimeStamp = now.Add(-time.Hour - time.Duration(i)*24*time.Hour)
+	}
+	return tree
+}
+
+func names(snapshots []*vboxcommon.VBoxSnapshot) []string {
+	var result []string
+	for _, sn := range snapshots {
+		result = append(result, sn.Name)
+	}
+	return result
+}
+
+func TestStepSnapshotRetention_count(t *testing.T) {
+	now := time.Now()
+	step := &StepSnapshotRetention{Prefix: "nightly-", Count: 2}
+
+	assert.Equal(t, []string{"nightly-1", "nightly-1-patched", "nightly-2"}, names(step.expired(testSnapshotTree(t, now), now)))
+}
+
+func TestStepSnapshotRetention_maxAge(t *testing.T) {
+	now := time.Now()
+	step := &StepSnapshotRetention{Prefix: "nightly-", MaxAge: 60 * time.Hour}
+
+	assert.Equal(t, []string{"nightly-1", "nightly-1-patched"}, names(step.expired(testSnapshotTree(t, now), now)))
+}
+
+func TestStepSnapshotRetention_protected(t *testing.T) {
+	now := time.Now()
+
+	// The attached snapshot and its ancestors are kept, as is the current
+	// snapshot even when it is over the limit
+	step := &StepSnapshotRetention{Prefix: "nightly-", Count: 1, AttachSnapshot: "nightly-1-patched"}
+	tree := testSnapshotTree(t, now)
+	tree.GetSnapshotsByName("nightly-4")[0].TimeStamp = now.Add(-30 * 24 * time.Hour)
+
+	assert.Equal(t, []string{"nightly-2"}, names(step.expired(tree, now)))
+}